@@ -65,6 +65,62 @@ HTTP and HTTPS URLs are supported. For instance,
 	checktypes:
 	  - https://example.com/checktypes.json
 
+Transient HTTP failures, like connection errors and 5xx or 429 status
+codes, are retried up to 4 times with exponential backoff.
+Gzip-compressed responses are supported.
+
+If the HTTP server returns an ETag header, the catalog is cached in
+the "catalogs" directory of the Lava home directory. In the following
+runs, the catalog is only downloaded again if it has changed. Corrupt
+cache entries are ignored. For more details about the Lava home
+directory, use "lava help environment".
+
+Catalogs stored in S3 buckets are supported using URLs with the form
+s3://bucket/key. The AWS credentials and region are resolved like in
+the AWS CLI, using the environment and the shared config files. For
+instance,
+
+	checktypes:
+	  - s3://example-bucket/checktypes.json
+
+Catalogs published as OCI artifacts are supported using the "oci"
+scheme followed by an image reference. If the reference does not
+specify a tag or a digest, "latest" is used. The credentials of the
+registry are read from the Docker config file, like in "docker pull".
+The artifact layer with a JSON media type is used. Otherwise, the
+artifact must have a single layer. If it is a tar archive, optionally
+gzip-compressed, the first JSON file of the archive is used. For
+instance,
+
+	checktypes:
+	  - oci://registry.example.com/lava/checktypes:v1
+
+The catalogs are retrieved concurrently, but they are always merged in
+the order they are specified. So, if a checktype is defined in several
+catalogs, the definition of the last catalog takes precedence.
+
+A catalog can also be specified with a mapping. The "url" field
+contains the URL of the catalog and the "priority" field its priority,
+which is 0 by default. If a checktype is defined in several catalogs,
+the definition of the catalog with the highest priority takes
+precedence, regardless of the order of the catalogs. Ties are resolved
+using the order of the catalogs. Any priority declared inside the
+catalog itself is ignored. For instance,
+
+	checktypes:
+	  - https://example.com/checktypes.json
+	  - url: internal.json
+	    priority: 10
+
+The "auth" field of the mapping specifies whether the bearer token in
+the environment variable LAVA_CATALOG_TOKEN is sent when retrieving
+the catalog via HTTP or HTTPS. It is false by default, so the token
+is only sent to the catalogs that explicitly enable it. For instance,
+
+	checktypes:
+	  - url: https://internal.example.com/checktypes.json
+	    auth: true
+
 At least one catalog must be specified.
 
 # targets
@@ -76,9 +132,40 @@ is defined by the following properties:
     path, a URL, a container image, etc. It is mandatory.
   - type: the asset type of the target. Valid values are "AWSAccount",
     "DockerImage", "GitRepository", "IP", "IPRange", "DomainName",
-    "Hostname", "WebAddress" and "Path". It is mandatory.
+    "Hostname", "WebAddress" and "Path". It is mandatory, unless
+    "detectAssetType" is enabled in "targetDefaults".
   - options: map of target-specific options. These options are merged
     with the options coming from the checktype catalog.
+  - labels: map of arbitrary key-value pairs attached to the target.
+    For instance, the owner or the environment of the target. Labels
+    do not affect the executed checks, they are included in the
+    findings reported for the target. In the JSON formats, they are
+    stored in the "target_labels" field of the findings and, in the
+    SARIF format, in the "target_labels" property of the results.
+  - credentials: credentials used by the checks to authenticate
+    against the target. It accepts either a "token" for bearer
+    authentication, a "username" and a "password" for basic
+    authentication or an "sshKey" with a private key for SSH
+    authentication. The values can reference environment variables
+    using the syntax $VAR or ${VAR}.
+  - include: list of checktype names or glob patterns. If specified,
+    only the matching checktypes are run against the target.
+  - exclude: list of checktype names or glob patterns. The matching
+    checktypes are not run against the target. It takes precedence
+    over "include".
+
+Targets that only differ in their labels are considered duplicated.
+They are scanned only once and their labels are merged. The number of
+duplicated targets is logged and included in the metrics report. The
+duplicated targets are listed when the log level is "debug".
+
+Similarly, checks that would run the same checktype with the same
+options and credentials against the same asset are only run once. Two
+targets refer to the same asset if they have the same type and their
+identifiers are equivalent. For instance, "Example.com." and
+"example.com" are the same DomainName, and "https://example.com:443"
+and "https://example.com/" are the same WebAddress. Labels of the
+duplicated checks are merged.
 
 For instance,
 
@@ -87,8 +174,148 @@ For instance,
 	    type: GitRepository
 	    options:
 	      branch: master
+	    labels:
+	      owner: team-a
+	    exclude:
+	      - vulcan-gitleaks
+	  - identifier: https://example.com
+	    type: WebAddress
+	    credentials:
+	      token: ${EXAMPLE_TOKEN}
+	    include:
+	      - vulcan-zap
+	      - vulcan-nuclei*
+
+The credentials are passed to the checks using the following
+environment variables:
+
+  - LAVA_TARGET_AUTH_TYPE: "basic", "bearer" or "ssh".
+  - LAVA_TARGET_AUTH_USERNAME: username for basic authentication.
+  - LAVA_TARGET_AUTH_PASSWORD: password for basic authentication.
+  - LAVA_TARGET_AUTH_TOKEN: token for bearer authentication.
+  - LAVA_TARGET_AUTH_SSH_KEY: private key for SSH authentication.
+
+Checktypes that clone Git repositories are expected to use these
+variables as follows. With "bearer", the token is used as the password
+of HTTPS URLs. With "basic", the username and the password are used
+for HTTPS URLs. With "ssh", the private key is used for SSH URLs, for
+instance through the GIT_SSH_COMMAND environment variable. Public
+repositories do not require credentials. For instance,
 
-At least one target must be specified.
+	targets:
+	  - identifier: git@github.com:example/private.git
+	    type: GitRepository
+	    credentials:
+	      sshKey: ${GIT_SSH_KEY}
+
+Credentials are injected into the check containers when they are
+created. They are never logged nor stored in the container images.
+They are not part of the check options either, so they are not
+included in the jobs nor in the reports. Secrets shared by all the
+checks, like the variables required by a checktype, can be passed
+using the "vars" property of the agent configuration.
+
+The identifier of a DockerImage target is an image reference, like
+"alpine:3.18" or "registry.example.com/app:1.0", which is passed as is
+to the checks. Local images can be scanned, because the Docker socket
+is shared with the checks that scan DockerImage targets when the
+Docker daemon is reachable through a Unix socket.
+
+At least one target must be specified, either in the "targets" field
+or through a target source.
+
+# targetSources
+
+The "targetSources" field contains a list of external sources of
+targets. Every source is defined by the following properties:
+
+  - type: the type of the source. Valid values are "terraform", which
+    reads the targets from a Terraform state file, "csv", which reads
+    the targets from a CSV file with headers, and "list", which reads
+    the targets from a text file with one target per line. It is
+    mandatory.
+  - url: URL of the source data. If the URL omits the scheme, it is
+    considered a file path. It is mandatory.
+  - mappings: list of rules that define how resources are converted
+    into targets. Every rule is defined by the properties "resource"
+    (resource type), "attribute" (resource attribute used as target
+    identifier) and "type" (asset type of the target). If not
+    specified, a default set of rules is used. Resources that do not
+    match any rule are skipped. It only applies to Terraform sources.
+  - columns: names of the CSV columns that contain the target
+    identifier (property "identifier") and the asset type (property
+    "type"). If not specified, the columns "identifier" and "type"
+    are used. It only applies to CSV sources.
+
+For instance,
+
+	targetSources:
+	  - type: terraform
+	    url: terraform.tfstate
+	    mappings:
+	      - resource: aws_eip
+	        attribute: public_ip
+	        type: IP
+
+The generated targets are labeled with the address ("terraform_address")
+and type ("terraform_type") of the corresponding resource.
+
+The targets generated from a CSV file are labeled with the rest of
+the columns of the corresponding row, using the column name as label
+name. Empty values are ignored. Rows with an empty identifier or an
+invalid asset type are skipped and logged with their line number. For
+instance, the following CSV file
+
+	identifier,type,environment,owner
+	example.com,DomainName,production,team-a
+	192.0.2.1,IP,staging,team-b
+
+generates two targets labeled with "environment" and "owner".
+
+Every line of a list file contains either the identifier and the asset
+type of a target separated by white space, or a JSON object with the
+properties "identifier", "type" and, optionally, "labels". Blank lines
+and lines starting with "#" are ignored. A malformed line makes the
+scan fail with an error that contains its line number. For instance,
+
+	# Production assets.
+	example.com DomainName
+	{"identifier": "192.0.2.1", "type": "IP", "labels": {"owner": "team-a"}}
+
+# targetDefaults
+
+The "targetDefaults" field contains default values shared by all the
+targets, including the ones coming from target sources. It supports
+the following properties:
+
+  - options: map of options merged into the options of every target.
+  - detectAssetType: if true, the "type" property of the targets can be
+    omitted and the asset type is inferred from the identifier. It is
+    false by default.
+
+The options of a check are calculated by merging, from lowest to
+highest precedence, the default options of the checktype, the
+asset type options of the checktype, the options in "targetDefaults"
+and the options of the target. For instance,
+
+	targetDefaults:
+	  options:
+	    depth: 2
+	targets:
+	  - identifier: https://example.com
+	    type: WebAddress
+	  - identifier: https://internal.example.com
+	    type: WebAddress
+	    options:
+	      depth: 5
+
+When "detectAssetType" is enabled, identifiers that refer to an
+existing file or directory are considered a Path. Otherwise, the asset
+types are detected using the Vulcan asset type detection, which may
+perform DNS queries. One identifier may be expanded into several
+targets. For instance, "https://example.com" can generate a
+WebAddress, a Hostname and a DomainName. The scan fails if no asset
+type can be detected for a target.
 
 # agent
 
@@ -99,19 +326,130 @@ properties:
   - pullPolicy: policy used to decide when to pull a required
     container image. Valid values are "Always", "IfNotPresent" and
     "Never". If not specified, "IfNotPresent" is used.
-  - parallel: maximum number of checks that can run in parallel. If
-    not specified, this limit is set to one.
+  - parallel: maximum number of checks that can run in parallel.
+    Every check runs in its own container, so it also limits the
+    number of check containers running at the same time. It must not
+    be negative. If not specified, this limit is set to one.
   - vars: map with the environment variables passed to the executed
     checktypes.
   - registries: configuration of the required container registries. It
     requires the following properties: "server", "username" and
     "password".
+  - maxFindings: maximum number of findings accepted per check. If a
+    check reports more findings, the exceeding ones are discarded and
+    a note is added to the check report. If not specified, this limit
+    is set to 10000. It must not be negative.
+  - maxLogSize: maximum number of bytes of logs kept in memory per
+    check. If a check writes more logs, only the last bytes are kept
+    and a truncation marker is added. It cannot be negative. If not
+    specified, this limit is set to 1 MiB. The logs of the checks
+    that did not finish successfully are printed by "lava scan -logs".
+  - internetProbe: URL used to check whether the Internet is
+    reachable. If it is not reachable, the checks whose checktype
+    requires Internet access are skipped and reported with the status
+    "SKIPPED". If not specified, all the checks are run.
+  - network: name of the container network the checks are attached
+    to. It must be created beforehand and allows to restrict the
+    network access of the checks. The values "none" and "host" are
+    not valid. If not specified, the default network of the
+    container runtime is used.
+  - overrides: map of command overrides indexed by checktype name.
+    Every override accepts the properties "entrypoint" (replaces the
+    entrypoint of the checktype image) and "args" (replaces the
+    arguments passed to the entrypoint). Note that overriding the
+    entrypoint also discards the default arguments of the image.
+    The property "noTimeout" disables the timeout of the checktype,
+    so the check is not killed while it is being debugged. Overrides
+    are intended for checktype development and debugging and are
+    always logged.
+  - reportAddr: address, in the form "host:port", where Lava listens
+    for the reports of the checks. It allows to open a specific port
+    in the firewall of the host. If the host is omitted, Lava listens
+    on the interface that is reachable from the checks. For instance,
+    the gateway of the Docker bridge network. If the port is omitted,
+    a random port is used. For instance, ":8080".
+  - reportHost: hostname or IP address the checks use to send their
+    reports to Lava. If not specified, the host gateway hostname of
+    the container runtime is used. For instance,
+    "host.docker.internal". With Docker Engine, this hostname is
+    mapped to the gateway of the network of the checks. The mapping
+    is always added to the checks, so a custom reportHost must
+    resolve to an address where reportAddr is reachable.
+  - snapshotFailedChecks: if true, the containers of the checks that
+    exit with a non-zero exit code, including the ones that time out,
+    are committed into images named "lava-failed-check:<check ID>"
+    before being removed, so they can be inspected after the scan.
+    The containers themselves cannot be kept, because the Vulcan
+    agent always removes them. So, only the images are kept and
+    the snapshots do not include the logs of the checks, which can
+    be printed with "lava scan -logs". The images are labeled with
+    "com.adevinta.lava.managed=true" and the check ID, and they are
+    logged. To inspect a snapshot, run a container from its image.
+    For instance, "docker run -it --entrypoint sh
+    lava-failed-check:<check ID>". Note that the images keep the
+    environment of the checks, which can contain secrets like target
+    credentials. It is intended for checktype debugging. If not
+    specified, no snapshots are taken.
+  - gracePeriod: time in seconds a check is given to exit after being
+    sent a SIGTERM signal because its timeout expired. The reports
+    sent by the check during this period, like partial results, are
+    kept. It applies to the checktypes that do not define their own
+    grace period. If not specified, checks that time out are stopped
+    with a fixed grace period of 5 seconds. See below for more
+    details.
+  - timeout: time in seconds a check is allowed to run when its
+    checktype does not define a timeout in the checktype catalog.
+    When the timeout expires, the check is stopped. If not specified,
+    the default timeout is 3 minutes.
+  - gatewayProbe: image of a container used to check that the checks
+    can reach the Lava host before running the scan. Checks send
+    their reports to the host, so, if it is not reachable, the scan
+    fails instead of finishing without results. The probe uses the
+    same address and hostname as the checks, so it honors reportAddr
+    and reportHost. The image must provide the wget command, like
+    "busybox". If not specified, no probe is done.
+  - partialCatalogs: if true, the scan is run with the checktype
+    catalogs that can be retrieved, and the ones that cannot be
+    retrieved are logged. The scan fails if none of the catalogs can
+    be retrieved. If not specified, the scan fails if any catalog
+    cannot be retrieved.
+  - validateCatalogs: if true, the scan fails if the checktype
+    catalogs contain checktypes that cannot be run. That is,
+    checktypes without assets or image. If not specified, these
+    checktypes are logged and ignored.
+  - imageTemplate: template used to rewrite the image references of
+    the checktypes when the catalogs are loaded. It requires the
+    property "template" and accepts the optional property "vars". See
+    below for more details.
+  - retry: retry policy of the checks that fail. It accepts the
+    optional properties "maxRetries", "budget" and "statuses". See
+    below for more details. If not specified, failed checks are not retried.
+  - checkpoint: path of the file where the progress of the scan is
+    stored. It allows to pause and resume long scans. See below for
+    more details. If not specified, no checkpoint is used.
+  - reportsDir: directory where the report of every check is written
+    as soon as it is received. Every report is stored in a JSON file
+    named after the check ID, so the reports of the completed checks
+    are kept even if the scan is interrupted. Write errors are logged
+    but do not stop the scan. If not specified, the reports are only
+    kept in memory.
+  - privileges: map of the privileges that can be granted to the
+    checktypes indexed by checktype name. See below for more details.
+    If not specified, no privileges can be granted.
+  - dockerAPIVersion: version of the Docker API used to run the
+    checks. For instance, "1.41". It allows to work with daemons that
+    do not support the latest API version. The DOCKER_API_VERSION
+    environment variable takes precedence over this property. If
+    neither is specified, the version is negotiated with the daemon.
+    The scan fails if the daemon does not support the requested
+    version.
 
 The sample below is a full agent configuration:
 
 	agent:
 	  pullPolicy: Always
 	  parallel: 4
+	  maxFindings: 5000
 	  vars:
 	    DEBUG: true
 	  registries:
@@ -119,6 +457,148 @@ The sample below is a full agent configuration:
 	      username: user
 	      password: p4ssw0rd
 
+The checks must always be able to reach the host running Lava, which
+receives their reports and serves local targets. The "network"
+property allows to run the checks in a more restrictive network. For
+instance, an internal network that does not allow egress traffic can
+be created with:
+
+	docker network create --internal lava-checks
+
+Allowlists of hosts or CIDRs are not enforced by Lava. They can be
+implemented by the network itself. For instance, using an internal
+network with an egress proxy or firewall rules.
+
+The trade-offs depend on the container runtime:
+
+  - Dockerd: Lava listens on the gateway of the configured network,
+    so it is reachable even from internal networks. The network must
+    have exactly one gateway.
+  - Docker Desktop, Rancher Desktop and Podman Desktop: the host is
+    reached through the hostname provided by the runtime. Some
+    runtimes do not route this hostname from internal networks, in
+    which case the checks are not able to send their reports.
+
+The "imageTemplate" property allows to share the same checktype
+catalogs across environments that pull the images from different
+registries. The "template" property is a Go text/template that
+generates the new image reference. It has access to the following
+variables:
+
+  - Checktype: name of the checktype.
+  - Domain: registry domain of the original image.
+  - Path: repository path of the original image.
+  - Name: last element of the repository path of the original image.
+  - Tag: tag of the original image or "latest" if it has no tag.
+
+Additional variables can be defined in the "vars" property. Their
+values can reference environment variables using the syntax $VAR or
+${VAR}. If the original image contains a digest, it is preserved. For
+instance,
+
+	agent:
+	  imageTemplate:
+	    template: '{{.Registry}}/vulcan/{{.Name}}:{{.Tag}}'
+	    vars:
+	      Registry: ${LAVA_REGISTRY}
+
+The rewritten images are included in the metrics report. Rewriting an
+image to an invalid reference makes the scan fail.
+
+The "retry" property allows to tolerate flaky targets. The failed
+checks are retried in rounds, waiting between rounds with jittered
+exponential backoff. The "statuses" property is the list of statuses
+that are considered failures. Valid values are "FAILED", "TIMEOUT"
+and "INCONCLUSIVE". If not specified, the checks with status FAILED
+or TIMEOUT are retried, given that timeouts are a common symptom of
+flaky targets. The "maxRetries" property is the maximum number of
+times a check is retried and defaults to 1. The "budget" property is
+the maximum number of retries shared by all the checks of the scan,
+which prevents a systemically failing environment from retrying
+indefinitely. When the budget is exhausted, the remaining failed
+checks are reported as they are. If "budget" is not specified, only
+the per-check limit applies. For instance,
+
+	agent:
+	  retry:
+	    maxRetries: 2
+	    budget: 10
+	    statuses:
+	      - FAILED
+	      - TIMEOUT
+
+The number of consumed retries is included in the metrics report.
+
+Some checktypes require elevated privileges. For instance, port
+scanners that use raw sockets. A checktype declares the privileges it
+requires in the checktype catalog using the "privileges" property,
+which accepts "privileged" (run in a privileged container) and
+"capabilities" (list of Linux capabilities added to the container).
+For instance,
+
+	{
+	  "name": "vulcan-nmap",
+	  "image": "vulcansec/vulcan-nmap:latest",
+	  "assets": ["IP", "Hostname"],
+	  "privileges": {
+	    "capabilities": ["NET_RAW"]
+	  }
+	}
+
+These privileges are only granted if they are allowed by the
+"privileges" property of the agent configuration. Otherwise, the
+check is not run and it is reported as failed. The "privileged"
+property allows privileged mode, which implies all the capabilities,
+and the "capabilities" property allows specific capabilities. For
+instance,
+
+	agent:
+	  privileges:
+	    vulcan-nmap:
+	      capabilities:
+	        - NET_RAW
+
+Granting privileges weakens the isolation between the check and the
+host running Lava. A privileged check can access the devices of the
+host and escape its container. Capabilities like SYS_ADMIN or
+NET_ADMIN are almost as powerful. So, only allow the minimum set of
+privileges required by checktypes from trusted catalogs. Every time
+privileges are granted to a check, a warning is logged.
+
+Checktypes that need more time to flush their results when they time
+out can declare a grace period in the checktype catalog using the
+"grace_period" property, which takes precedence over the
+"gracePeriod" property of the agent configuration. When the timeout
+of the check expires, Lava sends it a SIGTERM signal. If the check is
+still running when the grace period expires, it is killed. So, the
+check can run for its timeout plus its grace period. For instance,
+
+	{
+	  "name": "vulcan-nessus",
+	  "image": "vulcansec/vulcan-nessus:latest",
+	  "assets": ["IP", "Hostname"],
+	  "timeout": 3600,
+	  "grace_period": 60
+	}
+
+The "checkpoint" property allows to pause and resume long scans. The
+reports of the checks that finish successfully are stored in the
+checkpoint file every 30 seconds and when the scan stops. A scan can
+be paused by sending the signal SIGINT or SIGTERM to the lava
+command, which stops starting new checks and waits for the running
+ones to finish. In that case, the failed checks are not retried and
+the report only contains the checks that have been run. Running the
+same scan again resumes it from the checkpoint: the checks that
+already finished successfully are not run again and their stored
+reports are used instead. A check is considered the same if its
+checktype, image, target, asset type and options match. The
+checkpoint file is removed once all the checks have finished. The
+file is always replaced atomically, so it is consistent even if the
+lava command is killed while writing it. For instance,
+
+	agent:
+	  checkpoint: lava-checkpoint.json
+
 It is important to note that Lava is able to use the credentials from
 the container runtime CLIs installed in the system. So, if these CLIs
 are already logged in, it is not necessary to configure the registry
@@ -132,8 +612,39 @@ the following properties.
   - severity: minimum severity required to report a finding. Valid
     values are "critical", "high", "medium", "low" and "info". If not
     specified, "high" is used.
-  - format: output format. Valid values are "human" and "json". If not
-    specified, "human" is used.
+  - floor: severity floor of the report. Findings with a lower
+    severity are dropped from all the outputs, including the webhook,
+    so the report focuses on the actionable findings. Unlike
+    "severity", it does not change the exit code: dropped findings
+    are still considered to calculate it and are included in the
+    summary counts. The number of dropped findings is shown in the
+    human-readable report and in the metrics report. Valid values
+    are the same as in "severity". If not specified, no findings are
+    dropped.
+  - format: output format. Valid values are "human", "json", "jsonl",
+    "jsonl-reports", "sarif", "junit" and "html". If not specified,
+    "human" is used.
+    The "jsonl" format writes one finding per line, encoded like in
+    the "json" format. The "jsonl-reports" format writes one line per
+    check with its check ID, checktype, target, status and findings.
+    Every line of the JSON Lines formats can be parsed independently,
+    which makes them suitable for very large scans. With these
+    formats, the results of the checks that finish successfully are
+    written as soon as the checks finish, in no particular order.
+    The rest of the checks are written at the end of the scan.
+    Streaming is disabled when "dedup" is enabled. The "sarif" format
+    writes a SARIF 2.1.0 log that can be uploaded to GitHub code
+    scanning. Every finding is a result whose rule is identified by
+    the checktype and the summary of the finding, and whose location
+    is the target of the check. Critical and high findings have level
+    "error", medium findings "warning" and the rest "note". The
+    "junit" format writes a JUnit XML report with one test case per
+    checktype and target. A test case fails if the check reports
+    findings with a severity higher or equal than "severity" that are
+    not baselined, and the failure lists their summaries. Checks that
+    do not finish successfully are reported as errors. The "html"
+    format writes a self-contained HTML page with the findings grouped
+    by target and severity in collapsible sections.
   - output: path of the output file. If not specified, stdout is used.
   - metrics: path of the file where the metrics report will be
     written. If not specified, then the metrics report is not
@@ -141,6 +652,31 @@ the following properties.
   - exclusions: list of rules that define what findings should be
     excluded from the report. It allows to ignore findings because of
     accepted risks, false positives, etc.
+  - ignoreCheckErrors: if true, the checks that do not finish
+    successfully (for instance, because they crashed or could not
+    reach the target) do not make the scan fail. They are reported
+    anyway. If not specified, the scan fails with exit code 3 when a
+    check does not finish successfully, regardless of the findings.
+  - dedup: if true, findings with the same summary and affected
+    resource reported against the same target by several checks are
+    reported only once. The instance with the highest severity is
+    kept and the "checktypes" field of the finding lists the
+    checktypes that reported it. The number of removed duplicates is
+    included in the metrics report. Baselines are always generated
+    from all the findings. If not specified, every finding is
+    reported.
+  - webhook: webhook that receives the reported findings at the end of
+    the scan. It requires the property "url", which must be an HTTP or
+    HTTPS URL, and accepts the optional properties "headers" (HTTP
+    headers sent with the request, which can reference environment
+    variables using the syntax $VAR or ${VAR}) and "retries" (maximum
+    number of retries, 3 by default). Every delivery attempt times
+    out after 30 seconds.
+  - baseline: path of a baseline file. The findings in the baseline
+    are reported as baselined, but they do not affect the exit code.
+    If not specified, no baseline is used.
+  - baselineOutput: path of the file where the baseline of the scan is
+    written. If not specified, no baseline is generated.
 
 The sample below is a full report configuration:
 
@@ -154,6 +690,11 @@ The sample below is a full report configuration:
 	      summary: 'Secret Leaked in Git Repository'
 	      resource: '/testdata/certs/'
 
+The reported findings are sorted by severity in descending order and
+then by target, checktype and fingerprint. The check statuses are
+sorted by checktype and target. So, the same scan results always
+generate the same outputs, which can be compared across scans.
+
 The exclusion rules support the following filters:
 
   - target: regular expression that matches the name of the affected
@@ -165,13 +706,77 @@ The exclusion rules support the following filters:
     and the checktype options.
   - summary: regular expression that matches the summary of the
     vulnerability.
+  - cve: CVE identifier of the vulnerability. For instance,
+    "CVE-2023-44487". It matches if the identifier is mentioned in the
+    summary, the labels or the references of the vulnerability. The
+    comparison is case-insensitive.
 
 A finding is excluded if it matches all the filters of an exclusion
 rule.
 
+The "expires" property of an exclusion rule defines when the rule
+stops being applied. It accepts a date, like "2024-12-31", which refers
+to the beginning of that day in UTC, or a timestamp, like
+"2024-12-31T18:00:00Z". Expired rules are ignored and a warning is
+logged, so they can be reviewed. For instance,
+
+	exclusions:
+	  - description: Accepted risk until the next release.
+	    cve: CVE-2023-44487
+	    target: '^example\.com$'
+	    expires: 2024-12-31
+
 It is possible to provide a human-friendly description of an exclusion
 rule using its "description" property.
 
+The webhook receives a POST request with a JSON document containing
+the schema version and the list of reported findings, which are
+encoded like in the JSON output format. For instance,
+
+	{
+	  "version": "1",
+	  "findings": [...],
+	  "check_errors": [...]
+	}
+
+The "check_errors" field lists the checks that did not finish
+successfully. Every entry contains the "checktype", the "target" and
+the final "status" of the check.
+
+If the findings cannot be delivered, the error is logged and the
+local outputs are generated normally.
+
+Baselines allow to adopt Lava in existing projects, where failing on
+all the existing findings is not feasible. First, run a scan with the
+"baselineOutput" property to record the current findings. Then, use
+the generated file as "baseline" in subsequent scans, which fail only
+if new findings are found. The baseline contains all the findings that
+are not excluded, regardless of their severity. Findings are
+identified by a fingerprint calculated from the checktype, the target,
+the affected resource and the summary of the finding. A baseline file
+looks like:
+
+	{
+	  "version": "1",
+	  "findings": [
+	    {
+	      "fingerprint": "v1:2a8aa944417bf685...",
+	      "checktype": "vulcan-trivy",
+	      "target": ".",
+	      "summary": "Secret Leaked in Git Repository",
+	      "expires": "2024-06-01T00:00:00Z"
+	    }
+	  ]
+	}
+
+The optional "expires" property of an entry is the time after which
+the finding is considered new again. It allows to give a deadline to
+fix the known findings. When a baseline is regenerated, the
+expiration times of the findings that are already in the input
+baseline are preserved. Using the same file for "baseline" and
+"baselineOutput" accepts the new findings in every scan, so it is
+usually not desirable.
+
 # log
 
 The "log" field describes the logging level of the Lava command. Valid
@@ -179,6 +784,27 @@ values are "debug", "info", "warn" and "error". If not specified,
 "info" is used. For instance,
 
 	log: error
+
+# Overlays
+
+A configuration file can be complemented with one or more overlay
+files using the -overlay flag of the "lava scan" command. Overlays
+allow to keep a base configuration and tweak its targets and options
+per environment without duplicating it. For instance,
+
+	lava scan -c lava.yaml -overlay lava.prod.yaml
+
+The files are merged in order, so the last one takes precedence:
+
+  - Maps are merged recursively. For instance, "agent.vars" contains
+    the variables of all the files.
+  - Scalars and arrays replace the values of the previous files. For
+    instance, the "targets" of an overlay replace the targets of the
+    base configuration.
+
+Every file must only contain valid fields, but only the merged
+configuration must be complete and valid. If it is not, the error
+identifies the file that introduced the invalid value.
 	`,
 }
 
@@ -200,6 +826,8 @@ A Lava metrics file is a JSON document as shown in the following
 example.
 
 	{
+	  "baselined_vulnerability_count": 0,
+	  "check_error_count": 0,
 	  "checktype_urls": [
 	    "https://example.com/checktypes.json"
 	  ],
@@ -212,6 +840,9 @@ example.
 	    }
 	  },
 	  "config_version": "v0.0.0",
+	  "dropped_vulnerability_count": 0,
+	  "duplicated_check_count": 0,
+	  "duplicated_target_count": 0,
 	  "duration": 10.986237086,
 	  "excluded_vulnerability_count": 3,
 	  "exclusion_count": 2,
@@ -234,16 +865,38 @@ example.
 
 A Lava metrics file contains the following data:
 
+  - check_error_count: Number of checks that did not finish
+    successfully.
   - checktype_urls: List of URLs pointing to checktype catalogs.
   - checktypes: Checktype catalog used during the scan. It is computed
     by merging all the checktype catalogs specified in checktype_urls.
   - config_version: Minimum version of Lava required by the
     configuration file.
+  - dropped_vulnerability_count: Number of vulnerabilities dropped
+    from the report because their severity is below the severity
+    floor.
+  - duplicated_check_count: Number of duplicated checks that were
+    not run.
+  - duplicated_vulnerability_count: Number of duplicated
+    vulnerabilities removed from the report. It is only present if
+    deduplication is enabled.
+  - duplicated_target_count: Number of duplicated targets that were
+    not scanned.
   - duration: Duration of the scan.
   - excluded_vulnerability_count: Number of vulnerabilities excluded
     due to matching one or more exclusion rules.
   - exclusion_count: Number of exclusion rules.
+  - expired_exclusion_count: Number of exclusion rules that have
+    expired.
   - exit_code: Exit code returned by the Lava command.
+  - failed_checktype_urls: List of URLs pointing to checktype catalogs
+    that could not be retrieved. It is only present if partial
+    catalogs are allowed and some catalogs failed.
+  - retry_budget_exhausted: Whether the retry budget was exhausted
+    and some failed checks were not retried. It is only present if
+    a retry policy is configured.
+  - retry_count: Number of retries of failed checks consumed during
+    the scan. It is only present if a retry policy is configured.
   - severity: Minimum severity required to report a finding.
   - start_time: When the scan started.
   - targets: List of targets to scan.
@@ -263,17 +916,70 @@ sensible default setting.
 
 General-purpose environment variables:
 
+	LAVA_CA_BUNDLE
+		Path of a file with PEM-encoded certificates that are
+		trusted, in addition to the system root CAs, when
+		retrieving resources via HTTPS. For instance, the
+		checktype catalogs.
+	LAVA_CATALOG_TOKEN
+		Bearer token sent in the Authorization header of the
+		HTTP requests used to retrieve the checktype catalogs
+		with the "auth" field set to true. It is ignored by the
+		catalogs that are not retrieved via HTTP or HTTPS.
 	LAVA_FORCECOLOR
 		Forces colorized output. By default, colorized output
 		is disabled if the lava command is not executed from a
 		terminal, it is executed from a "dumb" terminal or the
 		NO_COLOR environment variable is set.
+	LAVA_HOME
+		Directory where the lava command stores transient and
+		cached data. If not specified, the directory "lava"
+		under the user cache directory is used. For instance,
+		"$XDG_CACHE_HOME/lava" or "$HOME/.cache/lava" on Linux.
+	LAVA_HOST_GATEWAY_HOSTNAME
+		Hostname used by the checks to reach the host. If not
+		specified, "host.docker.internal" is used, or
+		"host.containers.internal" with Podman. The hostname is
+		mapped to the host gateway of the container runtime.
+	LAVA_NO_TIMEOUT
+		Disables the timeout of all the checks if set to a true
+		value, like "1". The timeouts of the checktype manifests
+		are ignored and the checks run until they exit. It is
+		intended for checktype development and debugging and
+		must not be used in production.
 	LAVA_RUNTIME
 		Controls the container runtime used by the lava
 		command. Valid values are "Dockerd" and
 		DockerdDockerDesktop". If not specified, "Dockerd" is
-		used. The values "DockerdRancherDesktop" and
-		"DockerdPodmanDesktop" are also valid, but they are
-		considered experimental.
+		used. The values "DockerdRancherDesktop",
+		"DockerdPodmanDesktop", "DockerdColima" and "Podman" are
+		also valid, but they are considered experimental. If
+		"Dockerd" is used and the Docker socket belongs to a
+		Colima instance, "DockerdColima" is selected
+		automatically. "Podman" uses the
+		Podman API socket directly. The socket is taken from
+		CONTAINER_HOST or, if it is not set,
+		"$XDG_RUNTIME_DIR/podman/podman.sock" for rootless
+		Podman and "/run/podman/podman.sock" otherwise.
+		DOCKER_HOST takes precedence over them.
+
+The HTTP requests sent by the lava command, like the ones used to
+retrieve the checktype catalogs, honor the HTTP_PROXY, HTTPS_PROXY and
+NO_PROXY environment variables.
+
+Lava honors the Docker CLI environment variables, like DOCKER_HOST or
+DOCKER_TLS_VERIFY. In particular:
+
+	DOCKER_API_VERSION
+		Pins the version of the Docker API. It takes precedence
+		over the "agent.dockerAPIVersion" configuration property.
+		If not specified, the version is negotiated with the
+		daemon.
+	DOCKER_HOST
+		Address of the Docker daemon. Remote daemons can be
+		reached over SSH using "ssh://[user@]host[:port]", which
+		requires the Docker CLI in the remote host. Local
+		targets are not reachable from the checks run by remote
+		daemons.
 	`,
 }
@@ -4,10 +4,18 @@
 package scan
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
 	"runtime/debug"
+	"slices"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/adevinta/lava/cmd/lava/internal/base"
@@ -15,6 +23,7 @@ import (
 	"github.com/adevinta/lava/internal/engine"
 	"github.com/adevinta/lava/internal/metrics"
 	"github.com/adevinta/lava/internal/report"
+	"github.com/adevinta/lava/internal/targetsources"
 )
 
 // CmdScan represents the scan command.
@@ -28,6 +37,26 @@ The -c flag allows to specify a configuration file. By default, "lava
 scan" looks for a configuration file with the name "lava.yaml" in the
 current directory.
 
+The -overlay flag allows to specify a configuration file that is
+merged into the configuration file. It can be specified several
+times. Overlays are merged in the order they are specified: maps are
+merged recursively, while scalars and arrays replace the previous
+values. It allows to keep a base configuration and tweak it per
+environment. For instance,
+
+	lava scan -c lava.yaml -overlay lava.prod.yaml
+
+The -validate flag validates the configuration file without running
+the scan. Besides the syntax of the configuration, it retrieves the
+checktype catalogs and the targets of the target sources, and reports
+the checks that would use invalid images or miss required variables.
+The command exits with code 0 if the configuration is valid.
+
+The -logs flag prints the logs of the checks that did not finish
+successfully to the standard error. It helps to find out why a check
+failed. The size of the logs kept per check is limited by the
+"agent.maxLogSize" property of the configuration file.
+
 The exit code of the command depends on the correct execution of the
 security scan and the highest severity among all the vulnerabilities
 that have been found.
@@ -54,10 +83,30 @@ use "lava help environment".
 	`,
 }
 
-var cfgfile = CmdScan.Flag.String("c", "lava.yaml", "config file")
+var (
+	cfgfile  = CmdScan.Flag.String("c", "lava.yaml", "config file")
+	showLogs = CmdScan.Flag.Bool("logs", false, "print the logs of the checks that did not finish successfully")
+	validate = CmdScan.Flag.Bool("validate", false, "validate the configuration without running the scan")
+	overlays stringsFlag
+)
+
+// stringsFlag is a [flag.Value] that can be specified several times.
+type stringsFlag []string
+
+// String returns the values of the flag separated by commas.
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set appends a value to the flag.
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
 
 func init() {
 	CmdScan.Run = run // Break initialization cycle.
+	CmdScan.Flag.Var(&overlays, "overlay", "config overlay file (can be repeated)")
 }
 
 // osExit is used by tests to capture the exit code.
@@ -88,7 +137,7 @@ func scan(args []string) (int, error) {
 	startTime := time.Now()
 	metrics.Collect("start_time", startTime)
 
-	cfg, err := config.ParseFile(*cfgfile)
+	cfg, err := config.ParseFiles(append([]string{*cfgfile}, overlays...)...)
 	if err != nil {
 		return 0, fmt.Errorf("parse config file: %w", err)
 	}
@@ -103,9 +152,34 @@ func scan(args []string) (int, error) {
 		return 0, fmt.Errorf("minimum required version %v", cfg.LavaVersion)
 	}
 
+	if *validate {
+		if err := engine.Validate(cfg, true); err != nil {
+			return 0, fmt.Errorf("validate config: %w", err)
+		}
+		return 0, nil
+	}
+
+	srcTargets, err := targetsources.Targets(cfg.TargetSources)
+	if err != nil {
+		return 0, fmt.Errorf("get targets from sources: %w", err)
+	}
+	targets := cfg.TargetDefaults.Apply(append(slices.Clone(cfg.Targets), srcTargets...))
+	if err := config.ValidateTargets(targets, cfg.TargetDefaults.DetectAssetType); err != nil {
+		return 0, fmt.Errorf("validate targets: %w", err)
+	}
+	if cfg.TargetDefaults.DetectAssetType {
+		if targets, err = config.DetectAssetTypes(targets); err != nil {
+			return 0, fmt.Errorf("detect asset types: %w", err)
+		}
+	}
+
 	metrics.Collect("config_version", cfg.LavaVersion)
-	metrics.Collect("checktype_urls", cfg.ChecktypeURLs)
-	metrics.Collect("targets", cfg.Targets)
+	var checktypeURLs []string
+	for _, u := range cfg.ChecktypeURLs {
+		checktypeURLs = append(checktypeURLs, u.URL)
+	}
+	metrics.Collect("checktype_urls", checktypeURLs)
+	metrics.Collect("targets", targets)
 	metrics.Collect("severity", cfg.ReportConfig.Severity)
 	metrics.Collect("exclusion_count", len(cfg.ReportConfig.Exclusions))
 
@@ -117,10 +191,10 @@ func scan(args []string) (int, error) {
 	}
 	defer eng.Close()
 
-	er, err := eng.Run(cfg.Targets)
-	if err != nil {
-		return 0, fmt.Errorf("engine run: %w", err)
-	}
+	// SIGINT and SIGTERM pause the scan. The engine waits for the
+	// running checks to finish and saves the checkpoint.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	rw, err := report.NewWriter(cfg.ReportConfig)
 	if err != nil {
@@ -128,12 +202,38 @@ func scan(args []string) (int, error) {
 	}
 	defer rw.Close()
 
-	exitCode, err := rw.Write(er)
+	// Checks that finish successfully are streamed to the report
+	// output as soon as they are reported, if the output format
+	// supports it.
+	unobserve := eng.Observe(func(checkID string, r engine.CheckReport) {
+		if err := rw.Stream(r); err != nil {
+			slog.Error("could not stream check report", "check", checkID, "err", err)
+		}
+	})
+	er, err := eng.RunContext(ctx, targets)
+	unobserve()
+	if err != nil {
+		return 0, fmt.Errorf("engine run: %w", err)
+	}
+	if ctx.Err() != nil {
+		slog.Warn("scan paused, the report only contains the checks that have been run")
+	}
+
+	res, err := rw.Write(er)
 	if err != nil {
 		return 0, fmt.Errorf("render report: %w", err)
 	}
 
-	metrics.Collect("exit_code", exitCode)
+	for _, ce := range res.CheckErrors {
+		slog.Warn("check did not finish successfully", "checktype", ce.Checktype, "target", ce.Target, "status", ce.Status)
+	}
+
+	if *showLogs {
+		printCheckLogs(os.Stderr, er)
+	}
+
+	metrics.Collect("check_error_count", len(res.CheckErrors))
+	metrics.Collect("exit_code", res.ExitCode)
 	metrics.Collect("duration", time.Since(startTime).Seconds())
 
 	if cfg.ReportConfig.Metrics != "" {
@@ -142,5 +242,29 @@ func scan(args []string) (int, error) {
 		}
 	}
 
-	return int(exitCode), nil
+	return int(res.ExitCode), nil
+}
+
+// printCheckLogs prints the logs of the checks of the provided report
+// into w. Only the checks that did not finish successfully have logs.
+// See [engine.CheckReport]. The checks are sorted by checktype and
+// target.
+func printCheckLogs(w io.Writer, er engine.Report) {
+	var reports []engine.CheckReport
+	for _, r := range er {
+		if r.Logs != "" {
+			reports = append(reports, r)
+		}
+	}
+	slices.SortFunc(reports, func(a, b engine.CheckReport) int {
+		if c := cmp.Compare(a.ChecktypeName, b.ChecktypeName); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Target, b.Target)
+	})
+
+	for _, r := range reports {
+		fmt.Fprintf(w, "==> checktype=%v target=%v status=%v <==\n", r.ChecktypeName, r.Target, r.Status)
+		fmt.Fprintln(w, strings.TrimSuffix(r.Logs, "\n"))
+	}
 }
@@ -3,13 +3,17 @@
 package scan
 
 import (
+	"bytes"
 	"flag"
 	"log/slog"
 	"os"
 	"runtime/debug"
 	"testing"
 
+	report "github.com/adevinta/vulcan-report"
 	"github.com/jroimartin/clilog"
+
+	"github.com/adevinta/lava/internal/engine"
 )
 
 func TestMain(m *testing.M) {
@@ -104,6 +108,58 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRun_validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		wantNilErr bool
+	}{
+		{
+			name:       "valid config",
+			file:       "lava.yaml",
+			wantNilErr: true,
+		},
+		{
+			name:       "missing vars",
+			file:       "missing_vars.yaml",
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldPwd := mustGetwd()
+			oldCfgfile := *cfgfile
+			oldValidate := *validate
+			oldOsExit := osExit
+			defer func() {
+				mustChdir(oldPwd)
+				*cfgfile = oldCfgfile
+				*validate = oldValidate
+				osExit = oldOsExit
+			}()
+
+			*cfgfile = tt.file
+			*validate = true
+
+			exitCode := -1
+			osExit = func(status int) {
+				exitCode = status
+			}
+
+			mustChdir("testdata/validate")
+			err := run(nil)
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err == nil && exitCode != 0 {
+				t.Errorf("unexpected exit code: got: %v, want: 0", exitCode)
+			}
+		})
+	}
+}
+
 // mustGetwd returns a rooted path name corresponding to the current
 // directory. It panics on error.
 func mustGetwd() string {
@@ -121,3 +177,49 @@ func mustChdir(path string) {
 		panic(err)
 	}
 }
+
+func TestPrintCheckLogs(t *testing.T) {
+	er := engine.Report{
+		"check-1": {
+			Report: report.Report{
+				CheckData: report.CheckData{
+					ChecktypeName: "vulcan-trivy",
+					Target:        "example.com",
+					Status:        "FAILED",
+				},
+			},
+			Logs: "error: could not pull image\n",
+		},
+		"check-2": {
+			Report: report.Report{
+				CheckData: report.CheckData{
+					ChecktypeName: "vulcan-nmap",
+					Target:        "example.com",
+					Status:        "INCONCLUSIVE",
+				},
+			},
+			Logs: "error: timeout",
+		},
+		"check-3": {
+			Report: report.Report{
+				CheckData: report.CheckData{
+					ChecktypeName: "vulcan-zap",
+					Target:        "example.com",
+					Status:        "FINISHED",
+				},
+			},
+		},
+	}
+
+	want := `==> checktype=vulcan-nmap target=example.com status=INCONCLUSIVE <==
+error: timeout
+==> checktype=vulcan-trivy target=example.com status=FAILED <==
+error: could not pull image
+`
+
+	var buf bytes.Buffer
+	printCheckLogs(&buf, er)
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\ngot:\n%v\nwant:\n%v", got, want)
+	}
+}
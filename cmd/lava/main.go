@@ -19,6 +19,7 @@ import (
 	"github.com/adevinta/lava/cmd/lava/internal/initialize"
 	"github.com/adevinta/lava/cmd/lava/internal/scan"
 	"github.com/adevinta/lava/cmd/lava/internal/version"
+	"github.com/adevinta/lava/internal/urlutil"
 )
 
 func init() {
@@ -84,5 +85,12 @@ func parseEnv() error {
 		}
 		color.NoColor = !forceColor
 	}
+
+	client, err := urlutil.NewClient(os.Getenv("LAVA_CA_BUNDLE"))
+	if err != nil {
+		return fmt.Errorf("invalid LAVA_CA_BUNDLE value: %w", err)
+	}
+	urlutil.DefaultClient = client
+
 	return nil
 }
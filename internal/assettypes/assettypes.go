@@ -5,9 +5,16 @@
 package assettypes
 
 import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
 	"slices"
+	"strings"
 
 	types "github.com/adevinta/vulcan-types"
+	"github.com/distribution/reference"
 )
 
 // Lava asset types.
@@ -37,3 +44,100 @@ func ToVulcan(at types.AssetType) types.AssetType {
 	}
 	return at
 }
+
+// Canonical returns the canonical form of the provided identifier
+// for the specified asset type, so identifiers that refer to the same
+// asset can be compared. For instance, "Example.COM." and
+// "example.com" are the same Hostname. If the identifier cannot be
+// parsed, it is returned unchanged.
+func Canonical(at types.AssetType, identifier string) string {
+	switch at {
+	case types.Hostname, types.DomainName:
+		return strings.TrimSuffix(strings.ToLower(identifier), ".")
+	case types.IP:
+		if ip := net.ParseIP(identifier); ip != nil {
+			return ip.String()
+		}
+	case types.IPRange:
+		if _, ipnet, err := net.ParseCIDR(identifier); err == nil {
+			return ipnet.String()
+		}
+	case types.WebAddress:
+		return canonicalURL(identifier)
+	case types.DockerImage:
+		if named, err := reference.ParseNormalizedNamed(identifier); err == nil {
+			return reference.TagNameOnly(named).String()
+		}
+	case Path:
+		return filepath.Clean(identifier)
+	}
+	return identifier
+}
+
+// Asset is an identifier with its asset type.
+type Asset struct {
+	Identifier string
+	AssetType  types.AssetType
+}
+
+// Detect returns the assets that can be inferred from the provided
+// identifier. If the identifier refers to an existing file or
+// directory, it is considered a [Path]. Otherwise, the asset types
+// are detected using [types.DetectAssetTypes], which may perform DNS
+// queries. The Hostname and DomainName assets inferred from a
+// WebAddress are identified by the host of the URL. If no asset type
+// can be inferred, an empty slice is returned.
+func Detect(identifier string) ([]Asset, error) {
+	if _, err := os.Stat(identifier); err == nil {
+		return []Asset{{Identifier: identifier, AssetType: Path}}, nil
+	}
+
+	ats, err := types.DetectAssetTypes(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("detect asset types: %w", err)
+	}
+
+	host := identifier
+	if slices.Contains(ats, types.WebAddress) {
+		u, err := url.Parse(identifier)
+		if err != nil {
+			return nil, fmt.Errorf("parse URL: %w", err)
+		}
+		host = u.Hostname()
+	}
+
+	var assets []Asset
+	for _, at := range ats {
+		ident := identifier
+		if at == types.Hostname || at == types.DomainName {
+			ident = host
+		}
+		assets = append(assets, Asset{Identifier: ident, AssetType: at})
+	}
+	return assets, nil
+}
+
+// canonicalURL returns the canonical form of the provided URL. The
+// scheme and the host are lowercased, default ports are removed and
+// an empty path is replaced with "/".
+func canonicalURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String()
+}
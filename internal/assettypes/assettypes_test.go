@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestIsValid(t *testing.T) {
@@ -73,3 +74,128 @@ func TestToVulcan(t *testing.T) {
 		})
 	}
 }
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		name       string
+		at         types.AssetType
+		identifier string
+		want       string
+	}{
+		{
+			name:       "hostname",
+			at:         types.Hostname,
+			identifier: "WWW.Example.COM.",
+			want:       "www.example.com",
+		},
+		{
+			name:       "domain name",
+			at:         types.DomainName,
+			identifier: "Example.com",
+			want:       "example.com",
+		},
+		{
+			name:       "IPv6",
+			at:         types.IP,
+			identifier: "2001:DB8:0:0:0:0:0:1",
+			want:       "2001:db8::1",
+		},
+		{
+			name:       "IP range",
+			at:         types.IPRange,
+			identifier: "192.0.2.15/24",
+			want:       "192.0.2.0/24",
+		},
+		{
+			name:       "web address with default port",
+			at:         types.WebAddress,
+			identifier: "HTTPS://Example.com:443",
+			want:       "https://example.com/",
+		},
+		{
+			name:       "web address with custom port",
+			at:         types.WebAddress,
+			identifier: "http://example.com:8080/path?q=1",
+			want:       "http://example.com:8080/path?q=1",
+		},
+		{
+			name:       "docker image",
+			at:         types.DockerImage,
+			identifier: "alpine",
+			want:       "docker.io/library/alpine:latest",
+		},
+		{
+			name:       "path",
+			at:         Path,
+			identifier: "./repo/",
+			want:       "repo",
+		},
+		{
+			name:       "invalid identifier",
+			at:         types.IP,
+			identifier: "invalid",
+			want:       "invalid",
+		},
+		{
+			name:       "unsupported asset type",
+			at:         types.AWSAccount,
+			identifier: "arn:aws:iam::123456789012:root",
+			want:       "arn:aws:iam::123456789012:root",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Canonical(tt.at, tt.identifier)
+			if got != tt.want {
+				t.Errorf("unexpected value: want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name       string
+		identifier string
+		want       []Asset
+	}{
+		{
+			name:       "path",
+			identifier: ".",
+			want:       []Asset{{Identifier: ".", AssetType: Path}},
+		},
+		{
+			name:       "IP",
+			identifier: "192.0.2.1",
+			want:       []Asset{{Identifier: "192.0.2.1", AssetType: types.IP}},
+		},
+		{
+			name:       "IP range",
+			identifier: "192.0.2.0/24",
+			want:       []Asset{{Identifier: "192.0.2.0/24", AssetType: types.IPRange}},
+		},
+		{
+			name:       "Git repository",
+			identifier: "https://example.com/repo.git",
+			want:       []Asset{{Identifier: "https://example.com/repo.git", AssetType: types.GitRepository}},
+		},
+		{
+			name:       "Docker image",
+			identifier: "docker.io/library/alpine:3.18",
+			want:       []Asset{{Identifier: "docker.io/library/alpine:3.18", AssetType: types.DockerImage}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Detect(tt.identifier)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("assets mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
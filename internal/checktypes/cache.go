@@ -0,0 +1,189 @@
+// Copyright 2023 Adevinta
+
+package checktypes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/adevinta/lava/internal/urlutil"
+	"github.com/adevinta/lava/internal/workdir"
+)
+
+// cacheEntry is a checktype catalog cached on disk.
+type cacheEntry struct {
+	// URL is the URL of the catalog.
+	URL string `json:"url"`
+
+	// ETag is the entity tag returned by the server.
+	ETag string `json:"etag"`
+
+	// Checksum is the SHA-256 hash of Body. It allows to detect
+	// corrupt entries.
+	Checksum string `json:"checksum"`
+
+	// Encoding is the Content-Encoding of Body. For instance,
+	// "gzip". If empty, Body is not encoded.
+	Encoding string `json:"encoding,omitempty"`
+
+	// Body is the content of the catalog.
+	Body []byte `json:"body"`
+}
+
+// cachingTransport is an [http.RoundTripper] that caches on disk the
+// responses that contain an ETag header. When a cached response
+// exists, the request is sent with an If-None-Match header and the
+// cached body is reused if the server replies with "304 Not
+// Modified". Cache errors are logged and the request is sent as is.
+type cachingTransport struct {
+	base http.RoundTripper
+}
+
+// newCachingClient returns a copy of [urlutil.DefaultClient] that
+// caches the retrieved catalogs in the Lava home directory. See
+// [workdir.Catalogs].
+func newCachingClient() *http.Client {
+	client := *urlutil.DefaultClient
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = cachingTransport{base: base}
+	return &client
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	url := req.URL.String()
+	entry, cached := loadCacheEntry(url)
+	if cached {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		resp.Body.Close()
+		slog.Debug("using cached catalog", "url", url, "etag", entry.ETag)
+		resp.StatusCode = http.StatusOK
+		resp.Status = http.StatusText(http.StatusOK)
+		resp.Body = io.NopCloser(bytes.NewReader(entry.Body))
+		resp.ContentLength = int64(len(entry.Body))
+		if entry.Encoding != "" {
+			resp.Header.Set("Content-Encoding", entry.Encoding)
+		} else {
+			resp.Header.Del("Content-Encoding")
+		}
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read body: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		if err := storeCacheEntry(url, resp.Header.Get("ETag"), resp.Header.Get("Content-Encoding"), body); err != nil {
+			slog.Warn("could not cache catalog", "url", url, "err", err)
+		}
+	}
+	return resp, nil
+}
+
+// cacheEntryPath returns the path of the cache entry of the provided
+// URL.
+func cacheEntryPath(home, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(home, workdir.Catalogs, hex.EncodeToString(sum[:])+".json")
+}
+
+// loadCacheEntry returns the cache entry of the provided URL. It
+// reports whether the entry exists and is valid. Corrupt entries are
+// ignored.
+func loadCacheEntry(url string) (cacheEntry, bool) {
+	home, err := workdir.Home()
+	if err != nil {
+		slog.Warn("could not get catalog cache", "err", err)
+		return cacheEntry{}, false
+	}
+
+	data, err := os.ReadFile(cacheEntryPath(home, url))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("could not read cached catalog", "url", url, "err", err)
+		}
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		slog.Warn("ignoring corrupt cached catalog", "url", url, "err", err)
+		return cacheEntry{}, false
+	}
+
+	sum := sha256.Sum256(entry.Body)
+	if entry.URL != url || entry.ETag == "" || entry.Checksum != hex.EncodeToString(sum[:]) {
+		slog.Warn("ignoring corrupt cached catalog", "url", url)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// storeCacheEntry stores the provided catalog in the cache. The entry
+// is written atomically, so concurrent runs do not read partial
+// entries.
+func storeCacheEntry(url, etag, encoding string, body []byte) error {
+	dir, err := workdir.Dir(workdir.Catalogs)
+	if err != nil {
+		return fmt.Errorf("get catalog cache: %w", err)
+	}
+
+	sum := sha256.Sum256(body)
+	entry := cacheEntry{
+		URL:      url,
+		ETag:     etag,
+		Encoding: encoding,
+		Checksum: hex.EncodeToString(sum[:]),
+		Body:     body,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal entry: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("write entry: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close entry: %w", err)
+	}
+
+	home := filepath.Dir(dir)
+	if err := os.Rename(f.Name(), cacheEntryPath(home, url)); err != nil {
+		return fmt.Errorf("rename entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 Adevinta
+
+package checktypes
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewCatalog_cache(t *testing.T) {
+	catalogJSON, err := os.ReadFile("testdata/checktype_catalog.json")
+	if err != nil {
+		t.Fatalf("read catalog: %v", err)
+	}
+
+	tests := []struct {
+		name string
+
+		// corrupt is applied to the cache entry before the
+		// second fetch. If nil, the entry is left untouched.
+		corrupt func(path string) error
+
+		// gzip makes the server compress the catalog.
+		gzip bool
+
+		wantNotModified bool
+	}{
+		{
+			name:            "valid entry",
+			corrupt:         nil,
+			wantNotModified: true,
+		},
+		{
+			name:            "valid gzip entry",
+			corrupt:         nil,
+			gzip:            true,
+			wantNotModified: true,
+		},
+		{
+			name: "malformed entry",
+			corrupt: func(path string) error {
+				return os.WriteFile(path, []byte("malformed"), 0o644)
+			},
+			wantNotModified: false,
+		},
+		{
+			name: "checksum mismatch",
+			corrupt: func(path string) error {
+				data := []byte(`{"url": "", "etag": "\"v1\"", "checksum": "0", "body": "e30="}`)
+				return os.WriteFile(path, data, 0o644)
+			},
+			wantNotModified: false,
+		},
+		{
+			name: "missing entry",
+			corrupt: func(path string) error {
+				return os.Remove(path)
+			},
+			wantNotModified: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("LAVA_HOME", home)
+
+			var notModified int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				if tt.gzip {
+					w.Header().Set("Content-Encoding", "gzip")
+					zw := gzip.NewWriter(w)
+					zw.Write(catalogJSON) //nolint:errcheck
+					zw.Close()            //nolint:errcheck
+					return
+				}
+				w.Write(catalogJSON) //nolint:errcheck
+			}))
+			defer ts.Close()
+
+			url := ts.URL + "/checktypes.json"
+
+			want, err := NewCatalog(mkURLs(url))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.corrupt != nil {
+				if err := tt.corrupt(cacheEntryPath(home, url)); err != nil {
+					t.Fatalf("corrupt entry: %v", err)
+				}
+			}
+
+			got, err := NewCatalog(mkURLs(url))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("catalogs mismatch (-want +got):\n%v", diff)
+			}
+
+			if (notModified == 1) != tt.wantNotModified {
+				t.Errorf("unexpected number of not modified responses: %v", notModified)
+			}
+
+			if _, err := os.Stat(filepath.Join(home, "catalogs")); err != nil {
+				t.Errorf("missing cache dir: %v", err)
+			}
+		})
+	}
+}
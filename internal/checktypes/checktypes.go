@@ -5,19 +5,42 @@
 package checktypes
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
 
 	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
 	types "github.com/adevinta/vulcan-types"
 
+	"github.com/adevinta/lava/internal/config"
 	"github.com/adevinta/lava/internal/urlutil"
 )
 
-// ErrMalformedCatalog is returned by [NewCatalog] when the format of
-// the retrieved catalog is not valid.
-var ErrMalformedCatalog = errors.New("malformed catalog")
+var (
+	// ErrMalformedCatalog is returned by [NewCatalog] when the
+	// format of the retrieved catalog is not valid.
+	ErrMalformedCatalog = errors.New("malformed catalog")
+
+	// ErrNoCatalogs is returned by [NewPartialCatalog] when none
+	// of the catalogs can be retrieved.
+	ErrNoCatalogs = errors.New("no catalogs could be retrieved")
+
+	// ErrInvalidChecktype is returned by [Catalog.Validate] when
+	// a checktype of the catalog cannot be run.
+	ErrInvalidChecktype = errors.New("invalid checktype")
+
+	// ErrDuplicatedChecktype is returned by [NewCatalogStrict]
+	// when a checktype is defined in more than one catalog.
+	ErrDuplicatedChecktype = errors.New("duplicated checktype")
+)
 
 // Accepts reports whether the specified checktype accepts an asset
 // type.
@@ -30,32 +53,401 @@ func Accepts(ct checkcatalog.Checktype, at types.AssetType) bool {
 	return false
 }
 
+// Checktype represents a Vulcan checktype along with Lava-specific
+// properties.
+type Checktype struct {
+	checkcatalog.Checktype
+
+	// AssetTypeOptions contains default options indexed by asset
+	// type. They take precedence over the default options of the
+	// checktype when the checktype is run against a target of the
+	// corresponding asset type.
+	AssetTypeOptions map[string]map[string]any `json:"asset_type_options,omitempty"`
+
+	// Network is the network access required by the checktype.
+	// If empty, [NetworkInternal] is assumed.
+	Network Network `json:"network,omitempty"`
+
+	// Privileges are the privileges required by the checktype.
+	// For instance, to use raw sockets. They are only granted if
+	// they are allowed by the Lava configuration. If nil, no
+	// privileges are required.
+	Privileges *Privileges `json:"privileges,omitempty"`
+
+	// GracePeriod is the time in seconds the checktype is given
+	// to exit after being sent a SIGTERM signal because its
+	// timeout expired. It takes precedence over the grace period
+	// of the Lava configuration.
+	GracePeriod int `json:"grace_period,omitempty"`
+}
+
+// Privileges represents the privileges required by a checktype.
+type Privileges struct {
+	// Privileged means that the checktype must run in a
+	// privileged container.
+	Privileged bool `json:"privileged,omitempty"`
+
+	// Capabilities are the Linux capabilities that must be added
+	// to the container of the checktype. For instance,
+	// "NET_RAW".
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// Network represents the network access required by a checktype.
+type Network string
+
+// Network requirements.
+const (
+	// NetworkInternal means that the checktype only requires
+	// access to the target.
+	NetworkInternal Network = "internal"
+
+	// NetworkInternet means that the checktype requires access
+	// to the Internet.
+	NetworkInternet Network = "internet"
+)
+
+// OptionsFor returns the default options of the checktype for the
+// provided asset type. That is, the options of the checktype merged
+// with the options of the asset type.
+func (ct Checktype) OptionsFor(at types.AssetType) map[string]any {
+	opts := make(map[string]any)
+	maps.Copy(opts, ct.Options)
+	maps.Copy(opts, ct.AssetTypeOptions[string(at)])
+	return opts
+}
+
 // Catalog represents a collection of Vulcan checktypes.
-type Catalog map[string]checkcatalog.Checktype
+type Catalog map[string]Checktype
+
+// FilterByAssetType returns a new catalog with the checktypes that
+// accept the provided asset type. See [Accepts]. The receiver is not
+// modified.
+func (catalog Catalog) FilterByAssetType(at types.AssetType) Catalog {
+	filtered := make(Catalog)
+	for name, ct := range catalog {
+		if Accepts(ct.Checktype, at) {
+			filtered[name] = ct
+		}
+	}
+	return filtered
+}
+
+// FilterByNames returns a new catalog with the checktypes with the
+// provided names. Names not found in the catalog are ignored. The
+// receiver is not modified.
+func (catalog Catalog) FilterByNames(names ...string) Catalog {
+	filtered := make(Catalog)
+	for _, name := range names {
+		if ct, ok := catalog[name]; ok {
+			filtered[name] = ct
+		}
+	}
+	return filtered
+}
+
+// Validate reports the checktypes of the catalog that cannot be run.
+// That is, checktypes without assets or image, and checktypes whose
+// name does not match their key in the catalog. The returned error
+// aggregates all the problems, sorted by checktype, and every
+// problem wraps [ErrInvalidChecktype].
+func (catalog Catalog) Validate() error {
+	names := make([]string, 0, len(catalog))
+	for name := range catalog {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var errs []error
+	for _, name := range names {
+		ct := catalog[name]
+		if ct.Name != name {
+			errs = append(errs, fmt.Errorf("%w: %v: name mismatch: %q", ErrInvalidChecktype, name, ct.Name))
+		}
+		if len(ct.Assets) == 0 {
+			errs = append(errs, fmt.Errorf("%w: %v: no assets", ErrInvalidChecktype, name))
+		}
+		if strings.TrimSpace(ct.Image) == "" {
+			errs = append(errs, fmt.Errorf("%w: %v: no image", ErrInvalidChecktype, name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// maxConcurrentFetches is the maximum number of catalogs that are
+// retrieved concurrently by [NewCatalog].
+const maxConcurrentFetches = 8
 
 // NewCatalog retrieves the specified checktype catalogs and
 // consolidates them in a single catalog with all the checktypes
-// indexed by name. If a checktype is duplicated it is overridden with
-// the last one.
-func NewCatalog(urls []string) (Catalog, error) {
-	catalog := make(Catalog)
-	for _, url := range urls {
-		data, err := urlutil.Get(url)
-		if err != nil {
-			return nil, err
+// indexed by name. If a checktype is duplicated, the definition of
+// the catalog with the highest priority wins. The priority of a
+// catalog is set in its [config.ChecktypeURL]. Any priority declared
+// inside the catalog is ignored, so a catalog cannot give itself
+// precedence over the others. If the priorities are equal, the last
+// catalog wins.
+//
+// Catalogs are retrieved concurrently, but they are always merged in
+// the order they are specified. If one or more catalogs cannot be
+// retrieved, the returned error contains the errors of all of them.
+//
+// Besides the URLs supported by [urlutil.Get], catalogs can be pulled
+// from OCI registries using URLs with the scheme "oci://". The
+// catalogs retrieved via HTTP are cached on disk if the server
+// returns an ETag, and they are only downloaded again if they
+// change.
+func NewCatalog(urls []config.ChecktypeURL) (Catalog, error) {
+	return NewCatalogContext(context.Background(), urls)
+}
+
+// NewCatalogContext is like [NewCatalog] but accepts a context.
+// Cancelling the context aborts the in-flight requests used to
+// retrieve the catalogs.
+func NewCatalogContext(ctx context.Context, urls []config.ChecktypeURL) (Catalog, error) {
+	catalog, srcErrs := fetchCatalogs(ctx, urls)
+	if len(srcErrs) > 0 {
+		var errs []error
+		for _, srcErr := range srcErrs {
+			errs = append(errs, srcErr)
+		}
+		return nil, errors.Join(errs...)
+	}
+	return catalog, nil
+}
+
+// NewCatalogStrict is like [NewCatalog] but, instead of resolving
+// duplicated checktypes using the priority of the catalogs, it
+// returns an error if a checktype is defined in more than one
+// catalog. The returned error contains a [DuplicateError] for every
+// duplicated checktype, sorted by name.
+func NewCatalogStrict(urls []config.ChecktypeURL) (Catalog, error) {
+	srcs, srcErrs := fetchSources(context.Background(), urls)
+	if len(srcErrs) > 0 {
+		var errs []error
+		for _, srcErr := range srcErrs {
+			errs = append(errs, srcErr)
 		}
+		return nil, errors.Join(errs...)
+	}
 
-		var decData struct {
-			Checktypes []checkcatalog.Checktype `json:"checktypes"`
+	if dupErrs := findDuplicates(srcs); len(dupErrs) > 0 {
+		var errs []error
+		for _, dupErr := range dupErrs {
+			errs = append(errs, dupErr)
 		}
-		err = json.Unmarshal(data, &decData)
-		if err != nil {
-			return nil, fmt.Errorf("%w: %w", ErrMalformedCatalog, err)
+		return nil, errors.Join(errs...)
+	}
+	return mergeSources(srcs), nil
+}
+
+// findDuplicates returns the checktypes defined in more than one of
+// the provided catalogs sorted by name.
+func findDuplicates(srcs []catalogSource) []DuplicateError {
+	defs := make(map[string][]string)
+	for _, src := range srcs {
+		seen := make(map[string]bool)
+		for _, checktype := range src.Data.Checktypes {
+			if seen[checktype.Name] {
+				continue
+			}
+			seen[checktype.Name] = true
+			defs[checktype.Name] = append(defs[checktype.Name], src.URL)
 		}
+	}
 
-		for _, checktype := range decData.Checktypes {
+	var dupErrs []DuplicateError
+	for name, urls := range defs {
+		if len(urls) > 1 {
+			dupErrs = append(dupErrs, DuplicateError{Name: name, URLs: urls})
+		}
+	}
+	slices.SortFunc(dupErrs, func(a, b DuplicateError) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return dupErrs
+}
+
+// NewPartialCatalog is like [NewCatalog] but it tolerates the
+// failure of individual catalogs. The returned catalog contains the
+// checktypes of the catalogs that could be retrieved. The catalogs
+// that could not be retrieved are returned as a list of
+// [SourceError]. If all the catalogs fail, it returns an error that
+// wraps [ErrNoCatalogs].
+func NewPartialCatalog(urls []config.ChecktypeURL) (Catalog, []SourceError, error) {
+	catalog, srcErrs := fetchCatalogs(context.Background(), urls)
+	if len(urls) > 0 && len(srcErrs) == len(urls) {
+		var errs []error
+		for _, srcErr := range srcErrs {
+			errs = append(errs, srcErr)
+		}
+		return nil, srcErrs, fmt.Errorf("%w: %w", ErrNoCatalogs, errors.Join(errs...))
+	}
+	return catalog, srcErrs, nil
+}
+
+// SourceError is the error returned when a checktype catalog cannot
+// be retrieved.
+type SourceError struct {
+	// URL is the URL of the catalog.
+	URL string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (err SourceError) Error() string {
+	return fmt.Sprintf("%v: %v", err.URL, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err SourceError) Unwrap() error {
+	return err.Err
+}
+
+// DuplicateError is the error returned by [NewCatalogStrict] when a
+// checktype is defined in more than one catalog. It wraps
+// [ErrDuplicatedChecktype].
+type DuplicateError struct {
+	// Name is the name of the checktype.
+	Name string
+
+	// URLs are the URLs of the catalogs that define the
+	// checktype in the order they were specified.
+	URLs []string
+}
+
+// Error implements the error interface.
+func (err DuplicateError) Error() string {
+	return fmt.Sprintf("%v: %v defined in %v", ErrDuplicatedChecktype, err.Name, strings.Join(err.URLs, ", "))
+}
+
+// Unwrap returns [ErrDuplicatedChecktype].
+func (err DuplicateError) Unwrap() error {
+	return ErrDuplicatedChecktype
+}
+
+// fetchCatalogs retrieves the specified checktype catalogs
+// concurrently and merges the ones that could be retrieved in the
+// order they are specified. It also returns the errors of the
+// catalogs that could not be retrieved in the same order.
+func fetchCatalogs(ctx context.Context, urls []config.ChecktypeURL) (Catalog, []SourceError) {
+	srcs, srcErrs := fetchSources(ctx, urls)
+	return mergeSources(srcs), srcErrs
+}
+
+// catalogSource is a checktype catalog along with the URL it was
+// retrieved from and its priority.
+type catalogSource struct {
+	URL      string
+	Priority int
+	Data     catalogData
+}
+
+// fetchSources retrieves the specified checktype catalogs
+// concurrently. It returns the catalogs that could be retrieved and
+// the errors of the ones that could not, both in the order they are
+// specified.
+func fetchSources(ctx context.Context, urls []config.ChecktypeURL) ([]catalogSource, []SourceError) {
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, maxConcurrentFetches)
+		results = make([]catalogData, len(urls))
+		errs    = make([]error, len(urls))
+	)
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url config.ChecktypeURL) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i], errs[i] = fetchCatalog(ctx, url)
+		}(i, url)
+	}
+	wg.Wait()
+
+	var (
+		srcs    []catalogSource
+		srcErrs []SourceError
+	)
+	for i := range results {
+		if errs[i] != nil {
+			srcErrs = append(srcErrs, SourceError{URL: urls[i].URL, Err: errs[i]})
+			continue
+		}
+		srcs = append(srcs, catalogSource{URL: urls[i].URL, Priority: urls[i].Priority, Data: results[i]})
+	}
+	return srcs, srcErrs
+}
+
+// mergeSources merges the provided catalogs in a single catalog.
+func mergeSources(srcs []catalogSource) Catalog {
+	// Catalogs with higher priority are merged later, so they
+	// override the checktypes of the others. The sort is stable,
+	// so ties are resolved by the order of the URLs.
+	srcs = slices.Clone(srcs)
+	slices.SortStableFunc(srcs, func(a, b catalogSource) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	})
+
+	catalog := make(Catalog)
+	for _, src := range srcs {
+		for _, checktype := range src.Data.Checktypes {
 			catalog[checktype.Name] = checktype
 		}
 	}
-	return catalog, nil
+	return catalog
+}
+
+// catalogTokenEnv is the environment variable that contains the
+// bearer token sent when retrieving catalogs via HTTP.
+const catalogTokenEnv = "LAVA_CATALOG_TOKEN"
+
+// catalogHeader returns the headers of the HTTP requests used to
+// retrieve the catalogs. If auth is true and the environment
+// variable LAVA_CATALOG_TOKEN is set, its value is sent as a bearer
+// token.
+func catalogHeader(auth bool) http.Header {
+	header := make(http.Header)
+	if !auth {
+		return header
+	}
+	if token := os.Getenv(catalogTokenEnv); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return header
+}
+
+// catalogData is the decoded content of a checktype catalog.
+type catalogData struct {
+	// Checktypes are the checktypes of the catalog.
+	Checktypes []Checktype `json:"checktypes"`
+}
+
+// fetchCatalog retrieves and decodes the checktype catalog pointed
+// by the provided URL.
+func fetchCatalog(ctx context.Context, url config.ChecktypeURL) (catalogData, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if strings.HasPrefix(url.URL, ociScheme) {
+		data, err = fetchOCI(ctx, url.URL)
+	} else {
+		data, err = urlutil.GetWithOptions(ctx, url.URL, urlutil.Options{
+			Client: newCachingClient(),
+			Header: catalogHeader(url.Auth),
+		})
+	}
+	if err != nil {
+		return catalogData{}, err
+	}
+
+	var decData catalogData
+	if err := json.Unmarshal(data, &decData); err != nil {
+		return catalogData{}, fmt.Errorf("%w: %w", ErrMalformedCatalog, err)
+	}
+	return decData, nil
 }
@@ -3,15 +3,34 @@
 package checktypes
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
 	types "github.com/adevinta/vulcan-types"
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/urlutil"
 )
 
+// mkURLs returns the provided URLs as checktype catalogs with the
+// default settings.
+func mkURLs(urls ...string) []config.ChecktypeURL {
+	var cts []config.ChecktypeURL
+	for _, u := range urls {
+		cts = append(cts, config.ChecktypeURL{URL: u})
+	}
+	return cts
+}
+
 func TestAccepts(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -60,25 +79,27 @@ func TestAccepts(t *testing.T) {
 func TestNewCatalog(t *testing.T) {
 	tests := []struct {
 		name    string
-		urls    []string
+		urls    []config.ChecktypeURL
 		want    Catalog
 		wantErr error
 	}{
 		{
 			name: "valid file",
-			urls: []string{
-				"testdata/checktype_catalog.json",
+			urls: []config.ChecktypeURL{
+				{URL: "testdata/checktype_catalog.json"},
 			},
 			want: Catalog{
 				"vulcan-drupal": {
-					Name:        "vulcan-drupal",
-					Description: "Checks for some vulnerable versions of Drupal.",
-					Image:       "vulcansec/vulcan-drupal:edge",
-					Assets: []string{
-						"Hostname",
-					},
-					RequiredVars: []any{
-						"REQUIRED_VAR_1",
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-drupal",
+						Description: "Checks for some vulnerable versions of Drupal.",
+						Image:       "vulcansec/vulcan-drupal:edge",
+						Assets: []string{
+							"Hostname",
+						},
+						RequiredVars: []any{
+							"REQUIRED_VAR_1",
+						},
 					},
 				},
 			},
@@ -86,17 +107,109 @@ func TestNewCatalog(t *testing.T) {
 		},
 		{
 			name: "checktype catalog override",
-			urls: []string{
-				"testdata/checktype_catalog.json",
-				"testdata/checktype_catalog_override.json",
+			urls: []config.ChecktypeURL{
+				{URL: "testdata/checktype_catalog.json"},
+				{URL: "testdata/checktype_catalog_override.json"},
+			},
+			want: Catalog{
+				"vulcan-drupal": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-drupal",
+						Description: "Checks for some vulnerable versions of Drupal (overridden).",
+						Image:       "vulcansec/vulcan-drupal:overridden",
+						Assets: []string{
+							"Hostname",
+						},
+					},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "higher priority wins",
+			urls: []config.ChecktypeURL{
+				{URL: "testdata/checktype_catalog_priority.json", Priority: 10},
+				{URL: "testdata/checktype_catalog.json"},
+				{URL: "testdata/checktype_catalog_override.json"},
+			},
+			want: Catalog{
+				"vulcan-drupal": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-drupal",
+						Description: "Checks for some vulnerable versions of Drupal (prioritized).",
+						Image:       "vulcansec/vulcan-drupal:prioritized",
+						Assets: []string{
+							"Hostname",
+						},
+					},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "same priority last wins",
+			urls: []config.ChecktypeURL{
+				{URL: "testdata/checktype_catalog_override.json"},
+				{URL: "testdata/checktype_catalog_priority.json", Priority: 10},
+				{URL: "testdata/checktype_catalog_priority_tie.json", Priority: 10},
 			},
 			want: Catalog{
 				"vulcan-drupal": {
-					Name:        "vulcan-drupal",
-					Description: "Checks for some vulnerable versions of Drupal (overridden).",
-					Image:       "vulcansec/vulcan-drupal:overridden",
-					Assets: []string{
-						"Hostname",
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-drupal",
+						Description: "Checks for some vulnerable versions of Drupal (tie).",
+						Image:       "vulcansec/vulcan-drupal:tie",
+						Assets: []string{
+							"Hostname",
+						},
+					},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "catalog priority ignored",
+			urls: []config.ChecktypeURL{
+				{URL: "testdata/checktype_catalog_priority.json"},
+				{URL: "testdata/checktype_catalog_override.json"},
+			},
+			want: Catalog{
+				"vulcan-drupal": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-drupal",
+						Description: "Checks for some vulnerable versions of Drupal (overridden).",
+						Image:       "vulcansec/vulcan-drupal:overridden",
+						Assets: []string{
+							"Hostname",
+						},
+					},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "asset type options",
+			urls: []config.ChecktypeURL{
+				{URL: "testdata/checktype_catalog_asset_type_options.json"},
+			},
+			want: Catalog{
+				"vulcan-nmap": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-nmap",
+						Description: "Runs nmap.",
+						Image:       "vulcansec/vulcan-nmap:edge",
+						Assets: []string{
+							"IP",
+							"Hostname",
+						},
+						Options: map[string]any{
+							"timing": float64(3),
+						},
+					},
+					AssetTypeOptions: map[string]map[string]any{
+						"IP": {
+							"ports": "1-65535",
+						},
 					},
 				},
 			},
@@ -104,16 +217,16 @@ func TestNewCatalog(t *testing.T) {
 		},
 		{
 			name: "wrong file",
-			urls: []string{
-				"testdata/not_exists",
+			urls: []config.ChecktypeURL{
+				{URL: "testdata/not_exists"},
 			},
 			want:    nil,
 			wantErr: os.ErrNotExist,
 		},
 		{
 			name: "invalid file",
-			urls: []string{
-				"testdata/invalid_checktype_catalog.json",
+			urls: []config.ChecktypeURL{
+				{URL: "testdata/invalid_checktype_catalog.json"},
 			},
 			want:    nil,
 			wantErr: ErrMalformedCatalog,
@@ -134,3 +247,651 @@ func TestNewCatalog(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCatalog_concurrent(t *testing.T) {
+	const delay = 500 * time.Millisecond
+
+	// The first catalog is the slowest one, so it is retrieved
+	// last. However, it must not override the checktypes of the
+	// following catalogs.
+	var urls []string
+	for i := 0; i < 4; i++ {
+		d := delay - time.Duration(i)*100*time.Millisecond
+		body := fmt.Sprintf(`{"checktypes": [{"name": "vulcan-drupal", "image": "vulcansec/vulcan-drupal:%v"}]}`, i)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(d)
+			fmt.Fprint(w, body)
+		}))
+		defer ts.Close()
+		urls = append(urls, ts.URL)
+	}
+
+	start := time.Now()
+	got, err := NewCatalog(mkURLs(urls...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	want := Catalog{
+		"vulcan-drupal": {
+			Checktype: checkcatalog.Checktype{
+				Name:  "vulcan-drupal",
+				Image: "vulcansec/vulcan-drupal:3",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+	}
+
+	if elapsed >= 2*delay {
+		t.Errorf("catalogs were not retrieved concurrently: elapsed: %v", elapsed)
+	}
+}
+
+func TestNewCatalogContext_cancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewCatalogContext(ctx, mkURLs(ts.URL+"/checktypes.json", "testdata/checktype_catalog.json"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error: want: %v, got: %v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("request was not aborted: %v", d)
+	}
+}
+
+func TestNewCatalog_token(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"checktypes": [{"name": "vulcan-drupal", "image": "vulcansec/vulcan-drupal:edge"}]}`)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name           string
+		token          string
+		urls           []config.ChecktypeURL
+		wantStatusCode int
+		wantNilErr     bool
+	}{
+		{
+			name:       "valid token",
+			token:      "s3cr3t",
+			urls:       []config.ChecktypeURL{{URL: ts.URL, Auth: true}},
+			wantNilErr: true,
+		},
+		{
+			name:           "invalid token",
+			token:          "wr0ng",
+			urls:           []config.ChecktypeURL{{URL: ts.URL, Auth: true}},
+			wantStatusCode: http.StatusUnauthorized,
+			wantNilErr:     false,
+		},
+		{
+			name:           "no token",
+			token:          "",
+			urls:           []config.ChecktypeURL{{URL: ts.URL, Auth: true}},
+			wantStatusCode: http.StatusUnauthorized,
+			wantNilErr:     false,
+		},
+		{
+			name:           "auth disabled",
+			token:          "s3cr3t",
+			urls:           []config.ChecktypeURL{{URL: ts.URL}},
+			wantStatusCode: http.StatusUnauthorized,
+			wantNilErr:     false,
+		},
+		{
+			name:       "file ignores token",
+			token:      "s3cr3t",
+			urls:       []config.ChecktypeURL{{URL: "testdata/checktype_catalog.json", Auth: true}},
+			wantNilErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LAVA_CATALOG_TOKEN", tt.token)
+
+			_, err := NewCatalog(tt.urls)
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error: want nil: %v, got: %v", tt.wantNilErr, err)
+			}
+
+			if tt.wantStatusCode != 0 {
+				var httpErr urlutil.HTTPError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("error is not an HTTPError: %v", err)
+				}
+				if httpErr.StatusCode != tt.wantStatusCode {
+					t.Errorf("unexpected status code: want: %v, got: %v", tt.wantStatusCode, httpErr.StatusCode)
+				}
+			}
+
+			if err != nil && tt.token != "" && strings.Contains(err.Error(), tt.token) {
+				t.Errorf("token found in error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewCatalog_token_other_hosts(t *testing.T) {
+	t.Setenv("LAVA_CATALOG_TOKEN", "s3cr3t")
+
+	var authHeader, otherHeader string
+	authTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"checktypes": [{"name": "vulcan-drupal", "image": "vulcansec/vulcan-drupal:edge"}]}`)
+	}))
+	defer authTS.Close()
+
+	otherTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherHeader = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"checktypes": [{"name": "vulcan-nessus", "image": "vulcansec/vulcan-nessus:edge"}]}`)
+	}))
+	defer otherTS.Close()
+
+	urls := []config.ChecktypeURL{
+		{URL: authTS.URL, Auth: true},
+		{URL: otherTS.URL},
+	}
+	if _, err := NewCatalog(urls); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "Bearer s3cr3t"; authHeader != want {
+		t.Errorf("unexpected Authorization header: want: %q, got: %q", want, authHeader)
+	}
+	if otherHeader != "" {
+		t.Errorf("token sent to other host: %q", otherHeader)
+	}
+}
+
+func TestNewCatalog_concurrentPriority(t *testing.T) {
+	// The catalog with the highest priority is the slowest one.
+	// It must win regardless of the order the catalogs are
+	// retrieved.
+	var urls []config.ChecktypeURL
+	for i := 0; i < 3; i++ {
+		d := time.Duration(3-i) * 100 * time.Millisecond
+		priority := 0
+		if i == 0 {
+			priority = 1
+		}
+		body := fmt.Sprintf(`{"checktypes": [{"name": "vulcan-drupal", "image": "vulcansec/vulcan-drupal:%v"}]}`, i)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(d)
+			fmt.Fprint(w, body)
+		}))
+		defer ts.Close()
+		urls = append(urls, config.ChecktypeURL{URL: ts.URL, Priority: priority})
+	}
+
+	got, err := NewCatalog(urls)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := Catalog{
+		"vulcan-drupal": {
+			Checktype: checkcatalog.Checktype{
+				Name:  "vulcan-drupal",
+				Image: "vulcansec/vulcan-drupal:0",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestNewCatalog_errors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	urls := []string{
+		"testdata/checktype_catalog.json",
+		"testdata/not_exists",
+		ts.URL,
+		"testdata/invalid_checktype_catalog.json",
+	}
+
+	_, err := NewCatalog(mkURLs(urls...))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, wantErr := range []error{os.ErrNotExist, ErrMalformedCatalog} {
+		if !errors.Is(err, wantErr) {
+			t.Errorf("unexpected error: want: %v, got: %v", wantErr, err)
+		}
+	}
+
+	for _, url := range urls[1:] {
+		if !strings.Contains(err.Error(), url) {
+			t.Errorf("URL %q not found in error: %v", url, err)
+		}
+	}
+}
+
+func TestNewPartialCatalog(t *testing.T) {
+	tests := []struct {
+		name        string
+		urls        []string
+		want        Catalog
+		wantSrcErrs []string
+		wantErr     error
+	}{
+		{
+			name: "all catalogs retrieved",
+			urls: []string{
+				"testdata/checktype_catalog.json",
+			},
+			want: Catalog{
+				"vulcan-drupal": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-drupal",
+						Description: "Checks for some vulnerable versions of Drupal.",
+						Image:       "vulcansec/vulcan-drupal:edge",
+						Assets: []string{
+							"Hostname",
+						},
+						RequiredVars: []any{
+							"REQUIRED_VAR_1",
+						},
+					},
+				},
+			},
+			wantSrcErrs: nil,
+			wantErr:     nil,
+		},
+		{
+			name: "some catalogs fail",
+			urls: []string{
+				"testdata/not_exists",
+				"testdata/checktype_catalog_override.json",
+				"testdata/invalid_checktype_catalog.json",
+			},
+			want: Catalog{
+				"vulcan-drupal": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-drupal",
+						Description: "Checks for some vulnerable versions of Drupal (overridden).",
+						Image:       "vulcansec/vulcan-drupal:overridden",
+						Assets: []string{
+							"Hostname",
+						},
+					},
+				},
+			},
+			wantSrcErrs: []string{
+				"testdata/not_exists",
+				"testdata/invalid_checktype_catalog.json",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "all catalogs fail",
+			urls: []string{
+				"testdata/not_exists",
+				"testdata/invalid_checktype_catalog.json",
+			},
+			want: nil,
+			wantSrcErrs: []string{
+				"testdata/not_exists",
+				"testdata/invalid_checktype_catalog.json",
+			},
+			wantErr: ErrNoCatalogs,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, srcErrs, err := NewPartialCatalog(mkURLs(tt.urls...))
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+			}
+
+			var gotSrcErrs []string
+			for _, srcErr := range srcErrs {
+				gotSrcErrs = append(gotSrcErrs, srcErr.URL)
+			}
+			if diff := cmp.Diff(tt.wantSrcErrs, gotSrcErrs); diff != "" {
+				t.Errorf("source errors mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestNewCatalogStrict(t *testing.T) {
+	tests := []struct {
+		name       string
+		urls       []string
+		wantDups   []DuplicateError
+		wantNilErr bool
+	}{
+		{
+			name: "no duplicates",
+			urls: []string{
+				"testdata/checktype_catalog.json",
+				"testdata/checktype_catalog_asset_type_options.json",
+			},
+			wantDups:   nil,
+			wantNilErr: true,
+		},
+		{
+			name: "duplicates",
+			urls: []string{
+				"testdata/checktype_catalog.json",
+				"testdata/checktype_catalog_asset_type_options.json",
+				"testdata/checktype_catalog_override.json",
+				"testdata/checktype_catalog_priority.json",
+			},
+			wantDups: []DuplicateError{
+				{
+					Name: "vulcan-drupal",
+					URLs: []string{
+						"testdata/checktype_catalog.json",
+						"testdata/checktype_catalog_override.json",
+						"testdata/checktype_catalog_priority.json",
+					},
+				},
+			},
+			wantNilErr: false,
+		},
+		{
+			name: "invalid catalog",
+			urls: []string{
+				"testdata/checktype_catalog.json",
+				"testdata/invalid_checktype_catalog.json",
+			},
+			wantDups:   nil,
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCatalogStrict(mkURLs(tt.urls...))
+
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err != nil {
+				if got != nil {
+					t.Errorf("unexpected catalog: %v", got)
+				}
+
+				var gotDups []DuplicateError
+				if joinErr, ok := err.(interface{ Unwrap() []error }); ok {
+					for _, e := range joinErr.Unwrap() {
+						var dupErr DuplicateError
+						if errors.As(e, &dupErr) {
+							gotDups = append(gotDups, dupErr)
+						}
+					}
+				}
+				if diff := cmp.Diff(tt.wantDups, gotDups); diff != "" {
+					t.Errorf("duplicates mismatch (-want +got):\n%v", diff)
+				}
+				if len(tt.wantDups) > 0 && !errors.Is(err, ErrDuplicatedChecktype) {
+					t.Errorf("error does not wrap ErrDuplicatedChecktype: %v", err)
+				}
+				return
+			}
+
+			want, err := NewCatalog(mkURLs(tt.urls...))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestChecktype_OptionsFor(t *testing.T) {
+	ct := Checktype{
+		Checktype: checkcatalog.Checktype{
+			Options: map[string]any{
+				"option1": "checktype",
+				"option2": "checktype",
+			},
+		},
+		AssetTypeOptions: map[string]map[string]any{
+			"IP": {
+				"option2": "asset type",
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		assetType types.AssetType
+		want      map[string]any
+	}{
+		{
+			name:      "asset type with options",
+			assetType: types.IP,
+			want: map[string]any{
+				"option1": "checktype",
+				"option2": "asset type",
+			},
+		},
+		{
+			name:      "asset type without options",
+			assetType: types.Hostname,
+			want: map[string]any{
+				"option1": "checktype",
+				"option2": "checktype",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ct.OptionsFor(tt.assetType)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("options mismatch (-want +got):\n%v", diff)
+			}
+			if got["option2"] == "asset type" && ct.Options["option2"] != "checktype" {
+				t.Errorf("checktype options modified")
+			}
+		})
+	}
+}
+
+func TestCatalog_FilterByAssetType(t *testing.T) {
+	catalog := Catalog{
+		"vulcan-drupal": {
+			Checktype: checkcatalog.Checktype{
+				Name:   "vulcan-drupal",
+				Assets: []string{"Hostname", "WebAddress"},
+			},
+		},
+		"vulcan-nmap": {
+			Checktype: checkcatalog.Checktype{
+				Name:   "vulcan-nmap",
+				Assets: []string{"Hostname", "IP"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		at   types.AssetType
+		want Catalog
+	}{
+		{
+			name: "several matches",
+			at:   types.Hostname,
+			want: catalog,
+		},
+		{
+			name: "single match",
+			at:   types.IP,
+			want: Catalog{
+				"vulcan-nmap": catalog["vulcan-nmap"],
+			},
+		},
+		{
+			name: "no matches",
+			at:   types.DockerImage,
+			want: Catalog{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := catalog.FilterByAssetType(tt.at)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("catalogs mismatch (-want +got):\n%v", diff)
+			}
+			if len(catalog) != 2 {
+				t.Errorf("catalog was modified: %v", catalog)
+			}
+		})
+	}
+}
+
+func TestCatalog_FilterByNames(t *testing.T) {
+	catalog := Catalog{
+		"vulcan-drupal": {
+			Checktype: checkcatalog.Checktype{
+				Name: "vulcan-drupal",
+			},
+		},
+		"vulcan-nmap": {
+			Checktype: checkcatalog.Checktype{
+				Name: "vulcan-nmap",
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		want  Catalog
+	}{
+		{
+			name:  "existing names",
+			names: []string{"vulcan-nmap", "vulcan-drupal"},
+			want:  catalog,
+		},
+		{
+			name:  "unknown names",
+			names: []string{"vulcan-nmap", "vulcan-unknown"},
+			want: Catalog{
+				"vulcan-nmap": catalog["vulcan-nmap"],
+			},
+		},
+		{
+			name:  "no names",
+			names: nil,
+			want:  Catalog{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := catalog.FilterByNames(tt.names...)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("catalogs mismatch (-want +got):\n%v", diff)
+			}
+			if len(catalog) != 2 {
+				t.Errorf("catalog was modified: %v", catalog)
+			}
+		})
+	}
+}
+
+func TestCatalog_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		catalog  Catalog
+		wantErrs []string
+	}{
+		{
+			name: "valid catalog",
+			catalog: Catalog{
+				"vulcan-drupal": {
+					Checktype: checkcatalog.Checktype{
+						Name:   "vulcan-drupal",
+						Image:  "vulcansec/vulcan-drupal:edge",
+						Assets: []string{"Hostname"},
+					},
+				},
+			},
+			wantErrs: nil,
+		},
+		{
+			name:     "empty catalog",
+			catalog:  Catalog{},
+			wantErrs: nil,
+		},
+		{
+			name: "invalid checktypes",
+			catalog: Catalog{
+				"vulcan-nmap": {
+					Checktype: checkcatalog.Checktype{
+						Name:   "vulcan-nmap",
+						Image:  " ",
+						Assets: []string{"IP"},
+					},
+				},
+				"vulcan-drupal": {
+					Checktype: checkcatalog.Checktype{
+						Name:  "vulcan-drupal-typo",
+						Image: "vulcansec/vulcan-drupal:edge",
+					},
+				},
+			},
+			wantErrs: []string{
+				`invalid checktype: vulcan-drupal: name mismatch: "vulcan-drupal-typo"`,
+				"invalid checktype: vulcan-drupal: no assets",
+				"invalid checktype: vulcan-nmap: no image",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.catalog.Validate()
+
+			if (err == nil) != (len(tt.wantErrs) == 0) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil {
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidChecktype) {
+				t.Errorf("error does not wrap ErrInvalidChecktype: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantErrs, strings.Split(err.Error(), "\n")); diff != "" {
+				t.Errorf("errors mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Adevinta
+
+package checktypes
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+
+	"github.com/distribution/reference"
+)
+
+// ErrInvalidImage is returned by [Catalog.RewriteImages] when an
+// image reference is not valid.
+var ErrInvalidImage = errors.New("invalid image reference")
+
+// RewriteImages rewrites the image references of the checktypes in
+// the catalog using the provided [text/template]. The template is
+// executed with a map that contains the provided variables and the
+// following keys, which take precedence:
+//
+//   - Checktype: name of the checktype.
+//   - Domain: registry domain of the original image. For instance,
+//     "docker.io".
+//   - Path: repository path of the original image. For instance,
+//     "vulcansec/vulcan-drupal".
+//   - Name: last element of the repository path of the original
+//     image. For instance, "vulcan-drupal".
+//   - Tag: tag of the original image. If the image has no tag,
+//     "latest" is used.
+//
+// If the original image reference contains a digest and the rewritten
+// one does not, the digest is appended to the rewritten reference. It
+// returns an error if the original or the rewritten references are
+// not valid.
+func (c Catalog) RewriteImages(tmpl string, vars map[string]string) error {
+	t, err := template.New("").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("parse template: %w", err)
+	}
+
+	for name, ct := range c {
+		image, err := rewriteImage(t, ct, vars)
+		if err != nil {
+			return fmt.Errorf("checktype %v: %w", name, err)
+		}
+		ct.Image = image
+		c[name] = ct
+	}
+	return nil
+}
+
+// rewriteImage rewrites the image reference of the provided
+// checktype. See [Catalog.RewriteImages].
+func rewriteImage(t *template.Template, ct Checktype, vars map[string]string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ct.Image)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v: %w", ErrInvalidImage, ct.Image, err)
+	}
+
+	path := reference.Path(named)
+
+	tag := "latest"
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+
+	data := make(map[string]string)
+	maps.Copy(data, vars)
+	data["Checktype"] = ct.Name
+	data["Domain"] = reference.Domain(named)
+	data["Path"] = path
+	data["Name"] = path[strings.LastIndex(path, "/")+1:]
+	data["Tag"] = tag
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+	image := sb.String()
+
+	rewritten, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v: %w", ErrInvalidImage, image, err)
+	}
+
+	// Preserve the digest of the original image.
+	if digested, ok := named.(reference.Digested); ok {
+		if _, ok := rewritten.(reference.Digested); !ok {
+			image += "@" + digested.Digest().String()
+			if _, err := reference.ParseNormalizedNamed(image); err != nil {
+				return "", fmt.Errorf("%w: %v: %w", ErrInvalidImage, image, err)
+			}
+		}
+	}
+
+	return image, nil
+}
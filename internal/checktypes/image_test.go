@@ -0,0 +1,113 @@
+// Copyright 2023 Adevinta
+
+package checktypes
+
+import (
+	"errors"
+	"testing"
+
+	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
+)
+
+func TestCatalog_RewriteImages(t *testing.T) {
+	const digest = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	tests := []struct {
+		name       string
+		image      string
+		tmpl       string
+		vars       map[string]string
+		want       string
+		wantErr    error
+		wantNilErr bool
+	}{
+		{
+			name:       "registry variable",
+			image:      "vulcansec/vulcan-drupal:edge",
+			tmpl:       "{{.Registry}}/vulcan/{{.Name}}:{{.Tag}}",
+			vars:       map[string]string{"Registry": "registry.example.com"},
+			want:       "registry.example.com/vulcan/vulcan-drupal:edge",
+			wantNilErr: true,
+		},
+		{
+			name:       "default tag",
+			image:      "vulcansec/vulcan-drupal",
+			tmpl:       "registry.example.com/{{.Path}}:{{.Tag}}",
+			want:       "registry.example.com/vulcansec/vulcan-drupal:latest",
+			wantNilErr: true,
+		},
+		{
+			name:       "domain and checktype",
+			image:      "example.com:5000/vulcan-drupal:edge",
+			tmpl:       "{{.Domain}}/mirror/{{.Checktype}}:{{.Tag}}",
+			want:       "example.com:5000/mirror/vulcan-drupal:edge",
+			wantNilErr: true,
+		},
+		{
+			name:       "built-in variables take precedence",
+			image:      "vulcansec/vulcan-drupal:edge",
+			tmpl:       "registry.example.com/{{.Name}}:{{.Tag}}",
+			vars:       map[string]string{"Tag": "overridden"},
+			want:       "registry.example.com/vulcan-drupal:edge",
+			wantNilErr: true,
+		},
+		{
+			name:       "preserve digest",
+			image:      "vulcansec/vulcan-drupal:edge@" + digest,
+			tmpl:       "registry.example.com/{{.Name}}:{{.Tag}}",
+			want:       "registry.example.com/vulcan-drupal:edge@" + digest,
+			wantNilErr: true,
+		},
+		{
+			name:       "missing variable",
+			image:      "vulcansec/vulcan-drupal:edge",
+			tmpl:       "{{.Registry}}/{{.Name}}:{{.Tag}}",
+			wantNilErr: false,
+		},
+		{
+			name:       "invalid rewritten reference",
+			image:      "vulcansec/vulcan-drupal:edge",
+			tmpl:       "Registry.Example.com/{{.Name}}:{{.Tag}}:{{.Tag}}",
+			wantErr:    ErrInvalidImage,
+			wantNilErr: false,
+		},
+		{
+			name:       "invalid original reference",
+			image:      "vulcansec/vulcan-drupal:",
+			tmpl:       "registry.example.com/{{.Name}}:{{.Tag}}",
+			wantErr:    ErrInvalidImage,
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := Catalog{
+				"vulcan-drupal": {
+					Checktype: checkcatalog.Checktype{
+						Name:  "vulcan-drupal",
+						Image: tt.image,
+					},
+				},
+			}
+
+			err := catalog.RewriteImages(tt.tmpl, tt.vars)
+
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got := catalog["vulcan-drupal"].Image; got != tt.want {
+				t.Errorf("unexpected image: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 Adevinta
+
+package checktypes
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	types "github.com/adevinta/vulcan-types"
+)
+
+// Index is a read-only index of a [Catalog] that allows to query its
+// checktypes. It is safe for concurrent use.
+type Index struct {
+	// names contains the names of the checktypes of the catalog
+	// sorted alphabetically.
+	names []string
+
+	// checktypes contains the checktypes indexed by name.
+	checktypes map[string]Checktype
+
+	// byAssetType contains the names of the checktypes indexed by
+	// the asset types they accept.
+	byAssetType map[types.AssetType][]string
+
+	// withVars contains the names of the checktypes that require
+	// at least one variable.
+	withVars map[string]bool
+}
+
+// NewIndex returns an [Index] of the provided catalog. The catalog
+// is copied, so later modifications are not reflected in the index.
+func NewIndex(catalog Catalog) *Index {
+	idx := &Index{
+		checktypes:  make(map[string]Checktype),
+		byAssetType: make(map[types.AssetType][]string),
+		withVars:    make(map[string]bool),
+	}
+
+	for name, ct := range catalog {
+		idx.names = append(idx.names, name)
+		idx.checktypes[name] = ct
+		if len(RequiredVars(ct)) > 0 {
+			idx.withVars[name] = true
+		}
+	}
+	slices.Sort(idx.names)
+
+	for _, name := range idx.names {
+		ct := idx.checktypes[name]
+		for _, a := range ct.Assets {
+			at := types.AssetType(a)
+			if !slices.Contains(idx.byAssetType[at], name) {
+				idx.byAssetType[at] = append(idx.byAssetType[at], name)
+			}
+		}
+	}
+	return idx
+}
+
+// RequiredVars returns the names of the variables required by the
+// provided checktype. Values that are not strings are ignored.
+func RequiredVars(ct Checktype) []string {
+	var vars []string
+	switch rv := ct.RequiredVars.(type) {
+	case []string:
+		vars = append(vars, rv...)
+	case []any:
+		for _, v := range rv {
+			if s, ok := v.(string); ok {
+				vars = append(vars, s)
+			}
+		}
+	}
+	return vars
+}
+
+// Names returns the names of all the checktypes sorted
+// alphabetically.
+func (idx *Index) Names() []string {
+	return slices.Clone(idx.names)
+}
+
+// ByAssetType returns the checktypes that accept the provided asset
+// type sorted by name.
+func (idx *Index) ByAssetType(at types.AssetType) []Checktype {
+	return idx.lookup(idx.byAssetType[at])
+}
+
+// ByRequiredVars returns the checktypes sorted by name that require
+// at least one variable if required is true, or that do not require
+// any variable otherwise.
+func (idx *Index) ByRequiredVars(required bool) []Checktype {
+	var names []string
+	for _, name := range idx.names {
+		if idx.withVars[name] == required {
+			names = append(names, name)
+		}
+	}
+	return idx.lookup(names)
+}
+
+// ByName returns the checktypes whose name matches the provided
+// pattern sorted by name. The pattern syntax is the one accepted by
+// [path.Match].
+func (idx *Index) ByName(pattern string) ([]Checktype, error) {
+	var names []string
+	for _, name := range idx.names {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, fmt.Errorf("match name: %w", err)
+		}
+		if ok {
+			names = append(names, name)
+		}
+	}
+	return idx.lookup(names), nil
+}
+
+// Query represents a set of conditions on the checktypes of an
+// [Index]. The zero value of every field matches any checktype.
+type Query struct {
+	// AssetType is an asset type that must be accepted by the
+	// checktypes.
+	AssetType types.AssetType
+
+	// RequiredVars specifies whether the checktypes must require
+	// variables.
+	RequiredVars *bool
+
+	// Name is a pattern that must match the name of the
+	// checktypes. See [Index.ByName].
+	Name string
+}
+
+// Find returns the checktypes that match all the conditions of the
+// provided query sorted by name.
+func (idx *Index) Find(q Query) ([]Checktype, error) {
+	var names []string
+	for _, name := range idx.names {
+		if q.AssetType != "" && !Accepts(idx.checktypes[name].Checktype, q.AssetType) {
+			continue
+		}
+
+		if q.RequiredVars != nil && idx.withVars[name] != *q.RequiredVars {
+			continue
+		}
+
+		if q.Name != "" {
+			ok, err := path.Match(q.Name, name)
+			if err != nil {
+				return nil, fmt.Errorf("match name: %w", err)
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		names = append(names, name)
+	}
+	return idx.lookup(names), nil
+}
+
+// lookup returns the checktypes with the provided names.
+func (idx *Index) lookup(names []string) []Checktype {
+	var cts []Checktype
+	for _, name := range names {
+		cts = append(cts, idx.checktypes[name])
+	}
+	return cts
+}
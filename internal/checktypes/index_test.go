@@ -0,0 +1,258 @@
+// Copyright 2023 Adevinta
+
+package checktypes
+
+import (
+	"testing"
+
+	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+)
+
+var indexTestCatalog = Catalog{
+	"vulcan-drupal": {
+		Checktype: checkcatalog.Checktype{
+			Name:         "vulcan-drupal",
+			Assets:       []string{"Hostname", "WebAddress"},
+			RequiredVars: []any{"DRUPAL_TOKEN"},
+		},
+	},
+	"vulcan-http-headers": {
+		Checktype: checkcatalog.Checktype{
+			Name:   "vulcan-http-headers",
+			Assets: []string{"WebAddress"},
+		},
+	},
+	"vulcan-nmap": {
+		Checktype: checkcatalog.Checktype{
+			Name:         "vulcan-nmap",
+			Assets:       []string{"IP", "Hostname"},
+			RequiredVars: []any{},
+		},
+	},
+	"lava-gitleaks": {
+		Checktype: checkcatalog.Checktype{
+			Name:         "lava-gitleaks",
+			Assets:       []string{"GitRepository", "Path"},
+			RequiredVars: []string{"GITHUB_TOKEN"},
+		},
+	},
+}
+
+// names returns the names of the provided checktypes.
+func names(cts []Checktype) []string {
+	var ns []string
+	for _, ct := range cts {
+		ns = append(ns, ct.Name)
+	}
+	return ns
+}
+
+func TestIndex_Names(t *testing.T) {
+	want := []string{"lava-gitleaks", "vulcan-drupal", "vulcan-http-headers", "vulcan-nmap"}
+	got := NewIndex(indexTestCatalog).Names()
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("names mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestIndex_ByAssetType(t *testing.T) {
+	tests := []struct {
+		name      string
+		assetType types.AssetType
+		want      []string
+	}{
+		{
+			name:      "several checktypes",
+			assetType: types.WebAddress,
+			want:      []string{"vulcan-drupal", "vulcan-http-headers"},
+		},
+		{
+			name:      "single checktype",
+			assetType: types.IP,
+			want:      []string{"vulcan-nmap"},
+		},
+		{
+			name:      "no checktypes",
+			assetType: types.AWSAccount,
+			want:      nil,
+		},
+	}
+
+	idx := NewIndex(indexTestCatalog)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(idx.ByAssetType(tt.assetType))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestIndex_ByRequiredVars(t *testing.T) {
+	tests := []struct {
+		name     string
+		required bool
+		want     []string
+	}{
+		{
+			name:     "required vars",
+			required: true,
+			want:     []string{"lava-gitleaks", "vulcan-drupal"},
+		},
+		{
+			name:     "no required vars",
+			required: false,
+			want:     []string{"vulcan-http-headers", "vulcan-nmap"},
+		},
+	}
+
+	idx := NewIndex(indexTestCatalog)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(idx.ByRequiredVars(tt.required))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestIndex_ByName(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		want       []string
+		wantNilErr bool
+	}{
+		{
+			name:       "prefix",
+			pattern:    "vulcan-*",
+			want:       []string{"vulcan-drupal", "vulcan-http-headers", "vulcan-nmap"},
+			wantNilErr: true,
+		},
+		{
+			name:       "exact name",
+			pattern:    "lava-gitleaks",
+			want:       []string{"lava-gitleaks"},
+			wantNilErr: true,
+		},
+		{
+			name:       "no match",
+			pattern:    "unknown-*",
+			want:       nil,
+			wantNilErr: true,
+		},
+		{
+			name:       "malformed pattern",
+			pattern:    "vulcan-[",
+			want:       nil,
+			wantNilErr: false,
+		},
+	}
+
+	idx := NewIndex(indexTestCatalog)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cts, err := idx.ByName(tt.pattern)
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, names(cts)); diff != "" {
+				t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestIndex_Find(t *testing.T) {
+	noVars := false
+
+	tests := []struct {
+		name       string
+		query      Query
+		want       []string
+		wantNilErr bool
+	}{
+		{
+			name:       "empty query",
+			query:      Query{},
+			want:       []string{"lava-gitleaks", "vulcan-drupal", "vulcan-http-headers", "vulcan-nmap"},
+			wantNilErr: true,
+		},
+		{
+			name: "asset type and no required vars",
+			query: Query{
+				AssetType:    types.WebAddress,
+				RequiredVars: &noVars,
+			},
+			want:       []string{"vulcan-http-headers"},
+			wantNilErr: true,
+		},
+		{
+			name: "asset type and name",
+			query: Query{
+				AssetType: types.Hostname,
+				Name:      "*-nmap",
+			},
+			want:       []string{"vulcan-nmap"},
+			wantNilErr: true,
+		},
+		{
+			name: "malformed name",
+			query: Query{
+				Name: "[",
+			},
+			want:       nil,
+			wantNilErr: false,
+		},
+	}
+
+	idx := NewIndex(indexTestCatalog)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cts, err := idx.Find(tt.query)
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, names(cts)); diff != "" {
+				t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestRequiredVars(t *testing.T) {
+	tests := []struct {
+		name      string
+		checktype Checktype
+		want      []string
+	}{
+		{
+			name:      "any slice",
+			checktype: indexTestCatalog["vulcan-drupal"],
+			want:      []string{"DRUPAL_TOKEN"},
+		},
+		{
+			name:      "string slice",
+			checktype: indexTestCatalog["lava-gitleaks"],
+			want:      []string{"GITHUB_TOKEN"},
+		},
+		{
+			name:      "nil",
+			checktype: indexTestCatalog["vulcan-http-headers"],
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RequiredVars(tt.checktype)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("required vars mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
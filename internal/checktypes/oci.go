@@ -0,0 +1,342 @@
+// Copyright 2023 Adevinta
+
+package checktypes
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli/config"
+	configtypes "github.com/docker/cli/cli/config/types"
+
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+// ErrInvalidOCIArtifact is returned by [NewCatalog] when the OCI
+// artifact pointed by an oci:// URL does not contain a checktype
+// catalog.
+var ErrInvalidOCIArtifact = errors.New("invalid OCI artifact")
+
+// ociScheme is the URL scheme of the checktype catalogs stored as
+// OCI artifacts.
+const ociScheme = "oci://"
+
+// Docker Hub registry and the key of its credentials in the Docker
+// config file.
+const (
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+	dockerHubAuthKey  = "https://index.docker.io/v1/"
+)
+
+// manifestMediaTypes are the manifest media types accepted when
+// pulling an OCI artifact.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// ociManifest is an OCI image manifest. Only the fields required to
+// pull the catalog are decoded.
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// ociDescriptor describes an OCI blob.
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+// fetchOCI pulls the OCI artifact referenced by the provided oci://
+// URL and returns the checktype catalog it contains. The credentials
+// of the registry are read from the Docker config file.
+//
+// The artifact layer with a JSON media type is used. If there is no
+// such layer, the artifact must have a single layer. Tar layers,
+// optionally gzip-compressed, are extracted and their first JSON file
+// is returned.
+func fetchOCI(ctx context.Context, rawURL string) ([]byte, error) {
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(rawURL, ociScheme))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", urlutil.ErrInvalidURL, err)
+	}
+
+	rc := newRegistryClient(named)
+
+	ref := "latest"
+	if digested, ok := named.(reference.Digested); ok {
+		ref = digested.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		ref = tagged.Tag()
+	}
+
+	data, err := rc.get(ctx, "manifests/"+ref, manifestMediaTypes)
+	if err != nil {
+		return nil, fmt.Errorf("get manifest: %w", err)
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: decode manifest: %w", ErrInvalidOCIArtifact, err)
+	}
+
+	layer, err := catalogLayer(manifest.Layers)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := rc.get(ctx, "blobs/"+layer.Digest, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get layer: %w", err)
+	}
+
+	if err := verifyDigest(blob, layer.Digest); err != nil {
+		return nil, err
+	}
+	return extractLayer(blob, layer.MediaType)
+}
+
+// catalogLayer returns the layer that contains the checktype catalog.
+func catalogLayer(layers []ociDescriptor) (ociDescriptor, error) {
+	for _, l := range layers {
+		if strings.HasSuffix(l.MediaType, "json") {
+			return l, nil
+		}
+	}
+	if len(layers) != 1 {
+		return ociDescriptor{}, fmt.Errorf("%w: %v layers without JSON media type", ErrInvalidOCIArtifact, len(layers))
+	}
+	return layers[0], nil
+}
+
+// verifyDigest checks that the provided data matches the specified
+// digest. Only SHA-256 digests are supported.
+func verifyDigest(data []byte, digest string) error {
+	alg, hash, ok := strings.Cut(digest, ":")
+	if !ok || alg != "sha256" {
+		return fmt.Errorf("%w: unsupported digest: %v", ErrInvalidOCIArtifact, digest)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != hash {
+		return fmt.Errorf("%w: digest mismatch: %v", ErrInvalidOCIArtifact, digest)
+	}
+	return nil
+}
+
+// extractLayer returns the content of the provided layer. Tar layers,
+// optionally gzip-compressed, are extracted and the content of their
+// first JSON file is returned. Any other layer is returned as is.
+func extractLayer(blob []byte, mediaType string) ([]byte, error) {
+	var r io.Reader = bytes.NewReader(blob)
+	switch {
+	case strings.HasSuffix(mediaType, "tar+gzip"):
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: gzip: %w", ErrInvalidOCIArtifact, err)
+		}
+		defer zr.Close()
+		r = zr
+	case strings.HasSuffix(mediaType, "tar"):
+	default:
+		return blob, nil
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: no JSON file in layer", ErrInvalidOCIArtifact)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: tar: %w", ErrInvalidOCIArtifact, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Ext(hdr.Name) == ".json" {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// registryClient is a minimal client of the OCI distribution API
+// scoped to a single repository.
+type registryClient struct {
+	client  *http.Client
+	baseURL string
+	repo    string
+	authKey string
+
+	// authz is the value of the Authorization header obtained
+	// after the first authentication challenge.
+	authz string
+}
+
+// newRegistryClient returns a [registryClient] for the repository of
+// the provided reference. It uses [urlutil.DefaultClient].
+func newRegistryClient(named reference.Named) *registryClient {
+	domain := reference.Domain(named)
+	host, authKey := domain, domain
+	if domain == dockerHubDomain {
+		host, authKey = dockerHubRegistry, dockerHubAuthKey
+	}
+	return &registryClient{
+		client:  urlutil.DefaultClient,
+		baseURL: "https://" + host + "/v2/" + reference.Path(named) + "/",
+		repo:    reference.Path(named),
+		authKey: authKey,
+	}
+}
+
+// get retrieves the specified resource of the repository. If the
+// registry requires authentication, the credentials of the Docker
+// config file are used.
+func (rc *registryClient) get(ctx context.Context, resource string, accept []string) ([]byte, error) {
+	resp, err := rc.do(ctx, resource, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && rc.authz == "" {
+		resp.Body.Close()
+
+		if err := rc.authenticate(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+			return nil, fmt.Errorf("authenticate: %w", err)
+		}
+		if resp, err = rc.do(ctx, resource, accept); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, urlutil.NewHTTPError(rc.baseURL+resource, resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// do sends a GET request for the specified resource.
+func (rc *registryClient) do(ctx context.Context, resource string, accept []string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rc.baseURL+resource, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	for _, mt := range accept {
+		req.Header.Add("Accept", mt)
+	}
+	if rc.authz != "" {
+		req.Header.Set("Authorization", rc.authz)
+	}
+
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get %q: %w", resource, err)
+	}
+	return resp, nil
+}
+
+// challengeParamRegexp matches the parameters of a WWW-Authenticate
+// header.
+var challengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate handles the provided authentication challenge and
+// sets the Authorization header sent in the following requests.
+func (rc *registryClient) authenticate(ctx context.Context, challenge string) error {
+	auth, err := config.LoadDefaultConfigFile(io.Discard).GetAuthConfig(rc.authKey)
+	if err != nil {
+		return fmt.Errorf("get registry credentials: %w", err)
+	}
+
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if auth.Username == "" {
+			return errors.New("no credentials for basic authentication")
+		}
+		req := http.Request{Header: make(http.Header)}
+		req.SetBasicAuth(auth.Username, auth.Password)
+		rc.authz = req.Header.Get("Authorization")
+		return nil
+	case "bearer":
+		if auth.RegistryToken != "" {
+			rc.authz = "Bearer " + auth.RegistryToken
+			return nil
+		}
+		token, err := rc.fetchToken(ctx, params, auth)
+		if err != nil {
+			return err
+		}
+		rc.authz = "Bearer " + token
+		return nil
+	}
+	return fmt.Errorf("unsupported authentication challenge: %q", challenge)
+}
+
+// fetchToken requests a bearer token to the authorization server
+// specified by the parameters of a bearer challenge. If the provided
+// credentials are not empty, they are sent to the server.
+func (rc *registryClient) fetchToken(ctx context.Context, params string, auth configtypes.AuthConfig) (string, error) {
+	p := make(map[string]string)
+	for _, m := range challengeParamRegexp.FindAllStringSubmatch(params, -1) {
+		p[m[1]] = m[2]
+	}
+	if p["realm"] == "" {
+		return "", errors.New("missing realm in bearer challenge")
+	}
+
+	u, err := url.Parse(p["realm"])
+	if err != nil {
+		return "", fmt.Errorf("parse realm: %w", err)
+	}
+	q := u.Query()
+	if p["service"] != "" {
+		q.Set("service", p["service"])
+	}
+	q.Set("scope", "repository:"+rc.repo+":pull")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	if auth.Username != "" {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("get token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get token: %w", urlutil.NewHTTPError(u.String(), resp))
+	}
+
+	var tr struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", fmt.Errorf("decode token: %w", err)
+	}
+	if tr.Token != "" {
+		return tr.Token, nil
+	}
+	if tr.AccessToken != "" {
+		return tr.AccessToken, nil
+	}
+	return "", errors.New("empty token")
+}
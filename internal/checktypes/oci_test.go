@@ -0,0 +1,216 @@
+// Copyright 2023 Adevinta
+
+package checktypes
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+// testRegistry is a fake OCI registry that serves a single artifact
+// with bearer token authentication.
+type testRegistry struct {
+	user, pass string
+	layer      []byte
+	mediaType  string
+}
+
+const testRegistryToken = "test-token"
+
+func (reg testRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != reg.user || pass != reg.pass {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("scope") != "repository:lava/catalog:pull" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, `{"token": %q}`, testRegistryToken)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+testRegistryToken {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="https://%v/token",service="test"`, r.Host))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	sum := sha256.Sum256(reg.layer)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	switch r.URL.Path {
+	case "/v2/lava/catalog/manifests/v1":
+		manifest := ociManifest{
+			Layers: []ociDescriptor{{MediaType: reg.mediaType, Digest: digest}},
+		}
+		w.Header().Set("Content-Type", manifestMediaTypes[0])
+		json.NewEncoder(w).Encode(manifest) //nolint:errcheck
+	case "/v2/lava/catalog/blobs/" + digest:
+		w.Write(reg.layer) //nolint:errcheck
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// mkTarGzip returns a gzip-compressed tar archive with the provided
+// files.
+func mkTarGzip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("write content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar writer: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewCatalog_oci(t *testing.T) {
+	catalogJSON, err := os.ReadFile("testdata/checktype_catalog.json")
+	if err != nil {
+		t.Fatalf("read catalog: %v", err)
+	}
+
+	wantCatalog := Catalog{
+		"vulcan-drupal": {
+			Checktype: checkcatalog.Checktype{
+				Name:         "vulcan-drupal",
+				Description:  "Checks for some vulnerable versions of Drupal.",
+				Image:        "vulcansec/vulcan-drupal:edge",
+				Timeout:      0,
+				RequiredVars: []any{"REQUIRED_VAR_1"},
+				Assets:       []string{"Hostname"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		layer      []byte
+		mediaType  string
+		user, pass string
+		want       Catalog
+		wantErr    error
+		wantNilErr bool
+	}{
+		{
+			name:       "JSON layer",
+			layer:      catalogJSON,
+			mediaType:  "application/vnd.adevinta.lava.catalog.v1+json",
+			user:       "user",
+			pass:       "pass",
+			want:       wantCatalog,
+			wantNilErr: true,
+		},
+		{
+			name:       "tar gzip layer",
+			layer:      mkTarGzip(t, map[string]string{"catalog/checktypes.json": string(catalogJSON)}),
+			mediaType:  "application/vnd.oci.image.layer.v1.tar+gzip",
+			user:       "user",
+			pass:       "pass",
+			want:       wantCatalog,
+			wantNilErr: true,
+		},
+		{
+			name:      "malformed catalog",
+			layer:     []byte("malformed"),
+			mediaType: "application/vnd.adevinta.lava.catalog.v1+json",
+			user:      "user",
+			pass:      "pass",
+			wantErr:   ErrMalformedCatalog,
+		},
+		{
+			name:       "tar layer without JSON file",
+			layer:      mkTarGzip(t, map[string]string{"README": "catalog"}),
+			mediaType:  "application/vnd.oci.image.layer.v1.tar+gzip",
+			user:       "user",
+			pass:       "pass",
+			wantErr:    ErrInvalidOCIArtifact,
+			wantNilErr: false,
+		},
+		{
+			name:       "invalid credentials",
+			layer:      catalogJSON,
+			mediaType:  "application/vnd.adevinta.lava.catalog.v1+json",
+			user:       "user",
+			pass:       "invalid",
+			wantNilErr: false,
+		},
+	}
+
+	// The Docker CLI config package caches the config directory,
+	// so it is shared by all the test cases.
+	dockerConfig := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dockerConfig)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewTLSServer(testRegistry{
+				user:      "user",
+				pass:      "pass",
+				layer:     tt.layer,
+				mediaType: tt.mediaType,
+			})
+			defer srv.Close()
+
+			oldDefaultClient := urlutil.DefaultClient
+			defer func() { urlutil.DefaultClient = oldDefaultClient }()
+			urlutil.DefaultClient = srv.Client()
+
+			host := strings.TrimPrefix(srv.URL, "https://")
+
+			auth := base64.StdEncoding.EncodeToString([]byte(tt.user + ":" + tt.pass))
+			cfg := fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, host, auth)
+			if err := os.WriteFile(filepath.Join(dockerConfig, "config.json"), []byte(cfg), 0600); err != nil {
+				t.Fatalf("write docker config: %v", err)
+			}
+
+			got, err := NewCatalog(mkURLs("oci://" + host + "/lava/catalog:v1"))
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+				}
+			case (err == nil) != tt.wantNilErr:
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("catalogs mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -8,8 +8,16 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"net"
+	"net/url"
 	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	agentconfig "github.com/adevinta/vulcan-agent/config"
 	types "github.com/adevinta/vulcan-types"
@@ -29,6 +37,10 @@ var (
 	// specified.
 	ErrNoChecktypeURLs = errors.New("no checktype catalogs")
 
+	// ErrInvalidChecktypeURL means that a checktype catalog is
+	// not valid.
+	ErrInvalidChecktypeURL = errors.New("invalid checktype catalog")
+
 	// ErrNoTargets means that no targets were specified.
 	ErrNoTargets = errors.New("no targets")
 
@@ -49,8 +61,76 @@ var (
 	// ErrInvalidOutputFormat means that the output format is
 	// invalid.
 	ErrInvalidOutputFormat = errors.New("invalid output format")
+
+	// ErrInvalidCredentials means that the target credentials
+	// are invalid.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	// ErrInvalidTargetSource means that the target source is
+	// invalid.
+	ErrInvalidTargetSource = errors.New("invalid target source")
+
+	// ErrInvalidNetwork means that the network of the checks is
+	// invalid.
+	ErrInvalidNetwork = errors.New("invalid network")
+
+	// ErrInvalidImageTemplate means that the image template is
+	// invalid.
+	ErrInvalidImageTemplate = errors.New("invalid image template")
+
+	// ErrInvalidRetry means that the retry configuration is
+	// invalid.
+	ErrInvalidRetry = errors.New("invalid retry configuration")
+
+	// ErrInvalidReportAddr means that the address where the
+	// reports of the checks are received is not valid.
+	ErrInvalidReportAddr = errors.New("invalid report address")
+
+	// ErrInvalidGracePeriod means that the grace period of the
+	// checks is not valid.
+	ErrInvalidGracePeriod = errors.New("invalid grace period")
+
+	// ErrInvalidTimeout means that the default timeout of the
+	// checks is not valid.
+	ErrInvalidTimeout = errors.New("invalid timeout")
+
+	// ErrInvalidParallel means that the maximum number of checks
+	// that can run in parallel is not valid.
+	ErrInvalidParallel = errors.New("invalid parallel")
+
+	// ErrInvalidMaxFindings means that the maximum number of
+	// findings per check is not valid.
+	ErrInvalidMaxFindings = errors.New("invalid max findings")
+
+	// ErrInvalidMaxLogSize means that the maximum number of bytes
+	// of logs kept per check is not valid.
+	ErrInvalidMaxLogSize = errors.New("invalid max log size")
+
+	// ErrInvalidDockerAPIVersion means that the Docker API
+	// version is invalid.
+	ErrInvalidDockerAPIVersion = errors.New("invalid Docker API version")
+
+	// ErrInvalidChecktypePattern means that a checktype pattern
+	// of a target is not valid.
+	ErrInvalidChecktypePattern = errors.New("invalid checktype pattern")
+
+	// ErrInvalidCVE means that the CVE identifier of an exclusion
+	// rule is not valid.
+	ErrInvalidCVE = errors.New("invalid CVE identifier")
+
+	// ErrInvalidWebhookURL means that the URL of the webhook is
+	// not a valid HTTP or HTTPS URL.
+	ErrInvalidWebhookURL = errors.New("invalid webhook URL")
 )
 
+// dockerAPIVersionRegexp matches a valid Docker API version. For
+// instance, "1.43".
+var dockerAPIVersionRegexp = regexp.MustCompile(`^\d+\.\d+$`)
+
+// cveRegexp matches a valid CVE identifier. For instance,
+// "CVE-2023-44487".
+var cveRegexp = regexp.MustCompile(`(?i)^CVE-\d{4}-\d{4,}$`)
+
 // Config represents a Lava configuration.
 type Config struct {
 	// LavaVersion is the minimum required version of Lava.
@@ -64,11 +144,18 @@ type Config struct {
 
 	// ChecktypeURLs is a list of URLs pointing to checktype
 	// catalogs.
-	ChecktypeURLs []string `yaml:"checktypes"`
+	ChecktypeURLs []ChecktypeURL `yaml:"checktypes"`
 
 	// Targets is the list of targets.
 	Targets []Target `yaml:"targets"`
 
+	// TargetSources is a list of external sources of targets.
+	TargetSources []TargetSource `yaml:"targetSources"`
+
+	// TargetDefaults contains the default values shared by all
+	// the targets.
+	TargetDefaults TargetDefaults `yaml:"targetDefaults"`
+
 	// LogLevel is the logging level.
 	LogLevel slog.Level `yaml:"log"`
 }
@@ -85,7 +172,7 @@ func Parse(r io.Reader) (Config, error) {
 	if err := dec.Decode(&cfg); err != nil {
 		return Config{}, fmt.Errorf("decode config: %w", err)
 	}
-	if err := cfg.validate(); err != nil {
+	if err := cfg.Validate(); err != nil {
 		return Config{}, fmt.Errorf("validate config: %w", err)
 	}
 	return cfg, nil
@@ -102,8 +189,9 @@ func ParseFile(path string) (Config, error) {
 	return Parse(f)
 }
 
-// validate validates the Lava configuration.
-func (c Config) validate() error {
+// Validate validates the Lava configuration. It is called by [Parse]
+// to validate the decoded configuration.
+func (c Config) Validate() error {
 	// Lava version validation.
 	if !semver.IsValid(c.LavaVersion) {
 		return ErrInvalidLavaVersion
@@ -113,13 +201,40 @@ func (c Config) validate() error {
 	if len(c.ChecktypeURLs) == 0 {
 		return ErrNoChecktypeURLs
 	}
+	for _, u := range c.ChecktypeURLs {
+		if u.URL == "" {
+			return fmt.Errorf("%w: empty URL", ErrInvalidChecktypeURL)
+		}
+	}
 
 	// Targets validation.
-	if len(c.Targets) == 0 {
+	if len(c.Targets) == 0 && len(c.TargetSources) == 0 {
 		return ErrNoTargets
 	}
-	for _, t := range c.Targets {
-		if err := t.validate(); err != nil {
+	if err := ValidateTargets(c.Targets, c.TargetDefaults.DetectAssetType); err != nil {
+		return err
+	}
+	for _, ts := range c.TargetSources {
+		if err := ts.validate(); err != nil {
+			return err
+		}
+	}
+
+	// Agent validation.
+	if err := c.AgentConfig.validate(); err != nil {
+		return err
+	}
+
+	// Exclusions validation.
+	for _, excl := range c.ReportConfig.Exclusions {
+		if err := excl.validate(); err != nil {
+			return err
+		}
+	}
+
+	// Webhook validation.
+	if c.ReportConfig.Webhook != nil {
+		if err := c.ReportConfig.Webhook.validate(); err != nil {
 			return err
 		}
 	}
@@ -133,13 +248,61 @@ func (c Config) IsCompatible(v string) bool {
 	return semver.Compare(v, c.LavaVersion) >= 0
 }
 
+// ChecktypeURL points to a checktype catalog. In the configuration
+// file, it can be specified either as a URL or as a mapping with the
+// URL and the settings of the catalog.
+type ChecktypeURL struct {
+	// URL is the URL of the catalog.
+	URL string `yaml:"url"`
+
+	// Priority is the priority of the catalog. When a checktype
+	// is defined by several catalogs, the definition of the
+	// catalog with the highest priority wins. It is 0 by default.
+	Priority int `yaml:"priority"`
+
+	// Auth specifies whether the bearer token in the environment
+	// variable LAVA_CATALOG_TOKEN is sent when retrieving the
+	// catalog via HTTP or HTTPS.
+	Auth bool `yaml:"auth"`
+}
+
+// UnmarshalYAML decodes a ChecktypeURL yaml node containing either a
+// string with the URL of the catalog or a mapping with its settings.
+func (u *ChecktypeURL) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*u = ChecktypeURL{URL: value.Value}
+		return nil
+	}
+
+	// The known fields setting of the decoder does not apply to
+	// the nodes decoded by custom unmarshalers. So, the keys
+	// are checked explicitly.
+	if value.Kind == yaml.MappingNode {
+		for i := 0; i < len(value.Content); i += 2 {
+			switch key := value.Content[i].Value; key {
+			case "url", "priority", "auth":
+			default:
+				return fmt.Errorf("%w: unknown field: %v", ErrInvalidChecktypeURL, key)
+			}
+		}
+	}
+
+	type plain ChecktypeURL
+	var p plain
+	if err := value.Decode(&p); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidChecktypeURL, err)
+	}
+	*u = ChecktypeURL(p)
+	return nil
+}
+
 // AgentConfig is the configuration passed to the vulcan-agent.
 type AgentConfig struct {
 	// PullPolicy is the pull policy passed to vulcan-agent.
 	PullPolicy agentconfig.PullPolicy `yaml:"pullPolicy"`
 
 	// Parallel is the maximum number of checks that can run in
-	// parallel.
+	// parallel. If zero, checks are run one at a time.
 	Parallel int `yaml:"parallel"`
 
 	// Vars is the environment variables required by the Vulcan
@@ -149,6 +312,247 @@ type AgentConfig struct {
 	// RegistryAuths contains the credentials for a set of
 	// container registries.
 	RegistryAuths []RegistryAuth `yaml:"registries"`
+
+	// MaxFindings is the maximum number of findings accepted per
+	// check. The exceeding findings are discarded.
+	MaxFindings int `yaml:"maxFindings"`
+
+	// MaxLogSize is the maximum number of bytes of logs kept per
+	// check. If the logs are longer, only the last bytes are
+	// kept.
+	MaxLogSize int `yaml:"maxLogSize"`
+
+	// InternetProbe is a URL used to check whether the Internet
+	// is reachable. If it is not, the checks that require
+	// Internet access are skipped. If empty, no probe is done.
+	InternetProbe string `yaml:"internetProbe"`
+
+	// Network is the name of the container network the checks
+	// are attached to. It allows to restrict the network access
+	// of the checks. If empty, the default network of the
+	// container runtime is used.
+	Network string `yaml:"network"`
+
+	// Overrides contains command overrides indexed by checktype
+	// name. They are intended for checktype development and
+	// debugging.
+	Overrides map[string]CommandOverride `yaml:"overrides"`
+
+	// ImageTemplate rewrites the image references of the
+	// checktypes when the checktype catalogs are loaded.
+	ImageTemplate *ImageTemplate `yaml:"imageTemplate"`
+
+	// PartialCatalogs allows to run the scan when some of the
+	// checktype catalogs cannot be retrieved. The scan fails if
+	// none of them can be retrieved.
+	PartialCatalogs bool `yaml:"partialCatalogs"`
+
+	// ValidateCatalogs makes the scan fail if the checktype
+	// catalogs contain checktypes that cannot be run. For
+	// instance, checktypes without assets or image. If false,
+	// these checktypes are only logged.
+	ValidateCatalogs bool `yaml:"validateCatalogs"`
+
+	// DockerAPIVersion pins the version of the Docker API used
+	// to run the checks. The DOCKER_API_VERSION environment
+	// variable takes precedence over it. If both are empty, the
+	// version is negotiated with the daemon. Given that the
+	// Vulcan agent only honors the environment variable, it is
+	// set for the whole process until the engine is closed, so
+	// engines with different versions must not be used at the
+	// same time.
+	DockerAPIVersion string `yaml:"dockerAPIVersion"`
+
+	// ReportAddr is the address, in the form "host:port", where
+	// Lava listens for the reports of the checks. If the host is
+	// empty, Lava listens on the interface that is reachable from
+	// the checks. If the port is empty or 0, a random port is
+	// used. If empty, both defaults apply.
+	ReportAddr string `yaml:"reportAddr"`
+
+	// ReportHost is the hostname or IP address the checks use to
+	// reach Lava and send their reports. If empty, the host
+	// gateway hostname of the container runtime is used.
+	ReportHost string `yaml:"reportHost"`
+
+	// GatewayProbe is the image of the container used to check
+	// that the checks can reach the Lava host before running the
+	// scan. The image must provide the wget command. If empty, no
+	// probe is done.
+	GatewayProbe string `yaml:"gatewayProbe"`
+
+	// SnapshotFailedChecks makes Lava commit the containers of
+	// the checks that exit with a non-zero exit code into images
+	// labeled as managed by Lava, so they can be inspected after
+	// the scan. The containers are removed anyway.
+	SnapshotFailedChecks bool `yaml:"snapshotFailedChecks"`
+
+	// GracePeriod is the time in seconds a check is given to
+	// exit after being sent a SIGTERM signal because its timeout
+	// expired. The reports sent by the check during this period
+	// are stored. It applies to the checktypes that do not
+	// define their own grace period. If zero, checks are stopped
+	// by the Vulcan agent with a fixed grace period of 5 seconds.
+	GracePeriod int `yaml:"gracePeriod"`
+
+	// Timeout is the timeout in seconds of the checks whose
+	// checktype does not define one. If zero, a timeout of 3
+	// minutes is used.
+	Timeout int `yaml:"timeout"`
+
+	// Checkpoint is the path of the file where the progress of
+	// the scan is stored. It allows to resume an interrupted
+	// scan without running again the checks that already
+	// finished. If empty, no checkpoint is used.
+	Checkpoint string `yaml:"checkpoint"`
+
+	// ReportsDir is the directory where the report of every
+	// check is written as soon as it is received, so the
+	// completed reports are not lost if the scan is
+	// interrupted. If empty, the reports are only kept in
+	// memory.
+	ReportsDir string `yaml:"reportsDir"`
+
+	// Privileges contains the privileges that can be granted to
+	// the checktypes indexed by checktype name. Checktypes that
+	// require privileges that are not allowed are not run.
+	Privileges map[string]Privileges `yaml:"privileges"`
+
+	// Retry is the retry policy of the checks that fail. If nil,
+	// failed checks are not retried.
+	Retry *RetryConfig `yaml:"retry"`
+}
+
+// RetryConfig is the retry policy of the checks that fail. For
+// instance, due to a flaky target. Retries are done with jittered
+// exponential backoff.
+type RetryConfig struct {
+	// MaxRetries is the maximum number of times a failed check
+	// is retried. If zero, failed checks are retried once.
+	MaxRetries int `yaml:"maxRetries"`
+
+	// Budget is the maximum number of retries shared by all the
+	// checks of a scan. When it is exhausted, failed checks are
+	// not retried anymore. If zero, only the per-check limit
+	// applies.
+	Budget int `yaml:"budget"`
+
+	// Statuses are the statuses of the checks that are retried.
+	// Valid values are "FAILED", "TIMEOUT" and "INCONCLUSIVE".
+	// If empty, the checks with status FAILED or TIMEOUT are
+	// retried.
+	Statuses []string `yaml:"statuses"`
+}
+
+// retryableStatuses are the check statuses that can be specified in
+// [RetryConfig.Statuses].
+var retryableStatuses = []string{"FAILED", "TIMEOUT", "INCONCLUSIVE"}
+
+// ImageTemplate is a template used to rewrite the image references
+// of the checktypes. For instance, to pull the images from a
+// different registry.
+type ImageTemplate struct {
+	// Template is a Go [text/template] that generates the new
+	// image reference.
+	Template string `yaml:"template"`
+
+	// Vars are additional variables passed to the template.
+	// Their values can reference environment variables using the
+	// syntax $VAR or ${VAR}.
+	Vars map[string]string `yaml:"vars"`
+}
+
+// Privileges represents the privileges that can be granted to a
+// checktype.
+type Privileges struct {
+	// Privileged allows to run the checktype in a privileged
+	// container. It implies all the capabilities.
+	Privileged bool `yaml:"privileged"`
+
+	// Capabilities are the Linux capabilities that can be added
+	// to the container of the checktype. For instance, "NET_RAW".
+	Capabilities []string `yaml:"capabilities"`
+}
+
+// CommandOverride overrides the command run by the container of a
+// check.
+type CommandOverride struct {
+	// Entrypoint replaces the entrypoint of the checktype image.
+	Entrypoint []string `yaml:"entrypoint"`
+
+	// Args replaces the arguments passed to the entrypoint.
+	Args []string `yaml:"args"`
+
+	// NoTimeout disables the timeout of the checktype, so the
+	// check is not killed while it is being debugged.
+	NoTimeout bool `yaml:"noTimeout"`
+}
+
+// validate validates the agent configuration.
+func (c AgentConfig) validate() error {
+	// Checks must be able to reach the Lava host through a
+	// container network to send their reports. So, they cannot
+	// be isolated from the network nor share the network stack
+	// of the host.
+	switch c.Network {
+	case "none", "host":
+		return fmt.Errorf("%w: %v", ErrInvalidNetwork, c.Network)
+	}
+
+	if c.ImageTemplate != nil && c.ImageTemplate.Template == "" {
+		return fmt.Errorf("%w: empty template", ErrInvalidImageTemplate)
+	}
+
+	if c.ReportAddr != "" {
+		_, port, err := net.SplitHostPort(c.ReportAddr)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidReportAddr, err)
+		}
+		if port != "" {
+			if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+				return fmt.Errorf("%w: invalid port: %v", ErrInvalidReportAddr, port)
+			}
+		}
+	}
+
+	if c.Parallel < 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidParallel, c.Parallel)
+	}
+
+	if c.MaxFindings < 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidMaxFindings, c.MaxFindings)
+	}
+
+	if c.MaxLogSize < 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidMaxLogSize, c.MaxLogSize)
+	}
+
+	if c.GracePeriod < 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidGracePeriod, c.GracePeriod)
+	}
+
+	if c.Timeout < 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidTimeout, c.Timeout)
+	}
+
+	if c.DockerAPIVersion != "" && !dockerAPIVersionRegexp.MatchString(c.DockerAPIVersion) {
+		return fmt.Errorf("%w: %v", ErrInvalidDockerAPIVersion, c.DockerAPIVersion)
+	}
+
+	if c.Retry != nil {
+		if c.Retry.MaxRetries < 0 {
+			return fmt.Errorf("%w: negative max retries", ErrInvalidRetry)
+		}
+		if c.Retry.Budget < 0 {
+			return fmt.Errorf("%w: negative budget", ErrInvalidRetry)
+		}
+		for _, st := range c.Retry.Statuses {
+			if !slices.Contains(retryableStatuses, st) {
+				return fmt.Errorf("%w: invalid status: %v", ErrInvalidRetry, st)
+			}
+		}
+	}
+	return nil
 }
 
 // ReportConfig is the configuration of the report.
@@ -157,6 +561,12 @@ type ReportConfig struct {
 	// finding.
 	Severity Severity `yaml:"severity"`
 
+	// Floor is the severity floor of the report. Findings with a
+	// lower severity are dropped from the report, but they are
+	// still considered to calculate the result of the scan. If
+	// nil, no findings are dropped.
+	Floor *Severity `yaml:"floor"`
+
 	// Format is the output format.
 	Format OutputFormat `yaml:"format"`
 
@@ -167,10 +577,63 @@ type ReportConfig struct {
 	// instance, accepted risks, false positives, etc.
 	Exclusions []Exclusion `yaml:"exclusions"`
 
+	// IgnoreCheckErrors makes the checks that do not finish
+	// successfully not affect the result of the scan. They are
+	// reported anyway.
+	IgnoreCheckErrors bool `yaml:"ignoreCheckErrors"`
+
+	// Dedup makes the findings that are reported by several
+	// checks against the same target and resource be reported
+	// only once. If false, every finding is reported.
+	Dedup bool `yaml:"dedup"`
+
+	// Baseline is the path of a baseline file. The findings in
+	// the baseline are reported, but they do not affect the
+	// result of the scan. If empty, no baseline is used.
+	Baseline string `yaml:"baseline"`
+
+	// BaselineOutput is the path of the file where the baseline
+	// of the scan is written. It contains all the findings that
+	// are not excluded. If empty, the baseline is not written.
+	BaselineOutput string `yaml:"baselineOutput"`
+
 	// Metrics is the file where the metrics will be written.
 	// If Metrics is an empty string or not specified in the yaml file, then
 	// the metrics report is not saved.
 	Metrics string `yaml:"metrics"`
+
+	// Webhook is the configuration of the webhook that receives
+	// the findings at the end of the scan. If nil, the findings
+	// are not sent.
+	Webhook *WebhookConfig `yaml:"webhook"`
+}
+
+// WebhookConfig is the configuration of a webhook.
+type WebhookConfig struct {
+	// URL is the URL of the webhook.
+	URL string `yaml:"url"`
+
+	// Headers are the HTTP headers sent with the request. For
+	// instance, an authorization header. The values can
+	// reference environment variables using the syntax $VAR or
+	// ${VAR}.
+	Headers map[string]string `yaml:"headers" json:"-"`
+
+	// Retries is the maximum number of times a failed delivery
+	// is retried. If zero, the delivery is retried 3 times.
+	Retries int `yaml:"retries"`
+}
+
+// validate validates the webhook configuration.
+func (c WebhookConfig) validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidWebhookURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidWebhookURL, c.URL)
+	}
+	return nil
 }
 
 // Target represents the target of a scan.
@@ -184,22 +647,290 @@ type Target struct {
 
 	// Options is a list of specific options for the target.
 	Options map[string]any `yaml:"options"`
+
+	// Labels is a set of arbitrary key-value pairs attached to
+	// the target. For instance, the business unit, environment or
+	// owner of the target. Labels are only metadata and do not
+	// affect the generated checks.
+	Labels map[string]string `yaml:"labels"`
+
+	// Credentials are the credentials required to scan the
+	// target.
+	Credentials *Credentials `yaml:"credentials"`
+
+	// Include is a list of checktype names or glob patterns. If
+	// not empty, only the matching checktypes are run against
+	// the target.
+	Include []string `yaml:"include"`
+
+	// Exclude is a list of checktype names or glob patterns. The
+	// matching checktypes are not run against the target, even
+	// if they are included.
+	Exclude []string `yaml:"exclude"`
+}
+
+// Selects reports whether the provided checktype must be run against
+// the target according to its include and exclude lists. Exclude
+// takes precedence over include.
+func (t Target) Selects(checktype string) bool {
+	if matchAny(t.Exclude, checktype) {
+		return false
+	}
+	return len(t.Include) == 0 || matchAny(t.Include, checktype)
+}
+
+// matchAny reports whether name matches any of the provided glob
+// patterns. Invalid patterns do not match.
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetDefaults contains the default values shared by all the
+// targets.
+type TargetDefaults struct {
+	// Options are merged into the options of every target. The
+	// options of the target take precedence.
+	Options map[string]any `yaml:"options"`
+
+	// DetectAssetType enables the detection of the asset type of
+	// the targets that do not specify it. See
+	// [DetectAssetTypes].
+	DetectAssetType bool `yaml:"detectAssetType"`
+}
+
+// Apply returns a copy of the provided targets with the defaults
+// applied. The options of the targets are merged on top of the
+// default options, so they take precedence. The returned targets do
+// not share their options with the defaults.
+func (d TargetDefaults) Apply(targets []Target) []Target {
+	var ts []Target
+	for _, t := range targets {
+		if len(d.Options) > 0 {
+			opts := maps.Clone(d.Options)
+			maps.Copy(opts, t.Options)
+			t.Options = opts
+		}
+		ts = append(ts, t)
+	}
+	return ts
+}
+
+// DetectAssetTypes returns a copy of the provided targets where the
+// targets without asset type are replaced by one target per detected
+// asset type. For instance, "https://example.com" may be expanded
+// into a WebAddress and a Hostname. The detected targets keep the
+// options and labels of the original target. If no asset type can be
+// detected for a target, an error wrapping [ErrNoTargetAssetType] is
+// returned.
+func DetectAssetTypes(targets []Target) ([]Target, error) {
+	var ts []Target
+	for _, t := range targets {
+		if t.AssetType != "" {
+			ts = append(ts, t)
+			continue
+		}
+
+		assets, err := assettypes.Detect(t.Identifier)
+		if err != nil {
+			return nil, fmt.Errorf("detect asset type of %q: %w", t.Identifier, err)
+		}
+		if len(assets) == 0 {
+			return nil, fmt.Errorf("%w: %v", ErrNoTargetAssetType, t.Identifier)
+		}
+
+		for _, a := range assets {
+			dt := t
+			dt.Identifier = a.Identifier
+			dt.AssetType = a.AssetType
+			dt.Options = maps.Clone(t.Options)
+			dt.Labels = maps.Clone(t.Labels)
+			ts = append(ts, dt)
+		}
+	}
+	return ts, nil
+}
+
+// ValidateTargets reports whether the provided targets are valid. It
+// allows to validate the targets that are not part of the
+// configuration file, like the ones returned by the target sources.
+// If detectAssetType is true, the asset type of the targets can be
+// omitted.
+func ValidateTargets(targets []Target, detectAssetType bool) error {
+	for _, t := range targets {
+		if err := t.validate(detectAssetType); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // validate reports whether the target is a valid configuration value.
-func (t Target) validate() error {
+// If detectAssetType is true, the asset type of the target can be
+// omitted.
+func (t Target) validate(detectAssetType bool) error {
 	if t.Identifier == "" {
 		return ErrNoTargetIdentifier
 	}
 	if t.AssetType == "" {
-		return ErrNoTargetAssetType
-	}
-	if !t.AssetType.IsValid() && !assettypes.IsValid(t.AssetType) {
+		if !detectAssetType {
+			return ErrNoTargetAssetType
+		}
+	} else if !t.AssetType.IsValid() && !assettypes.IsValid(t.AssetType) {
 		return fmt.Errorf("%w: %v", ErrInvalidAssetType, t.AssetType)
 	}
+	if t.Credentials != nil {
+		if err := t.Credentials.validate(); err != nil {
+			return err
+		}
+	}
+	for _, p := range append(append([]string{}, t.Include...), t.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidChecktypePattern, p)
+		}
+	}
+	return nil
+}
+
+// Credentials are the credentials used by the checks to authenticate
+// against a target. Either a token or a username and a password must
+// be provided. The values can reference environment variables using
+// the syntax $VAR or ${VAR}, which are expanded when the checks are
+// run. Credentials are never encoded as JSON, so they are not
+// included in metrics or reports.
+type Credentials struct {
+	// Username is the username used for basic authentication.
+	Username string `yaml:"username" json:"-"`
+
+	// Password is the password used for basic authentication.
+	Password string `yaml:"password" json:"-"`
+
+	// Token is the token used for bearer authentication.
+	Token string `yaml:"token" json:"-"`
+
+	// SSHKey is the private key used for SSH authentication. It
+	// is usually used to clone private Git repositories.
+	SSHKey string `yaml:"sshKey" json:"-"`
+}
+
+// validate reports whether the credentials are a valid configuration
+// value. Exactly one authentication method must be specified.
+func (c Credentials) validate() error {
+	n := 0
+	if c.Username != "" || c.Password != "" {
+		n++
+	}
+	if c.Token != "" {
+		n++
+	}
+	if c.SSHKey != "" {
+		n++
+	}
+	if n != 1 {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// TargetSourceType is the type of a target source.
+type TargetSourceType string
+
+// Target source types.
+const (
+	TargetSourceTerraform TargetSourceType = "terraform"
+	TargetSourceCSV       TargetSourceType = "csv"
+	TargetSourceList      TargetSourceType = "list"
+)
+
+// TargetSource represents an external source of targets. For
+// instance, a Terraform state file or a list of targets.
+type TargetSource struct {
+	// Type is the type of the source.
+	Type TargetSourceType `yaml:"type"`
+
+	// URL points to the source data. If the URL omits the
+	// scheme, it is considered a file path.
+	URL string `yaml:"url"`
+
+	// Mappings is the list of rules used to convert the
+	// resources of the source into targets. If empty, the
+	// default mappings of the source type are used. It only
+	// applies to Terraform sources.
+	Mappings []ResourceMapping `yaml:"mappings"`
+
+	// Columns defines the columns of a CSV source that contain
+	// the target fields. It only applies to CSV sources.
+	Columns CSVColumns `yaml:"columns"`
+}
+
+// CSVColumns defines the columns of a CSV target source that contain
+// the fields of the targets. The rest of the columns are converted
+// into target labels.
+type CSVColumns struct {
+	// Identifier is the name of the column that contains the
+	// target identifier. If empty, "identifier" is used.
+	Identifier string `yaml:"identifier"`
+
+	// AssetType is the name of the column that contains the
+	// asset type of the target. If empty, "type" is used.
+	AssetType string `yaml:"type"`
+}
+
+// validate reports whether the target source is a valid
+// configuration value.
+func (ts TargetSource) validate() error {
+	switch ts.Type {
+	case TargetSourceTerraform:
+		if ts.Columns != (CSVColumns{}) {
+			return fmt.Errorf("%w: columns are only supported by CSV sources", ErrInvalidTargetSource)
+		}
+	case TargetSourceCSV:
+		if len(ts.Mappings) > 0 {
+			return fmt.Errorf("%w: mappings are only supported by Terraform sources", ErrInvalidTargetSource)
+		}
+	case TargetSourceList:
+		if len(ts.Mappings) > 0 {
+			return fmt.Errorf("%w: mappings are only supported by Terraform sources", ErrInvalidTargetSource)
+		}
+		if ts.Columns != (CSVColumns{}) {
+			return fmt.Errorf("%w: columns are only supported by CSV sources", ErrInvalidTargetSource)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type: %v", ErrInvalidTargetSource, ts.Type)
+	}
+	if ts.URL == "" {
+		return fmt.Errorf("%w: no URL", ErrInvalidTargetSource)
+	}
+	for _, m := range ts.Mappings {
+		if m.Resource == "" || m.Attribute == "" {
+			return fmt.Errorf("%w: incomplete mapping", ErrInvalidTargetSource)
+		}
+		if !m.AssetType.IsValid() && !assettypes.IsValid(m.AssetType) {
+			return fmt.Errorf("%w: %v", ErrInvalidAssetType, m.AssetType)
+		}
+	}
 	return nil
 }
 
+// ResourceMapping defines how to convert a resource of a target
+// source into a target.
+type ResourceMapping struct {
+	// Resource is the type of the resource. For instance,
+	// "aws_eip".
+	Resource string `yaml:"resource"`
+
+	// Attribute is the attribute of the resource used as target
+	// identifier. For instance, "public_ip".
+	Attribute string `yaml:"attribute"`
+
+	// AssetType is the asset type of the generated target.
+	AssetType types.AssetType `yaml:"type"`
+}
+
 // RegistryAuth contains the credentials for a container registry.
 type RegistryAuth struct {
 	// Server is the URL of the registry.
@@ -256,6 +987,26 @@ func (s Severity) String() string {
 	return ""
 }
 
+// ScoreToSeverity converts a CVSS score into a [Severity].
+// To calculate the severity we are using the [severity ratings]
+// provided by the NVD.
+//
+// [severity ratings]: https://nvd.nist.gov/vuln-metrics/cvss
+func ScoreToSeverity(score float32) Severity {
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score >= 0.1:
+		return SeverityLow
+	default:
+		return SeverityInfo
+	}
+}
+
 // MarshalText encode a [Severity] as a text.
 func (s Severity) MarshalText() (text []byte, err error) {
 	if !s.IsValid() {
@@ -283,11 +1034,21 @@ type OutputFormat int
 const (
 	OutputFormatHuman OutputFormat = iota
 	OutputFormatJSON
+	OutputFormatJSONL
+	OutputFormatJSONLReports
+	OutputFormatSARIF
+	OutputFormatJUnit
+	OutputFormatHTML
 )
 
 var outputFormatNames = map[string]OutputFormat{
-	"human": OutputFormatHuman,
-	"json":  OutputFormatJSON,
+	"human":         OutputFormatHuman,
+	"json":          OutputFormatJSON,
+	"jsonl":         OutputFormatJSONL,
+	"jsonl-reports": OutputFormatJSONLReports,
+	"sarif":         OutputFormatSARIF,
+	"junit":         OutputFormatJUnit,
+	"html":          OutputFormatHTML,
 }
 
 // parseOutputFormat converts a string into an [OutputFormat] value.
@@ -329,6 +1090,32 @@ type Exclusion struct {
 	// the vulnerability.
 	Summary string `yaml:"summary"`
 
+	// CVE is the CVE identifier of the vulnerability. For
+	// instance, "CVE-2023-44487". It is compared
+	// case-insensitively with the summary, the labels and the
+	// references of the vulnerability.
+	CVE string `yaml:"cve"`
+
+	// Expires is the time from which the exclusion is not applied
+	// anymore. A date without time, like "2024-12-31", refers to
+	// the beginning of that day in UTC. If zero, the exclusion
+	// does not expire.
+	Expires time.Time `yaml:"expires"`
+
 	// Description describes the exclusion.
 	Description string `yaml:"description"`
 }
+
+// validate validates the exclusion rule.
+func (excl Exclusion) validate() error {
+	if excl.CVE != "" && !cveRegexp.MatchString(excl.CVE) {
+		return fmt.Errorf("%w: %q", ErrInvalidCVE, excl.CVE)
+	}
+	return nil
+}
+
+// Expired reports whether the exclusion has expired at the provided
+// time.
+func (excl Exclusion) Expired(t time.Time) bool {
+	return !excl.Expires.IsZero() && !t.Before(excl.Expires)
+}
@@ -8,10 +8,13 @@ import (
 	"log/slog"
 	"regexp"
 	"testing"
+	"time"
 
 	agentconfig "github.com/adevinta/vulcan-agent/config"
 	types "github.com/adevinta/vulcan-types"
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/assettypes"
 )
 
 func TestParse(t *testing.T) {
@@ -27,8 +30,8 @@ func TestParse(t *testing.T) {
 			file: "testdata/valid.yaml",
 			want: Config{
 				LavaVersion: "v1.0.0",
-				ChecktypeURLs: []string{
-					"checktypes.json",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
 				},
 				Targets: []Target{
 					{
@@ -79,8 +82,8 @@ func TestParse(t *testing.T) {
 			file: "testdata/critical_severity.yaml",
 			want: Config{
 				LavaVersion: "v1.0.0",
-				ChecktypeURLs: []string{
-					"checktypes.json",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
 				},
 				ReportConfig: ReportConfig{
 					Severity: SeverityCritical,
@@ -93,6 +96,26 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "report severity floor",
+			file: "testdata/report_floor.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				ReportConfig: ReportConfig{
+					Severity: SeverityLow,
+					Floor:    ptr(SeverityMedium),
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+			},
+		},
 		{
 			name:    "invalid severity",
 			file:    "testdata/invalid_severity.yaml",
@@ -104,8 +127,8 @@ func TestParse(t *testing.T) {
 			file: "testdata/never_pull_policy.yaml",
 			want: Config{
 				LavaVersion: "v1.0.0",
-				ChecktypeURLs: []string{
-					"checktypes.json",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
 				},
 				AgentConfig: AgentConfig{
 					PullPolicy: agentconfig.PullPolicyNever,
@@ -135,8 +158,8 @@ func TestParse(t *testing.T) {
 			file: "testdata/json_output_format.yaml",
 			want: Config{
 				LavaVersion: "v1.0.0",
-				ChecktypeURLs: []string{
-					"checktypes.json",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
 				},
 				Targets: []Target{
 					{
@@ -149,6 +172,25 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "JSON Lines reports output format",
+			file: "testdata/jsonl_reports_output_format.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					Format: OutputFormatJSONLReports,
+				},
+			},
+		},
 		{
 			name:    "invalid output format",
 			file:    "testdata/invalid_output_format.yaml",
@@ -160,8 +202,8 @@ func TestParse(t *testing.T) {
 			file: "testdata/debug_log_level.yaml",
 			want: Config{
 				LavaVersion: "v1.0.0",
-				ChecktypeURLs: []string{
-					"checktypes.json",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
 				},
 				Targets: []Target{
 					{
@@ -178,6 +220,459 @@ func TestParse(t *testing.T) {
 			want:          Config{},
 			wantErrRegexp: regexp.MustCompile(`level string ".*": unknown name`),
 		},
+		{
+			name: "target labels",
+			file: "testdata/target_labels.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+						Labels: map[string]string{
+							"env":   "prod",
+							"owner": "team-a",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "target credentials",
+			file: "testdata/target_credentials.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "https://example.com",
+						AssetType:  types.WebAddress,
+						Credentials: &Credentials{
+							Token: "${EXAMPLE_TOKEN}",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "target ssh credentials",
+			file: "testdata/target_ssh_credentials.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "git@github.com:example/private.git",
+						AssetType:  types.GitRepository,
+						Credentials: &Credentials{
+							SSHKey: "${GIT_SSH_KEY}",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "target sources",
+			file: "testdata/target_sources.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				TargetSources: []TargetSource{
+					{
+						Type: TargetSourceTerraform,
+						URL:  "terraform.tfstate",
+						Mappings: []ResourceMapping{
+							{
+								Resource:  "aws_eip",
+								Attribute: "public_ip",
+								AssetType: types.IP,
+							},
+						},
+					},
+					{
+						Type: TargetSourceCSV,
+						URL:  "inventory.csv",
+						Columns: CSVColumns{
+							Identifier: "host",
+							AssetType:  "kind",
+						},
+					},
+					{
+						Type: TargetSourceList,
+						URL:  "targets.txt",
+					},
+				},
+			},
+		},
+		{
+			name: "target defaults",
+			file: "testdata/target_defaults.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "https://example.com",
+						AssetType:  types.WebAddress,
+					},
+				},
+				TargetDefaults: TargetDefaults{
+					Options: map[string]any{
+						"depth": 2,
+					},
+				},
+			},
+		},
+		{
+			name: "detect asset type",
+			file: "testdata/detect_asset_type.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				TargetDefaults: TargetDefaults{
+					DetectAssetType: true,
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+					},
+				},
+			},
+		},
+		{
+			name: "checktype priorities",
+			file: "testdata/checktype_priorities.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "https://example.com/checktypes.json"},
+					{URL: "internal.json", Priority: 10, Auth: true},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+			},
+		},
+		{
+			name:    "unknown checktype URL field",
+			file:    "testdata/invalid_checktype_url.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidChecktypeURL,
+		},
+		{
+			name:    "empty checktype URL",
+			file:    "testdata/empty_checktype_url.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidChecktypeURL,
+		},
+		{
+			name:    "invalid target source",
+			file:    "testdata/invalid_target_source.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidTargetSource,
+		},
+		{
+			name:    "invalid list target source",
+			file:    "testdata/invalid_list_target_source.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidTargetSource,
+		},
+		{
+			name:    "invalid target credentials",
+			file:    "testdata/invalid_target_credentials.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "invalid target ssh credentials",
+			file:    "testdata/invalid_target_ssh_credentials.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "invalid target checktype pattern",
+			file:    "testdata/invalid_target_checktype_pattern.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidChecktypePattern,
+		},
+		{
+			name: "agent network",
+			file: "testdata/agent_network.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Network: "lava-checks",
+				},
+			},
+		},
+		{
+			name: "agent overrides",
+			file: "testdata/agent_overrides.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Overrides: map[string]CommandOverride{
+						"vulcan-drupal": {
+							Entrypoint: []string{"/bin/sh"},
+							Args:       []string{"-c", "env"},
+						},
+						"vulcan-nessus": {
+							NoTimeout: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "agent image template",
+			file: "testdata/agent_image_template.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					ImageTemplate: &ImageTemplate{
+						Template: "{{.Registry}}/vulcan/{{.Name}}:{{.Tag}}",
+						Vars: map[string]string{
+							"Registry": "${LAVA_REGISTRY}",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid agent image template",
+			file:    "testdata/invalid_agent_image_template.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidImageTemplate,
+		},
+		{
+			name:    "invalid agent network",
+			file:    "testdata/invalid_agent_network.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidNetwork,
+		},
+		{
+			name: "agent retry",
+			file: "testdata/agent_retry.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Retry: &RetryConfig{
+						MaxRetries: 2,
+						Budget:     10,
+						Statuses:   []string{"FAILED", "TIMEOUT", "INCONCLUSIVE"},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid agent retry",
+			file:    "testdata/invalid_agent_retry.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidRetry,
+		},
+		{
+			name:    "invalid agent retry status",
+			file:    "testdata/invalid_agent_retry_status.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidRetry,
+		},
+		{
+			name: "agent Docker API version",
+			file: "testdata/agent_docker_api_version.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					DockerAPIVersion: "1.41",
+				},
+			},
+		},
+		{
+			name: "agent privileges",
+			file: "testdata/agent_privileges.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Privileges: map[string]Privileges{
+						"vulcan-nmap": {
+							Capabilities: []string{"NET_RAW"},
+						},
+						"vulcan-special": {
+							Privileged: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid agent Docker API version",
+			file:    "testdata/invalid_agent_docker_api_version.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidDockerAPIVersion,
+		},
+		{
+			name: "agent report address",
+			file: "testdata/agent_report_addr.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					ReportAddr: ":8080",
+					ReportHost: "lava.internal",
+				},
+			},
+		},
+		{
+			name:    "invalid agent report address",
+			file:    "testdata/invalid_agent_report_addr.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidReportAddr,
+		},
+		{
+			name:    "invalid agent parallel",
+			file:    "testdata/invalid_agent_parallel.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidParallel,
+		},
+		{
+			name:    "invalid agent max findings",
+			file:    "testdata/invalid_agent_max_findings.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidMaxFindings,
+		},
+		{
+			name:    "invalid agent max log size",
+			file:    "testdata/invalid_agent_max_log_size.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidMaxLogSize,
+		},
+		{
+			name:    "invalid agent grace period",
+			file:    "testdata/invalid_agent_grace_period.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidGracePeriod,
+		},
+		{
+			name:    "invalid agent timeout",
+			file:    "testdata/invalid_agent_timeout.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidTimeout,
+		},
+		{
+			name: "report exclusions",
+			file: "testdata/report_exclusions.yaml",
+			want: Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{
+					{URL: "checktypes.json"},
+				},
+				ReportConfig: ReportConfig{
+					Exclusions: []Exclusion{
+						{
+							Target:      `^example\.com$`,
+							CVE:         "CVE-2023-44487",
+							Expires:     time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+							Description: "Accepted until the end of the year.",
+						},
+					},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid exclusion CVE",
+			file:    "testdata/invalid_exclusion_cve.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidCVE,
+		},
+		{
+			name:    "invalid webhook URL",
+			file:    "testdata/invalid_webhook_url.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidWebhookURL,
+		},
 	}
 
 	for _, tt := range tests {
@@ -208,6 +703,236 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestTargetDefaults_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults TargetDefaults
+		targets  []Target
+		want     []Target
+	}{
+		{
+			name: "merge options",
+			defaults: TargetDefaults{
+				Options: map[string]any{
+					"option1": "defaults",
+					"option2": "defaults",
+				},
+			},
+			targets: []Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Options: map[string]any{
+						"option2": "target",
+					},
+				},
+				{
+					Identifier: "192.0.2.1",
+					AssetType:  types.IP,
+				},
+			},
+			want: []Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Options: map[string]any{
+						"option1": "defaults",
+						"option2": "target",
+					},
+				},
+				{
+					Identifier: "192.0.2.1",
+					AssetType:  types.IP,
+					Options: map[string]any{
+						"option1": "defaults",
+						"option2": "defaults",
+					},
+				},
+			},
+		},
+		{
+			name:     "no defaults",
+			defaults: TargetDefaults{},
+			targets: []Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Options: map[string]any{
+						"option1": "target",
+					},
+				},
+			},
+			want: []Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Options: map[string]any{
+						"option1": "target",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.defaults.Apply(tt.targets)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+
+			// The defaults must not be modified.
+			for _, t2 := range got {
+				t2.Options["lava-test"] = true
+			}
+			if _, ok := tt.defaults.Options["lava-test"]; ok {
+				t.Errorf("defaults were modified: %v", tt.defaults.Options)
+			}
+		})
+	}
+}
+
+func TestDetectAssetTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		targets    []Target
+		want       []Target
+		wantNilErr bool
+	}{
+		{
+			name: "detect asset types",
+			targets: []Target{
+				{
+					Identifier: "192.0.2.1",
+					Options: map[string]any{
+						"option": "value",
+					},
+					Labels: map[string]string{
+						"environment": "production",
+					},
+				},
+				{
+					Identifier: ".",
+				},
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+			},
+			want: []Target{
+				{
+					Identifier: "192.0.2.1",
+					AssetType:  types.IP,
+					Options: map[string]any{
+						"option": "value",
+					},
+					Labels: map[string]string{
+						"environment": "production",
+					},
+				},
+				{
+					Identifier: ".",
+					AssetType:  assettypes.Path,
+				},
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name:       "no targets",
+			targets:    nil,
+			want:       nil,
+			wantNilErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectAssetTypes(tt.targets)
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestValidateTargets(t *testing.T) {
+	tests := []struct {
+		name            string
+		targets         []Target
+		detectAssetType bool
+		wantErr         error
+	}{
+		{
+			name: "valid targets",
+			targets: []Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "no identifier",
+			targets: []Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+				{
+					AssetType: types.DomainName,
+				},
+			},
+			wantErr: ErrNoTargetIdentifier,
+		},
+		{
+			name: "invalid asset type",
+			targets: []Target{
+				{
+					Identifier: "example.com",
+					AssetType:  "InvalidAssetType",
+				},
+			},
+			wantErr: ErrInvalidAssetType,
+		},
+		{
+			name: "no asset type",
+			targets: []Target{
+				{
+					Identifier: "example.com",
+				},
+			},
+			wantErr: ErrNoTargetAssetType,
+		},
+		{
+			name: "detect asset type",
+			targets: []Target{
+				{
+					Identifier: "example.com",
+				},
+			},
+			detectAssetType: true,
+			wantErr:         nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTargets(tt.targets, tt.detectAssetType)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestConfig_IsCompatible(t *testing.T) {
 	tests := []struct {
 		name string
@@ -257,6 +982,50 @@ func TestConfig_IsCompatible(t *testing.T) {
 	}
 }
 
+func TestExclusion_Expired(t *testing.T) {
+	expires := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		excl Exclusion
+		t    time.Time
+		want bool
+	}{
+		{
+			name: "no expiration",
+			excl: Exclusion{},
+			t:    expires,
+			want: false,
+		},
+		{
+			name: "before expiration",
+			excl: Exclusion{Expires: expires},
+			t:    expires.Add(-time.Second),
+			want: false,
+		},
+		{
+			name: "at expiration",
+			excl: Exclusion{Expires: expires},
+			t:    expires,
+			want: true,
+		},
+		{
+			name: "after expiration",
+			excl: Exclusion{Expires: expires},
+			t:    expires.Add(time.Hour),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.excl.Expired(tt.t); got != tt.want {
+				t.Errorf("unexpected result: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSeverity_MarshalText(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -314,3 +1083,50 @@ func TestSeverity_MarshalText(t *testing.T) {
 		})
 	}
 }
+
+func TestScoreToSeverity(t *testing.T) {
+	tests := []struct {
+		name  string
+		score float32
+		want  Severity
+	}{
+		{
+			name:  "critical",
+			score: 9,
+			want:  SeverityCritical,
+		},
+		{
+			name:  "high",
+			score: 7,
+			want:  SeverityHigh,
+		},
+		{
+			name:  "medium",
+			score: 4,
+			want:  SeverityMedium,
+		},
+		{
+			name:  "low",
+			score: 0.1,
+			want:  SeverityLow,
+		},
+		{
+			name:  "info",
+			score: 0,
+			want:  SeverityInfo,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScoreToSeverity(tt.score)
+			if got != tt.want {
+				t.Errorf("unexpected severity: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ptr returns a pointer to the provided value.
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 Adevinta
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoConfigFiles is returned by [ParseFiles] when no configuration
+// files are provided.
+var ErrNoConfigFiles = errors.New("no config files")
+
+// ParseFiles returns the Lava configuration that results from
+// merging the provided configuration files in order. The first file
+// is the base configuration and the following ones are overlays.
+// Every overlay is deep-merged into the result of the previous
+// merges with the following precedence rules:
+//
+//   - Maps are merged recursively. Keys that only exist in one of
+//     them are kept.
+//   - Scalars and arrays of the overlay replace the ones of the
+//     previous configuration.
+//
+// Every file must be a valid configuration document on its own, but
+// only the merged configuration is validated. If it is not valid, the
+// returned error identifies the file that introduced the invalid
+// value.
+func ParseFiles(paths ...string) (Config, error) {
+	if len(paths) == 0 {
+		return Config{}, ErrNoConfigFiles
+	}
+
+	var docs []map[string]any
+	for _, path := range paths {
+		doc, err := readConfigDoc(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("%v: %w", path, err)
+		}
+		docs = append(docs, doc)
+	}
+
+	cfg, err := parseDocs(docs)
+	if err == nil {
+		return cfg, nil
+	}
+
+	// Find the file that introduced the error. That is, the first
+	// file since which all the merged configurations fail with
+	// the same error.
+	culprit := len(docs) - 1
+	for i := len(docs) - 2; i >= 0; i-- {
+		if _, ierr := parseDocs(docs[:i+1]); ierr == nil || ierr.Error() != err.Error() {
+			break
+		}
+		culprit = i
+	}
+	return Config{}, fmt.Errorf("%v: %w", paths[culprit], err)
+}
+
+// readConfigDoc reads the specified configuration file as a generic
+// YAML document. It also checks that the document can be decoded
+// into a [Config]. Empty files and files that only contain comments
+// are returned as an empty document.
+func readConfigDoc(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		if errors.Is(err, io.EOF) {
+			return map[string]any{}, nil
+		}
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	return doc, nil
+}
+
+// parseDocs merges the provided YAML documents in order and parses
+// the result.
+func parseDocs(docs []map[string]any) (Config, error) {
+	var merged map[string]any
+	for _, doc := range docs {
+		merged = mergeMaps(merged, doc)
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return Config{}, fmt.Errorf("encode merged config: %w", err)
+	}
+	return Parse(bytes.NewReader(data))
+}
+
+// mergeMaps returns the result of deep-merging src into dst. Nested
+// maps are merged recursively, and any other value of src replaces
+// the corresponding value of dst. The provided maps are not
+// modified.
+func mergeMaps(dst, src map[string]any) map[string]any {
+	merged := maps.Clone(dst)
+	if merged == nil {
+		merged = make(map[string]any)
+	}
+	for k, sv := range src {
+		sm, sok := sv.(map[string]any)
+		dm, dok := merged[k].(map[string]any)
+		if sok && dok {
+			merged[k] = mergeMaps(dm, sm)
+			continue
+		}
+		merged[k] = sv
+	}
+	return merged
+}
@@ -0,0 +1,231 @@
+// Copyright 2023 Adevinta
+
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       []string
+		want        Config
+		wantErr     error
+		wantErrFile string
+	}{
+		{
+			name:  "single file",
+			files: []string{"testdata/overlays/base.yaml"},
+			want: Config{
+				LavaVersion:   "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{{URL: "checktypes.json"}},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Parallel: 2,
+					Vars: map[string]string{
+						"DEBUG":   "false",
+						"API_URL": "https://dev.example.com",
+					},
+				},
+				ReportConfig: ReportConfig{
+					Severity: SeverityLow,
+				},
+			},
+		},
+		{
+			name: "overlay",
+			files: []string{
+				"testdata/overlays/base.yaml",
+				"testdata/overlays/prod.yaml",
+			},
+			want: Config{
+				LavaVersion:   "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{{URL: "checktypes.json"}},
+				Targets: []Target{
+					{
+						Identifier: "example.org",
+						AssetType:  types.DomainName,
+					},
+					{
+						Identifier: "example.net",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Parallel: 2,
+					Vars: map[string]string{
+						"DEBUG":   "false",
+						"API_URL": "https://prod.example.com",
+					},
+				},
+				ReportConfig: ReportConfig{
+					Severity: SeverityHigh,
+				},
+			},
+		},
+		{
+			name: "overlay completes base",
+			files: []string{
+				"testdata/overlays/no_targets.yaml",
+				"testdata/overlays/prod.yaml",
+			},
+			want: Config{
+				LavaVersion:   "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{{URL: "checktypes.json"}},
+				Targets: []Target{
+					{
+						Identifier: "example.org",
+						AssetType:  types.DomainName,
+					},
+					{
+						Identifier: "example.net",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Vars: map[string]string{
+						"API_URL": "https://prod.example.com",
+					},
+				},
+				ReportConfig: ReportConfig{
+					Severity: SeverityHigh,
+				},
+			},
+		},
+		{
+			name: "empty overlay",
+			files: []string{
+				"testdata/overlays/base.yaml",
+				"testdata/overlays/empty.yaml",
+			},
+			want: Config{
+				LavaVersion:   "v1.0.0",
+				ChecktypeURLs: []ChecktypeURL{{URL: "checktypes.json"}},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Parallel: 2,
+					Vars: map[string]string{
+						"DEBUG":   "false",
+						"API_URL": "https://dev.example.com",
+					},
+				},
+				ReportConfig: ReportConfig{
+					Severity: SeverityLow,
+				},
+			},
+		},
+		{
+			name: "invalid value in overlay",
+			files: []string{
+				"testdata/overlays/base.yaml",
+				"testdata/overlays/invalid_report_addr.yaml",
+				"testdata/overlays/prod.yaml",
+			},
+			wantErr:     ErrInvalidReportAddr,
+			wantErrFile: "testdata/overlays/invalid_report_addr.yaml",
+		},
+		{
+			name: "invalid base",
+			files: []string{
+				"testdata/overlays/no_targets.yaml",
+			},
+			wantErr:     ErrNoTargets,
+			wantErrFile: "testdata/overlays/no_targets.yaml",
+		},
+		{
+			name: "unknown field in overlay",
+			files: []string{
+				"testdata/overlays/base.yaml",
+				"testdata/overlays/unknown_field.yaml",
+			},
+			wantErrFile: "testdata/overlays/unknown_field.yaml",
+		},
+		{
+			name:    "no files",
+			files:   nil,
+			wantErr: ErrNoConfigFiles,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFiles(tt.files...)
+
+			if tt.wantErr == nil && tt.wantErrFile == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if diff := cmp.Diff(tt.want, got); diff != "" {
+					t.Errorf("configs mismatch (-want +got):\n%v", diff)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("unexpected nil error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+			if !strings.HasPrefix(err.Error(), tt.wantErrFile) {
+				t.Errorf("error does not identify the file: got: %v, want file: %v", err, tt.wantErrFile)
+			}
+		})
+	}
+}
+
+func TestMergeMaps(t *testing.T) {
+	dst := map[string]any{
+		"scalar": "dst",
+		"array":  []any{"a", "b"},
+		"map": map[string]any{
+			"a": 1,
+			"b": 2,
+		},
+		"dst": true,
+	}
+	src := map[string]any{
+		"scalar": "src",
+		"array":  []any{"c"},
+		"map": map[string]any{
+			"b": 3,
+			"c": 4,
+		},
+		"src": true,
+	}
+	want := map[string]any{
+		"scalar": "src",
+		"array":  []any{"c"},
+		"map": map[string]any{
+			"a": 1,
+			"b": 3,
+			"c": 4,
+		},
+		"dst": true,
+		"src": true,
+	}
+
+	got := mergeMaps(dst, src)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("merged maps mismatch (-want +got):\n%v", diff)
+	}
+	if diff := cmp.Diff(2, dst["map"].(map[string]any)["b"]); diff != "" {
+		t.Errorf("dst was modified (-want +got):\n%v", diff)
+	}
+}
@@ -6,27 +6,67 @@ package containers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/distribution/reference"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/context/docker"
+	"github.com/docker/cli/cli/context/store"
 	"github.com/docker/cli/cli/flags"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/go-connections/tlsconfig"
 )
 
-// ErrInvalidRuntime means that the provided container runtime is not
-// supported.
-var ErrInvalidRuntime = errors.New("invalid runtime")
+var (
+	// ErrInvalidRuntime means that the provided container runtime
+	// is not supported.
+	ErrInvalidRuntime = errors.New("invalid runtime")
+
+	// ErrUnsupportedAPIVersion means that the requested Docker
+	// API version is not supported by the daemon.
+	ErrUnsupportedAPIVersion = errors.New("unsupported Docker API version")
+
+	// ErrHostGatewayUnreachable means that the host is not
+	// reachable from the containers through the host gateway.
+	ErrHostGatewayUnreachable = errors.New("host gateway unreachable")
+
+	// ErrImageUnauthorized means that the registry rejected the
+	// credentials used to pull an image or that credentials are
+	// required.
+	ErrImageUnauthorized = errors.New("image pull unauthorized")
+
+	// ErrImageNotFound means that the image to pull does not
+	// exist in the registry.
+	ErrImageNotFound = errors.New("image not found")
+)
+
+// ManagedLabel is the label of the Docker objects created by Lava
+// that outlive a scan. For instance, the snapshots of the checks
+// that fail.
+const ManagedLabel = "com.adevinta.lava.managed"
 
 // Runtime is the container runtime.
 type Runtime int
@@ -37,6 +77,8 @@ const (
 	RuntimeDockerdDockerDesktop                 // Docker Desktop
 	RuntimeDockerdRancherDesktop                // Rancher Desktop (dockerd)
 	RuntimeDockerdPodmanDesktop                 // Podman Desktop (dockerd)
+	RuntimePodman                               // Podman
+	RuntimeDockerdColima                        // Colima (dockerd)
 )
 
 var runtimeNames = map[string]Runtime{
@@ -44,6 +86,8 @@ var runtimeNames = map[string]Runtime{
 	"DockerdDockerDesktop":  RuntimeDockerdDockerDesktop,
 	"DockerdRancherDesktop": RuntimeDockerdRancherDesktop,
 	"DockerdPodmanDesktop":  RuntimeDockerdPodmanDesktop,
+	"Podman":                RuntimePodman,
+	"DockerdColima":         RuntimeDockerdColima,
 }
 
 // ParseRuntime converts a runtime name into a [Runtime] value. It
@@ -86,7 +130,22 @@ func (rt *Runtime) UnmarshalText(text []byte) error {
 // DockerdClient represents a Docker API client.
 type DockerdClient struct {
 	client.APIClient
-	rt Runtime
+	rt      Runtime
+	cfg     *configfile.ConfigFile
+	host    string
+	network string
+	gateway string
+
+	// bridgeGw caches the gateway of the default bridge network.
+	// It is a pointer, so the copies of the client share the
+	// cache.
+	bridgeGw *gatewayCache
+}
+
+// gatewayCache caches the result of a successful gateway lookup.
+type gatewayCache struct {
+	mu sync.Mutex
+	gw *net.IPNet
 }
 
 // NewDockerdClient returns a new container runtime client compatible
@@ -97,6 +156,17 @@ type DockerdClient struct {
 // from the Docker config file and honors the [Docker CLI environment
 // variables]. It also sets up TLS authentication if TLS is enabled.
 //
+// If the runtime is [RuntimePodman] and the DOCKER_HOST environment
+// variable is not set, the client connects to the Podman API socket.
+// See [PodmanHost].
+//
+// If the runtime is [RuntimeDockerd] and the daemon host is a Colima
+// socket, [RuntimeDockerdColima] is used instead.
+//
+// Remote daemons are reached over SSH using hosts with the form
+// ssh://[user@]host[:port]. In that case, the Docker CLI must be
+// installed in the remote host.
+//
 // [Docker CLI environment variables]: https://docs.docker.com/engine/reference/commandline/cli/#environment-variables
 func NewDockerdClient(rt Runtime) (DockerdClient, error) {
 	tlsVerify := os.Getenv(client.EnvTLSVerify) != ""
@@ -119,27 +189,121 @@ func NewDockerdClient(rt Runtime) (DockerdClient, error) {
 		TLSVerify:  tlsVerify,
 		TLSOptions: tlsopts,
 	}
+	if rt == RuntimePodman && os.Getenv(client.EnvOverrideHost) == "" {
+		opts.Hosts = []string{PodmanHost()}
+	}
+
+	cfg := config.LoadDefaultConfigFile(io.Discard)
 
-	acpicli, err := command.NewAPIClientFromFlags(opts, config.LoadDefaultConfigFile(io.Discard))
+	acpicli, err := command.NewAPIClientFromFlags(opts, cfg)
 	if err != nil {
 		return DockerdClient{}, fmt.Errorf("new Docker API Client: %w", err)
 	}
 
+	// Connection helpers, like the SSH one, replace the daemon
+	// host of the API client with a dummy value. So, the endpoint
+	// is resolved again to keep the actual host.
+	host, err := endpointHost(opts, cfg)
+	if err != nil {
+		return DockerdClient{}, fmt.Errorf("resolve Docker endpoint: %w", err)
+	}
+	if isRemoteHost(host) {
+		slog.Warn("using remote Docker daemon, local targets are not reachable from the checks", "daemonHost", host)
+	}
+
+	if rt == RuntimeDockerd && isColimaHost(host) {
+		slog.Debug("detected Colima runtime", "daemonHost", host)
+		rt = RuntimeDockerdColima
+	}
+
 	cli := DockerdClient{
 		APIClient: acpicli,
 		rt:        rt,
+		cfg:       cfg,
+		host:      host,
+		bridgeGw:  &gatewayCache{},
 	}
 	return cli, nil
 }
 
+// PodmanHost returns the address of the Podman API socket. If the
+// CONTAINER_HOST environment variable is set, its value is returned.
+// Otherwise, it returns the socket of the rootless Podman service,
+// "$XDG_RUNTIME_DIR/podman/podman.sock", or the socket of the
+// rootful service, "/run/podman/podman.sock", if XDG_RUNTIME_DIR is
+// not set.
+func PodmanHost() string {
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return "unix://" + filepath.Join(dir, "podman", "podman.sock")
+	}
+	return "unix:///run/podman/podman.sock"
+}
+
+// endpointHost returns the host of the Docker endpoint selected by
+// the provided options, the environment and the Docker config file.
+// It follows the same rules as the Docker CLI.
+func endpointHost(opts *flags.ClientOptions, cfg *configfile.ConfigFile) (string, error) {
+	name := command.DefaultContextName
+	switch {
+	case len(opts.Hosts) > 0, os.Getenv(client.EnvOverrideHost) != "":
+	case os.Getenv(command.EnvOverrideContext) != "":
+		name = os.Getenv(command.EnvOverrideContext)
+	case cfg.CurrentContext != "":
+		name = cfg.CurrentContext
+	}
+
+	storeConfig := command.DefaultContextStoreConfig()
+	cs := &command.ContextStoreWithDefault{
+		Store: store.New(config.ContextStoreDir(), storeConfig),
+		Resolver: func() (*command.DefaultContext, error) {
+			return command.ResolveDefaultContext(opts, storeConfig)
+		},
+	}
+
+	meta, err := cs.GetMetadata(name)
+	if err != nil {
+		return "", fmt.Errorf("get context %q: %w", name, err)
+	}
+	ep, err := docker.EndpointFromContext(meta)
+	if err != nil {
+		return "", fmt.Errorf("get context %q endpoint: %w", name, err)
+	}
+	return ep.Host, nil
+}
+
+// isRemoteHost reports whether the provided daemon host is a remote
+// daemon reached over SSH.
+func isRemoteHost(daemonHost string) bool {
+	u, err := url.Parse(daemonHost)
+	return err == nil && u.Scheme == "ssh"
+}
+
+// isColimaHost reports whether the provided daemon host is the Docker
+// socket of a Colima instance. That is, a Unix socket with the path
+// "~/.colima/<profile>/docker.sock".
+func isColimaHost(daemonHost string) bool {
+	u, err := url.Parse(daemonHost)
+	if err != nil || u.Scheme != "unix" || path.Base(u.Path) != "docker.sock" {
+		return false
+	}
+	return path.Base(path.Dir(path.Dir(u.Path))) == ".colima"
+}
+
 // Close closes the transport used by the client.
 func (cli *DockerdClient) Close() error {
 	return cli.APIClient.Close()
 }
 
-// DaemonHost returns the host address used by the client.
+// DaemonHost returns the host address used by the client. For
+// remote daemons, it returns the ssh:// address of the daemon.
 func (cli *DockerdClient) DaemonHost() string {
-	daemonHost := cli.APIClient.DaemonHost()
+	daemonHost := cli.host
+	if daemonHost == "" {
+		daemonHost = cli.APIClient.DaemonHost()
+	}
 
 	u, err := url.Parse(daemonHost)
 	if err != nil {
@@ -149,18 +313,78 @@ func (cli *DockerdClient) DaemonHost() string {
 
 	// Docker Desktop cannot share Unix sockets unless it is the
 	// Docker Unix socket and its path is exactly
+	// "/var/run/docker.sock". Colima forwards the Docker Unix
+	// socket of its virtual machine, whose path is also
 	// "/var/run/docker.sock".
-	if cli.rt == RuntimeDockerdDockerDesktop && u.Scheme == "unix" && path.Base(u.Path) == "docker.sock" {
+	if (cli.rt == RuntimeDockerdDockerDesktop || cli.rt == RuntimeDockerdColima) && u.Scheme == "unix" && path.Base(u.Path) == "docker.sock" {
 		return "unix:///var/run/docker.sock"
 	}
 
 	return daemonHost
 }
 
+// CheckAPIVersion checks that the Docker API version requested with
+// the DOCKER_API_VERSION environment variable is supported by the
+// daemon. If the environment variable is not set, the API version is
+// negotiated with the daemon and no check is done.
+func (cli *DockerdClient) CheckAPIVersion() error {
+	version := os.Getenv(client.EnvOverrideAPIVersion)
+	if version == "" {
+		return nil
+	}
+
+	ping, err := cli.Ping(context.Background())
+	if err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+
+	// Old daemons do not report their API version.
+	if ping.APIVersion == "" {
+		return nil
+	}
+
+	if versions.GreaterThan(version, ping.APIVersion) {
+		return fmt.Errorf("%w: %v: maximum supported version is %v", ErrUnsupportedAPIVersion, version, ping.APIVersion)
+	}
+	return nil
+}
+
+// SetNetwork sets the Docker network the containers are attached to.
+// The host gateway exposed by the client is computed according to
+// this network. An empty name means the default network of the
+// container runtime.
+func (cli *DockerdClient) SetNetwork(name string) error {
+	if name == "" || (cli.rt != RuntimeDockerd && cli.rt != RuntimePodman) {
+		cli.network = name
+		cli.gateway = ""
+		return nil
+	}
+
+	gw, err := cli.networkGateway(name)
+	if err != nil {
+		return fmt.Errorf("get network gateway: %w", err)
+	}
+	cli.network = name
+	cli.gateway = gw.IP.String()
+	return nil
+}
+
+// Network returns the Docker network the containers are attached
+// to. An empty string means the default network of the container
+// runtime.
+func (cli *DockerdClient) Network() string {
+	return cli.network
+}
+
 // HostGatewayHostname returns a hostname that points to the container
-// engine host and is reachable from the containers.
+// engine host and is reachable from the containers. It can be
+// overridden with the LAVA_HOST_GATEWAY_HOSTNAME environment
+// variable.
 func (cli *DockerdClient) HostGatewayHostname() string {
-	if cli.rt == RuntimeDockerdPodmanDesktop {
+	if hostname := os.Getenv("LAVA_HOST_GATEWAY_HOSTNAME"); hostname != "" {
+		return hostname
+	}
+	if cli.rt == RuntimeDockerdPodmanDesktop || cli.rt == RuntimePodman {
 		return "host.containers.internal"
 	}
 	return "host.docker.internal"
@@ -170,33 +394,332 @@ func (cli *DockerdClient) HostGatewayHostname() string {
 // containers to reach the container engine host. It returns an empty
 // string if this mapping is not required.
 func (cli *DockerdClient) HostGatewayMapping() string {
+	if cli.rt == RuntimeDockerdColima {
+		// In Colima, "host-gateway" resolves to the address
+		// of the host in the virtual machine network.
+		return cli.HostGatewayHostname() + ":host-gateway"
+	}
 	if cli.rt == RuntimeDockerd {
+		// "host-gateway" always resolves to the gateway of
+		// the default bridge network, which is not reachable
+		// from internal networks.
+		if cli.gateway != "" {
+			return cli.HostGatewayHostname() + ":" + cli.gateway
+		}
+		return cli.HostGatewayHostname() + ":host-gateway"
+	}
+	// The other runtimes add the default host gateway hostname
+	// to the containers, so no mapping is required unless it is
+	// overridden.
+	if os.Getenv("LAVA_HOST_GATEWAY_HOSTNAME") != "" {
 		return cli.HostGatewayHostname() + ":host-gateway"
 	}
 	return ""
 }
 
 // HostGatewayInterfaceAddr returns the address of a local interface
-// that is reachable from the containers.
+// that is reachable from the containers. Remote daemons cannot reach
+// the local host, so the loopback address is returned for them.
 func (cli *DockerdClient) HostGatewayInterfaceAddr() (string, error) {
+	if isRemoteHost(cli.host) {
+		return "127.0.0.1", nil
+	}
 	if cli.rt == RuntimeDockerd {
+		if cli.gateway != "" {
+			return cli.gateway, nil
+		}
+
 		gw, err := cli.bridgeGateway()
 		if err != nil {
 			return "", fmt.Errorf("get bridge gateway: %w", err)
 		}
 		return gw.IP.String(), nil
 	}
+	if cli.rt == RuntimePodman {
+		return cli.podmanHostAddr()
+	}
 	return "127.0.0.1", nil
 }
 
+// defaultPodmanNetwork is the name of the default network in
+// Podman.
+const defaultPodmanNetwork = "podman"
+
+// podmanHostAddr returns the address of the host that
+// "host.containers.internal" resolves to. In rootful mode, it is the
+// gateway of the network of the containers. In rootless mode, the
+// networks live in a separate network namespace and Podman resolves
+// "host.containers.internal" to the first non-loopback address of
+// the host.
+func (cli *DockerdClient) podmanHostAddr() (string, error) {
+	info, err := cli.Info(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("info: %w", err)
+	}
+
+	if !slices.Contains(info.SecurityOptions, "name=rootless") {
+		if cli.gateway != "" {
+			return cli.gateway, nil
+		}
+
+		gw, err := cli.networkGateway(defaultPodmanNetwork)
+		if err != nil {
+			return "", fmt.Errorf("get podman network gateway: %w", err)
+		}
+		return gw.IP.String(), nil
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", fmt.Errorf("get interface addresses: %w", err)
+	}
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || !ipnet.IP.IsGlobalUnicast() {
+			continue
+		}
+		return ipnet.IP.String(), nil
+	}
+	return "", errors.New("no non-loopback address")
+}
+
+// probeTimeout is the maximum time the host gateway probe is allowed
+// to run, including the time required to pull the probe image.
+const probeTimeout = 2 * time.Minute
+
+// ProbeHostGateway checks that the containers can reach the host
+// through the host gateway. It listens on the provided address and
+// runs a container with the provided image, which sends an HTTP
+// request to the listener using the specified hostname. If hostname
+// is empty, the hostname returned by
+// [DockerdClient.HostGatewayHostname] is used. The image must
+// provide the wget command. For instance, "busybox". The image is
+// pulled if it is not present. If the listener does not receive the
+// request, it returns an error wrapping [ErrHostGatewayUnreachable].
+func (cli *DockerdClient) ProbeHostGateway(image, addr, hostname string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	if hostname == "" {
+		hostname = cli.HostGatewayHostname()
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	var reached atomic.Bool
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reached.Store(true)
+		}),
+	}
+	go srv.Serve(ln) //nolint:errcheck
+	defer srv.Close()
+
+	if err := cli.ensureImage(ctx, image); err != nil {
+		return fmt.Errorf("ensure image: %w", err)
+	}
+
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		return fmt.Errorf("split host port: %w", err)
+	}
+	probeURL := "http://" + net.JoinHostPort(hostname, port) + "/"
+
+	contCfg := &container.Config{
+		Image: image,
+		Cmd:   []string{"wget", "-q", "-O", "/dev/null", "-T", "5", probeURL},
+	}
+	hostCfg := &container.HostConfig{
+		NetworkMode: container.NetworkMode(cli.network),
+	}
+	if m := cli.HostGatewayMapping(); m != "" {
+		hostCfg.ExtraHosts = []string{m}
+	}
+
+	resp, err := cli.ContainerCreate(ctx, contCfg, hostCfg, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("container create: %w", err)
+	}
+	defer func() {
+		if err := cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true}); err != nil {
+			slog.Warn("could not remove probe container", "container", resp.ID, "err", err)
+		}
+	}()
+
+	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("container start: %w", err)
+	}
+
+	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("container wait: %w", err)
+	case status := <-statusCh:
+		if status.StatusCode != 0 || !reached.Load() {
+			return fmt.Errorf("%w: %v", ErrHostGatewayUnreachable, probeURL)
+		}
+	}
+	return nil
+}
+
+// ensureImage pulls the provided image if it is not present.
+func (cli *DockerdClient) ensureImage(ctx context.Context, image string) error {
+	_, _, err := cli.ImageInspectWithRaw(ctx, image)
+	if err == nil {
+		return nil
+	}
+	if !client.IsErrNotFound(err) {
+		return fmt.Errorf("image inspect: %w", err)
+	}
+
+	return cli.PullImage(ctx, image)
+}
+
+// PullImage pulls the provided image using the registry credentials
+// of the Docker config file. The pull progress is logged. If the
+// registry rejects the credentials, the returned error wraps
+// [ErrImageUnauthorized]. If the image does not exist, it wraps
+// [ErrImageNotFound].
+func (cli *DockerdClient) PullImage(ctx context.Context, ref string) error {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return fmt.Errorf("parse reference: %w", err)
+	}
+
+	auth, err := cli.registryAuth(reference.Domain(named))
+	if err != nil {
+		return fmt.Errorf("get registry credentials: %w", err)
+	}
+
+	rc, err := cli.ImagePull(ctx, ref, types.ImagePullOptions{RegistryAuth: auth})
+	if err != nil {
+		return fmt.Errorf("image pull %v: %w", ref, classifyPullError(err))
+	}
+	defer rc.Close()
+
+	if err := readDockerOutput(rc); err != nil {
+		return fmt.Errorf("image pull %v: %w", ref, classifyPullError(err))
+	}
+	return nil
+}
+
+// dockerHubAuthKey is the key of the Docker Hub credentials in the
+// Docker config file.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// registryAuth returns the encoded credentials of the specified
+// registry, as expected by the X-Registry-Auth header. It returns an
+// empty string if there are no credentials for the registry.
+func (cli *DockerdClient) registryAuth(domain string) (string, error) {
+	if cli.cfg == nil {
+		return "", nil
+	}
+
+	key := domain
+	if domain == "docker.io" {
+		key = dockerHubAuthKey
+	}
+
+	ac, err := cli.cfg.GetAuthConfig(key)
+	if err != nil {
+		return "", err
+	}
+	if ac.Username == "" && ac.Auth == "" && ac.IdentityToken == "" && ac.RegistryToken == "" {
+		return "", nil
+	}
+
+	return registry.EncodeAuthConfig(registry.AuthConfig{
+		Username:      ac.Username,
+		Password:      ac.Password,
+		Auth:          ac.Auth,
+		ServerAddress: ac.ServerAddress,
+		IdentityToken: ac.IdentityToken,
+		RegistryToken: ac.RegistryToken,
+	})
+}
+
+// readDockerOutput reads the JSON messages returned by the Docker
+// daemon when pulling an image. Progress messages are logged. It
+// returns the first error message found in the stream.
+func readDockerOutput(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("decode message: %w", err)
+		}
+
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if msg.ErrorMessage != "" {
+			return errors.New(msg.ErrorMessage)
+		}
+		if msg.Status != "" {
+			slog.Debug("docker output", "id", msg.ID, "status", msg.Status, "progress", msg.ProgressMessage)
+		}
+	}
+}
+
+// classifyPullError wraps the provided image pull error with
+// [ErrImageUnauthorized] or [ErrImageNotFound] when possible. Errors
+// reported in the pull output are not typed, so their message is
+// inspected.
+func classifyPullError(err error) error {
+	switch {
+	case errdefs.IsUnauthorized(err), errdefs.IsForbidden(err):
+		return fmt.Errorf("%w: %w", ErrImageUnauthorized, err)
+	case errdefs.IsNotFound(err):
+		return fmt.Errorf("%w: %w", ErrImageNotFound, err)
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "unauthorized"), strings.Contains(msg, "authentication required"), strings.Contains(msg, "denied"):
+		return fmt.Errorf("%w: %w", ErrImageUnauthorized, err)
+	case strings.Contains(msg, "not found"), strings.Contains(msg, "manifest unknown"):
+		return fmt.Errorf("%w: %w", ErrImageNotFound, err)
+	}
+	return err
+}
+
 // defaultDockerBridgeNetwork is the name of the default bridge
 // network in Docker.
 const defaultDockerBridgeNetwork = "bridge"
 
 // bridgeGateway returns the gateway of the default Docker bridge
-// network.
+// network. The gateway is cached after the first successful lookup.
 func (cli *DockerdClient) bridgeGateway() (*net.IPNet, error) {
-	gws, err := cli.gateways(context.Background(), defaultDockerBridgeNetwork)
+	if cli.bridgeGw == nil {
+		return cli.networkGateway(defaultDockerBridgeNetwork)
+	}
+
+	cli.bridgeGw.mu.Lock()
+	defer cli.bridgeGw.mu.Unlock()
+
+	if cli.bridgeGw.gw != nil {
+		return cli.bridgeGw.gw, nil
+	}
+
+	gw, err := cli.networkGateway(defaultDockerBridgeNetwork)
+	if err != nil {
+		return nil, err
+	}
+	cli.bridgeGw.gw = gw
+	return gw, nil
+}
+
+// networkGateway returns the gateway of the specified Docker
+// network. It returns error if the network does not have exactly one
+// gateway.
+func (cli *DockerdClient) networkGateway(network string) (*net.IPNet, error) {
+	gws, err := cli.gateways(context.Background(), network)
 	if err != nil {
 		return nil, fmt.Errorf("could not get Docker network gateway: %w", err)
 	}
@@ -5,16 +5,22 @@ package containers
 import (
 	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"regexp"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/docker/cli/cli/config/configfile"
+	configtypes "github.com/docker/cli/cli/config/types"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -31,6 +37,12 @@ func TestParseRuntime(t *testing.T) {
 			want:       RuntimeDockerdDockerDesktop,
 			wantNilErr: true,
 		},
+		{
+			name:       "podman",
+			rtName:     "Podman",
+			want:       RuntimePodman,
+			wantNilErr: true,
+		},
 		{
 			name:       "invalid runtime",
 			rtName:     "Invalid",
@@ -144,6 +156,12 @@ var (
 	bridgeCfgs = []ipamConfig{{Subnet: "172.17.0.0/16", Gateway: "172.17.0.1"}}
 	bridgeAddr = &net.IPNet{IP: net.ParseIP("172.17.0.1"), Mask: net.CIDRMask(16, 32)}
 
+	podmanCfgs = []ipamConfig{{Subnet: "10.88.0.0/16", Gateway: "10.88.0.1"}}
+	podmanAddr = &net.IPNet{IP: net.ParseIP("10.88.0.1"), Mask: net.CIDRMask(16, 32)}
+
+	internalCfgs = []ipamConfig{{Subnet: "172.20.0.0/16", Gateway: "172.20.0.1"}}
+	internalAddr = &net.IPNet{IP: net.ParseIP("172.20.0.1"), Mask: net.CIDRMask(16, 32)}
+
 	defaultAPITestdata = apiTestdata{
 		networks: map[string]networkTestdata{
 			defaultDockerBridgeNetwork: {
@@ -151,6 +169,14 @@ var (
 				gateways:      []*net.IPNet{bridgeAddr},
 				bridgeGateway: bridgeAddr,
 			},
+			defaultPodmanNetwork: {
+				cfgs:     podmanCfgs,
+				gateways: []*net.IPNet{podmanAddr},
+			},
+			"internal": {
+				cfgs:     internalCfgs,
+				gateways: []*net.IPNet{internalAddr},
+			},
 			"multi": {
 				cfgs: []ipamConfig{
 					{Subnet: "172.18.0.0/16", Gateway: "172.18.0.1"},
@@ -179,7 +205,8 @@ var (
 			},
 		},
 		system: systemTestdata{
-			id: "dockerutil",
+			id:         "dockerutil",
+			apiVersion: "1.43",
 		},
 	}
 )
@@ -256,6 +283,170 @@ func TestNewDockerdClient_tls(t *testing.T) {
 	}
 }
 
+func TestNewDockerdClient_podman(t *testing.T) {
+	tests := []struct {
+		name          string
+		dockerHost    string
+		containerHost string
+		xdgRuntimeDir string
+		want          string
+	}{
+		{
+			name:          "rootless socket",
+			xdgRuntimeDir: "/run/user/1000",
+			want:          "unix:///run/user/1000/podman/podman.sock",
+		},
+		{
+			name: "rootful socket",
+			want: "unix:///run/podman/podman.sock",
+		},
+		{
+			name:          "container host",
+			containerHost: "unix:///tmp/podman.sock",
+			xdgRuntimeDir: "/run/user/1000",
+			want:          "unix:///tmp/podman.sock",
+		},
+		{
+			name:          "docker host",
+			dockerHost:    "tcp://example.com:1234",
+			containerHost: "unix:///tmp/podman.sock",
+			want:          "tcp://example.com:1234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOCKER_CONFIG", "testdata/certs")
+			t.Setenv("DOCKER_HOST", tt.dockerHost)
+			t.Setenv("CONTAINER_HOST", tt.containerHost)
+			t.Setenv("XDG_RUNTIME_DIR", tt.xdgRuntimeDir)
+
+			cli, err := NewDockerdClient(RuntimePodman)
+			if err != nil {
+				t.Fatalf("new API client: %v", err)
+			}
+			defer cli.Close()
+
+			if got := cli.DaemonHost(); got != tt.want {
+				t.Errorf("unexpected daemon host: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDockerdClient_HostGatewayInterfaceAddr_podmanRootless(t *testing.T) {
+	td := defaultAPITestdata
+	td.system.rootless = true
+
+	cli, err := newTestDockerdClient(t, RuntimePodman, td)
+	if err != nil {
+		t.Fatalf("new test client: %v", err)
+	}
+	defer cli.Close()
+
+	got, err := cli.HostGatewayInterfaceAddr()
+	if err != nil {
+		t.Skipf("no non-loopback address: %v", err)
+	}
+
+	ip := net.ParseIP(got)
+	if ip == nil || ip.IsLoopback() || ip.Equal(podmanAddr.IP) {
+		t.Errorf("unexpected address: %v", got)
+	}
+}
+
+func TestNewDockerdClient_colima(t *testing.T) {
+	tests := []struct {
+		name           string
+		rt             Runtime
+		dockerHost     string
+		wantRuntime    Runtime
+		wantDaemonHost string
+	}{
+		{
+			name:           "colima socket",
+			rt:             RuntimeDockerd,
+			dockerHost:     "unix:///Users/lava/.colima/default/docker.sock",
+			wantRuntime:    RuntimeDockerdColima,
+			wantDaemonHost: "unix:///var/run/docker.sock",
+		},
+		{
+			name:           "colima profile socket",
+			rt:             RuntimeDockerd,
+			dockerHost:     "unix:///Users/lava/.colima/work/docker.sock",
+			wantRuntime:    RuntimeDockerdColima,
+			wantDaemonHost: "unix:///var/run/docker.sock",
+		},
+		{
+			name:           "docker socket",
+			rt:             RuntimeDockerd,
+			dockerHost:     "unix:///var/run/docker.sock",
+			wantRuntime:    RuntimeDockerd,
+			wantDaemonHost: "unix:///var/run/docker.sock",
+		},
+		{
+			name:           "tcp host",
+			rt:             RuntimeDockerd,
+			dockerHost:     "tcp://example.com:1234",
+			wantRuntime:    RuntimeDockerd,
+			wantDaemonHost: "tcp://example.com:1234",
+		},
+		{
+			name:           "explicit runtime",
+			rt:             RuntimeDockerdDockerDesktop,
+			dockerHost:     "unix:///Users/lava/.colima/default/docker.sock",
+			wantRuntime:    RuntimeDockerdDockerDesktop,
+			wantDaemonHost: "unix:///var/run/docker.sock",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOCKER_CONFIG", "testdata/certs")
+			t.Setenv("DOCKER_HOST", tt.dockerHost)
+
+			cli, err := NewDockerdClient(tt.rt)
+			if err != nil {
+				t.Fatalf("new API client: %v", err)
+			}
+			defer cli.Close()
+
+			if cli.rt != tt.wantRuntime {
+				t.Errorf("unexpected runtime: got: %v, want: %v", cli.rt, tt.wantRuntime)
+			}
+
+			if got := cli.DaemonHost(); got != tt.wantDaemonHost {
+				t.Errorf("unexpected daemon host: got: %v, want: %v", got, tt.wantDaemonHost)
+			}
+		})
+	}
+}
+
+func TestNewDockerdClient_ssh(t *testing.T) {
+	const dockerHost = "ssh://lava@builder.example.com:2222"
+
+	t.Setenv("DOCKER_CONFIG", "testdata/certs")
+	t.Setenv("DOCKER_HOST", dockerHost)
+
+	cli, err := NewDockerdClient(RuntimeDockerd)
+	if err != nil {
+		t.Fatalf("new API client: %v", err)
+	}
+	defer cli.Close()
+
+	if dh := cli.DaemonHost(); dh != dockerHost {
+		t.Errorf("unexpected daemon host: got: %v, want: %v", dh, dockerHost)
+	}
+
+	addr, err := cli.HostGatewayInterfaceAddr()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if addr != "127.0.0.1" {
+		t.Errorf("unexpected address: got: %v, want: 127.0.0.1", addr)
+	}
+}
+
 func TestDockerdClient_DaemonHost(t *testing.T) {
 	const dockerHost = "tcp://example.com:1234"
 
@@ -277,6 +468,7 @@ func TestDockerdClient_HostGatewayHostname(t *testing.T) {
 	tests := []struct {
 		name string
 		rt   Runtime
+		env  string
 		want string
 	}{
 		{
@@ -289,6 +481,28 @@ func TestDockerdClient_HostGatewayHostname(t *testing.T) {
 			rt:   RuntimeDockerdPodmanDesktop,
 			want: "host.containers.internal",
 		},
+		{
+			name: "podman",
+			rt:   RuntimePodman,
+			want: "host.containers.internal",
+		},
+		{
+			name: "dockerd override",
+			rt:   RuntimeDockerd,
+			env:  "gateway.example.com",
+			want: "gateway.example.com",
+		},
+		{
+			name: "dockerd colima",
+			rt:   RuntimeDockerdColima,
+			want: "host.docker.internal",
+		},
+		{
+			name: "podman override",
+			rt:   RuntimePodman,
+			env:  "gateway.example.com",
+			want: "gateway.example.com",
+		},
 		{
 			name: "invalid runtime",
 			rt:   Runtime(255),
@@ -298,6 +512,8 @@ func TestDockerdClient_HostGatewayHostname(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LAVA_HOST_GATEWAY_HOSTNAME", tt.env)
+
 			cli, err := NewDockerdClient(tt.rt)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
@@ -316,6 +532,7 @@ func TestDockerdClient_HostGatewayMapping(t *testing.T) {
 	tests := []struct {
 		name string
 		rt   Runtime
+		env  string
 		want string
 	}{
 		{
@@ -328,6 +545,28 @@ func TestDockerdClient_HostGatewayMapping(t *testing.T) {
 			rt:   RuntimeDockerdPodmanDesktop,
 			want: "",
 		},
+		{
+			name: "podman",
+			rt:   RuntimePodman,
+			want: "",
+		},
+		{
+			name: "dockerd override",
+			rt:   RuntimeDockerd,
+			env:  "gateway.example.com",
+			want: "gateway.example.com:host-gateway",
+		},
+		{
+			name: "dockerd colima",
+			rt:   RuntimeDockerdColima,
+			want: "host.docker.internal:host-gateway",
+		},
+		{
+			name: "dockerd docker desktop override",
+			rt:   RuntimeDockerdDockerDesktop,
+			env:  "gateway.example.com",
+			want: "gateway.example.com:host-gateway",
+		},
 		{
 			name: "invalid runtime",
 			rt:   Runtime(255),
@@ -337,6 +576,8 @@ func TestDockerdClient_HostGatewayMapping(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LAVA_HOST_GATEWAY_HOSTNAME", tt.env)
+
 			cli, err := NewDockerdClient(tt.rt)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
@@ -490,6 +731,16 @@ func TestDockerdClient_HostGatewayInterfaceAddr(t *testing.T) {
 			rt:   RuntimeDockerd,
 			want: bridgeAddr.IP.String(),
 		},
+		{
+			name: "podman",
+			rt:   RuntimePodman,
+			want: podmanAddr.IP.String(),
+		},
+		{
+			name: "colima",
+			rt:   RuntimeDockerdColima,
+			want: "127.0.0.1",
+		},
 	}
 
 	for _, tt := range tests {
@@ -512,6 +763,328 @@ func TestDockerdClient_HostGatewayInterfaceAddr(t *testing.T) {
 	}
 }
 
+func TestDockerdClient_HostGatewayInterfaceAddr_cache(t *testing.T) {
+	tests := []struct {
+		name         string
+		networks     map[string]networkTestdata
+		wantInspects int32
+		wantNilErr   bool
+	}{
+		{
+			name:         "successful lookup",
+			networks:     defaultAPITestdata.networks,
+			wantInspects: 1,
+			wantNilErr:   true,
+		},
+		{
+			name:         "failed lookup",
+			networks:     map[string]networkTestdata{},
+			wantInspects: 3,
+			wantNilErr:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			td := defaultAPITestdata
+			td.networks = tt.networks
+			td.networkInspects = &atomic.Int32{}
+
+			cli, err := newTestDockerdClient(t, RuntimeDockerd, td)
+			if err != nil {
+				t.Fatalf("new test client: %v", err)
+			}
+			defer cli.Close()
+
+			for i := 0; i < 3; i++ {
+				addr, err := cli.HostGatewayInterfaceAddr()
+				if (err == nil) != tt.wantNilErr {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err == nil && addr != bridgeAddr.IP.String() {
+					t.Errorf("unexpected address: got: %v, want: %v", addr, bridgeAddr.IP)
+				}
+			}
+
+			if got := td.networkInspects.Load(); got != tt.wantInspects {
+				t.Errorf("unexpected number of network inspects: got: %v, want: %v", got, tt.wantInspects)
+			}
+		})
+	}
+}
+
+func TestDockerdClient_SetNetwork(t *testing.T) {
+	tests := []struct {
+		name        string
+		rt          Runtime
+		network     string
+		wantAddr    string
+		wantMapping string
+		wantNilErr  bool
+	}{
+		{
+			name:        "docker engine default network",
+			rt:          RuntimeDockerd,
+			network:     "",
+			wantAddr:    bridgeAddr.IP.String(),
+			wantMapping: "host.docker.internal:host-gateway",
+			wantNilErr:  true,
+		},
+		{
+			name:        "docker engine custom network",
+			rt:          RuntimeDockerd,
+			network:     "internal",
+			wantAddr:    internalAddr.IP.String(),
+			wantMapping: "host.docker.internal:" + internalAddr.IP.String(),
+			wantNilErr:  true,
+		},
+		{
+			name:        "docker desktop custom network",
+			rt:          RuntimeDockerdDockerDesktop,
+			network:     "internal",
+			wantAddr:    "127.0.0.1",
+			wantMapping: "",
+			wantNilErr:  true,
+		},
+		{
+			name:        "podman custom network",
+			rt:          RuntimePodman,
+			network:     "internal",
+			wantAddr:    internalAddr.IP.String(),
+			wantMapping: "",
+			wantNilErr:  true,
+		},
+		{
+			name:       "docker engine multiple gateways",
+			rt:         RuntimeDockerd,
+			network:    "multi",
+			wantNilErr: false,
+		},
+		{
+			name:       "docker engine unknown network",
+			rt:         RuntimeDockerd,
+			network:    "notfound",
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli, err := newTestDockerdClient(t, tt.rt, defaultAPITestdata)
+			if err != nil {
+				t.Fatalf("new test client: %v", err)
+			}
+			defer cli.Close()
+
+			err = cli.SetNetwork(tt.network)
+
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got := cli.Network(); got != tt.network {
+				t.Errorf("unexpected network: got: %v, want: %v", got, tt.network)
+			}
+
+			addr, err := cli.HostGatewayInterfaceAddr()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if addr != tt.wantAddr {
+				t.Errorf("unexpected address: got: %v, want: %v", addr, tt.wantAddr)
+			}
+
+			if got := cli.HostGatewayMapping(); got != tt.wantMapping {
+				t.Errorf("unexpected mapping: got: %v, want: %v", got, tt.wantMapping)
+			}
+		})
+	}
+}
+
+func TestDockerdClient_ProbeHostGateway(t *testing.T) {
+	tests := []struct {
+		name            string
+		hostname        string
+		hosts           []string
+		hostUnreachable bool
+		wantErr         error
+	}{
+		{
+			name:            "reachable",
+			hostUnreachable: false,
+			wantErr:         nil,
+		},
+		{
+			name:            "unreachable",
+			hostUnreachable: true,
+			wantErr:         ErrHostGatewayUnreachable,
+		},
+		{
+			name:     "custom hostname",
+			hostname: "lava.internal",
+			hosts:    []string{"lava.internal"},
+			wantErr:  nil,
+		},
+		{
+			name:     "unresolvable custom hostname",
+			hostname: "lava.internal",
+			hosts:    []string{"host.docker.internal"},
+			wantErr:  ErrHostGatewayUnreachable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			td := defaultAPITestdata
+			td.hostUnreachable = tt.hostUnreachable
+			td.hosts = tt.hosts
+
+			// Docker Desktop listens on the loopback
+			// interface.
+			cli, err := newTestDockerdClient(t, RuntimeDockerdDockerDesktop, td)
+			if err != nil {
+				t.Fatalf("new test client: %v", err)
+			}
+			defer cli.Close()
+
+			err = cli.ProbeHostGateway("busybox", "127.0.0.1:0", tt.hostname)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDockerdClient_PullImage(t *testing.T) {
+	tests := []struct {
+		name       string
+		ref        string
+		auths      map[string]configtypes.AuthConfig
+		wantErr    error
+		wantNilErr bool
+	}{
+		{
+			name:       "public image",
+			ref:        "registry.example.com/public:latest",
+			wantNilErr: true,
+		},
+		{
+			name: "private image",
+			ref:  "registry.example.com/private:latest",
+			auths: map[string]configtypes.AuthConfig{
+				"registry.example.com": {Username: "user", Password: "pass"},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "invalid credentials",
+			ref:  "registry.example.com/private:latest",
+			auths: map[string]configtypes.AuthConfig{
+				"registry.example.com": {Username: "user", Password: "invalid"},
+			},
+			wantErr: ErrImageUnauthorized,
+		},
+		{
+			name:    "missing credentials",
+			ref:     "registry.example.com/private:latest",
+			wantErr: ErrImageUnauthorized,
+		},
+		{
+			name:    "denied in output",
+			ref:     "registry.example.com/denied:latest",
+			wantErr: ErrImageUnauthorized,
+		},
+		{
+			name:    "missing image",
+			ref:     "registry.example.com/missing:latest",
+			wantErr: ErrImageNotFound,
+		},
+		{
+			name:    "missing image in output",
+			ref:     "registry.example.com/missing-stream:latest",
+			wantErr: ErrImageNotFound,
+		},
+		{
+			name:       "invalid reference",
+			ref:        "Invalid Reference",
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli, err := newTestDockerdClient(t, RuntimeDockerd, defaultAPITestdata)
+			if err != nil {
+				t.Fatalf("new test client: %v", err)
+			}
+			defer cli.Close()
+
+			cli.cfg = configfile.New("")
+			for k, v := range tt.auths {
+				cli.cfg.AuthConfigs[k] = v
+			}
+
+			err = cli.PullImage(context.Background(), tt.ref)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+				}
+			case (err == nil) != tt.wantNilErr:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDockerdClient_CheckAPIVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		wantErr error
+	}{
+		{
+			name:    "negotiated",
+			version: "",
+		},
+		{
+			name:    "older version",
+			version: "1.41",
+		},
+		{
+			name:    "same version",
+			version: "1.43",
+		},
+		{
+			name:    "newer version",
+			version: "1.44",
+			wantErr: ErrUnsupportedAPIVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOCKER_API_VERSION", tt.version)
+
+			cli, err := newTestDockerdClient(t, RuntimeDockerd, defaultAPITestdata)
+			if err != nil {
+				t.Fatalf("new test client: %v", err)
+			}
+			defer cli.Close()
+
+			err = cli.CheckAPIVersion()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 type testDockerdClient struct {
 	DockerdClient
 	srv *httptest.Server
@@ -547,6 +1120,12 @@ type testAPI struct {
 var routeRegexp = regexp.MustCompile(`^/v\d+\.\d+(/.*)$`)
 
 func (api testAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The ping endpoint is not versioned.
+	if r.URL.Path == "/_ping" {
+		api.handlePing(w, r)
+		return
+	}
+
 	m := routeRegexp.FindStringSubmatch(r.URL.Path)
 	if m == nil {
 		http.Error(w, "bad request", http.StatusBadRequest)
@@ -554,6 +1133,21 @@ func (api testAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	endpoint := m[1]
 
+	switch {
+	case endpoint == "/images/create" && r.Method == http.MethodPost:
+		api.handleImageCreate(w, r)
+		return
+	case endpoint == "/containers/create" && r.Method == http.MethodPost:
+		api.handleContainerCreate(w, r)
+		return
+	case strings.HasPrefix(endpoint, "/containers/"):
+		api.handleContainer(w, r, strings.TrimPrefix(endpoint, "/containers/"))
+		return
+	case strings.HasPrefix(endpoint, "/images/") && strings.HasSuffix(endpoint, "/json"):
+		fmt.Fprint(w, "{}")
+		return
+	}
+
 	if r.Method != "GET" {
 		http.Error(w, "not implemented", http.StatusNotImplemented)
 		return
@@ -572,6 +1166,18 @@ func (api testAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 type apiTestdata struct {
 	networks map[string]networkTestdata
 	system   systemTestdata
+
+	// hostUnreachable makes the containers unable to reach the
+	// host.
+	hostUnreachable bool
+
+	// hosts are the hostnames that point to the host from the
+	// containers. If nil, every hostname points to the host.
+	hosts []string
+
+	// networkInspects, if not nil, counts the network inspect
+	// requests.
+	networkInspects *atomic.Int32
 }
 
 type networkTestdata struct {
@@ -594,6 +1200,10 @@ type ipamConfig struct {
 }
 
 func (api testAPI) handleNetworks(w http.ResponseWriter, _ *http.Request, name string) {
+	if api.testdata.networkInspects != nil {
+		api.testdata.networkInspects.Add(1)
+	}
+
 	td, ok := api.testdata.networks[name]
 	if !ok {
 		http.Error(w, "not found", http.StatusNotFound)
@@ -607,16 +1217,122 @@ func (api testAPI) handleNetworks(w http.ResponseWriter, _ *http.Request, name s
 }
 
 type systemTestdata struct {
-	id string
+	id         string
+	apiVersion string
+	rootless   bool
 }
 
 type info struct {
-	ID string `json:"ID"`
+	ID              string   `json:"ID"`
+	SecurityOptions []string `json:"SecurityOptions"`
+}
+
+func (api testAPI) handlePing(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("API-Version", api.testdata.system.apiVersion)
+	fmt.Fprint(w, "OK")
 }
 
 func (api testAPI) handleInfo(w http.ResponseWriter, _ *http.Request) {
 	net := info{ID: api.testdata.system.id}
+	if api.testdata.system.rootless {
+		net.SecurityOptions = []string{"name=rootless"}
+	}
 	if err := json.NewEncoder(w).Encode(net); err != nil {
 		http.Error(w, fmt.Sprintf("marshal: %v", err), http.StatusInternalServerError)
 	}
 }
+
+// handleContainerCreate simulates the execution of the wget command
+// run by the host gateway probe. The hostname of the probed URL is
+// replaced with the loopback address if it points to the host. See
+// [apiTestdata.hosts]. The exit code of the command is
+// encoded in the ID of the returned container.
+func (api testAPI) handleContainerCreate(w http.ResponseWriter, r *http.Request) {
+	var cfg struct {
+		Cmd []string `json:"Cmd"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		http.Error(w, fmt.Sprintf("unmarshal: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(cfg.Cmd) == 0 {
+		http.Error(w, "missing command", http.StatusBadRequest)
+		return
+	}
+
+	u, err := url.Parse(cfg.Cmd[len(cfg.Cmd)-1])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("parse URL: %v", err), http.StatusBadRequest)
+		return
+	}
+	resolves := api.testdata.hosts == nil || slices.Contains(api.testdata.hosts, u.Hostname())
+
+	exitCode := 1
+	if !api.testdata.hostUnreachable && resolves {
+		u.Host = net.JoinHostPort("127.0.0.1", u.Port())
+		if resp, err := http.Get(u.String()); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				exitCode = 0
+			}
+		}
+	}
+
+	fmt.Fprintf(w, `{"Id": "exit-%v"}`, exitCode)
+}
+
+// handleImageCreate simulates an image pull. The image
+// "registry.example.com/private" requires the credentials
+// "user:pass". The images "registry.example.com/missing*" do not
+// exist and "registry.example.com/denied" fails in the middle of the
+// pull.
+func (api testAPI) handleImageCreate(w http.ResponseWriter, r *http.Request) {
+	image := r.URL.Query().Get("fromImage")
+
+	switch {
+	case image == "registry.example.com/private":
+		data, err := base64.URLEncoding.DecodeString(r.Header.Get("X-Registry-Auth"))
+		if err != nil {
+			http.Error(w, `{"message": "invalid auth"}`, http.StatusBadRequest)
+			return
+		}
+		var auth struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := json.Unmarshal(data, &auth); err != nil || auth.Username != "user" || auth.Password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "authentication required"}`)
+			return
+		}
+	case image == "registry.example.com/missing":
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "manifest unknown"}`)
+		return
+	case image == "registry.example.com/missing-stream":
+		fmt.Fprintln(w, `{"status": "Pulling from missing-stream", "id": "latest"}`)
+		fmt.Fprintln(w, `{"errorDetail": {"message": "manifest for registry.example.com/missing-stream:latest not found"}, "error": "manifest for registry.example.com/missing-stream:latest not found"}`)
+		return
+	case image == "registry.example.com/denied":
+		fmt.Fprintln(w, `{"status": "Pulling from denied", "id": "latest"}`)
+		fmt.Fprintln(w, `{"errorDetail": {"message": "denied: requested access to the resource is denied"}, "error": "denied: requested access to the resource is denied"}`)
+		return
+	}
+
+	fmt.Fprintln(w, `{"status": "Pulling from image", "id": "latest"}`)
+	fmt.Fprintln(w, `{"status": "Download complete", "id": "0123456789ab"}`)
+}
+
+func (api testAPI) handleContainer(w http.ResponseWriter, r *http.Request, path string) {
+	id, action, _ := strings.Cut(path, "/")
+	switch {
+	case r.Method == http.MethodPost && action == "start":
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && action == "wait":
+		fmt.Fprintf(w, `{"StatusCode": %v}`, strings.TrimPrefix(id, "exit-"))
+	case r.Method == http.MethodDelete && action == "":
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "not implemented", http.StatusNotImplemented)
+	}
+}
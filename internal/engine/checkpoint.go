@@ -0,0 +1,202 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// checkpointVersion is the version of the checkpoint file format. It
+// must be increased every time the format changes in a backwards
+// incompatible way.
+const checkpointVersion = "1"
+
+// checkpointInterval is the time between checkpoint writes. It is a
+// variable, so tests can modify it.
+var checkpointInterval = 30 * time.Second
+
+// ErrInvalidCheckpoint means that the checkpoint file is not valid.
+var ErrInvalidCheckpoint = errors.New("invalid checkpoint")
+
+// checkpoint is the progress of a scan stored on disk.
+type checkpoint struct {
+	// Version is the version of the checkpoint file format.
+	Version string `json:"version"`
+
+	// Checks contains the reports of the checks that finished
+	// successfully indexed by check key. See [checkKey].
+	Checks map[string]CheckReport `json:"checks"`
+}
+
+// checkKey returns an identifier of the provided check that is
+// stable across scans. Unlike the check ID, it only depends on the
+// checktype, the target and the options of the check.
+func checkKey(c check) (string, error) {
+	data, err := json.Marshal(struct {
+		Checktype string         `json:"checktype"`
+		Image     string         `json:"image"`
+		Target    string         `json:"target"`
+		AssetType string         `json:"asset_type"`
+		Options   map[string]any `json:"options"`
+	}{
+		Checktype: c.checktype.Name,
+		Image:     c.checktype.Image,
+		Target:    c.target.Identifier,
+		AssetType: string(c.target.AssetType),
+		Options:   c.options,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode check: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// checkpointer keeps the checkpoint file of a scan up to date.
+type checkpointer struct {
+	path string
+
+	// keys contains the keys of the checks of the scan indexed
+	// by check ID.
+	keys map[string]string
+
+	mu sync.Mutex
+	cp checkpoint
+}
+
+// newCheckpointer returns a [checkpointer] for the provided checks.
+// If the checkpoint file exists, the progress stored in it is
+// loaded.
+func newCheckpointer(path string, checks []check) (*checkpointer, error) {
+	cp, err := readCheckpoint(path)
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+
+	keys := make(map[string]string)
+	for _, c := range checks {
+		key, err := checkKey(c)
+		if err != nil {
+			return nil, fmt.Errorf("check key: %w", err)
+		}
+		keys[c.id] = key
+	}
+
+	cpr := &checkpointer{
+		path: path,
+		keys: keys,
+		cp:   cp,
+	}
+	return cpr, nil
+}
+
+// readCheckpoint reads the checkpoint stored in the specified file.
+// If the file does not exist, an empty checkpoint is returned.
+func readCheckpoint(path string) (checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkpoint{
+			Version: checkpointVersion,
+			Checks:  make(map[string]CheckReport),
+		}, nil
+	}
+	if err != nil {
+		return checkpoint{}, fmt.Errorf("read file: %w", err)
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return checkpoint{}, fmt.Errorf("%w: %w", ErrInvalidCheckpoint, err)
+	}
+	if cp.Version != checkpointVersion {
+		return checkpoint{}, fmt.Errorf("%w: unsupported version %q", ErrInvalidCheckpoint, cp.Version)
+	}
+	if cp.Checks == nil {
+		cp.Checks = make(map[string]CheckReport)
+	}
+	return cp, nil
+}
+
+// completed returns the report of the provided check if it finished
+// successfully in a previous scan. The returned report is updated
+// with the ID and the labels of the check.
+func (cpr *checkpointer) completed(c check) (CheckReport, bool) {
+	cpr.mu.Lock()
+	defer cpr.mu.Unlock()
+
+	r, ok := cpr.cp.Checks[cpr.keys[c.id]]
+	if !ok {
+		return CheckReport{}, false
+	}
+	r.CheckID = c.id
+	r.Labels = c.target.Labels
+	return r, true
+}
+
+// save adds the checks of the provided report that finished
+// successfully to the checkpoint and writes it to disk. The file is
+// replaced atomically, so it is always consistent even if the
+// process is killed while writing it.
+func (cpr *checkpointer) save(rep Report) error {
+	cpr.mu.Lock()
+	defer cpr.mu.Unlock()
+
+	for checkID, r := range rep {
+		key, ok := cpr.keys[checkID]
+		if !ok || r.Status != "FINISHED" {
+			continue
+		}
+		cpr.cp.Checks[key] = r
+	}
+
+	data, err := json.Marshal(cpr.cp)
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	return writeFileAtomic(cpr.path, data)
+}
+
+// remove removes the checkpoint file.
+func (cpr *checkpointer) remove() error {
+	if err := os.Remove(cpr.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to the named file. The data is written
+// to a temporary file in the same directory, which is renamed to the
+// final name once the data has been flushed to disk.
+func writeFileAtomic(name string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("write temporary file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close temporary file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), name); err != nil {
+		return fmt.Errorf("rename temporary file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
+	report "github.com/adevinta/vulcan-report"
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/engine/enginetest"
+)
+
+func TestCheckKey(t *testing.T) {
+	c := check{
+		id:        "id1",
+		checktype: checkcatalog.Checktype{Name: "checktype", Image: "image:latest"},
+		target:    config.Target{Identifier: "example.com", AssetType: types.DomainName},
+		options:   map[string]any{"a": 1, "b": "two"},
+	}
+
+	key, err := checkKey(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	other := c
+	other.id = "id2"
+	other.target.Labels = map[string]string{"env": "prod"}
+	otherKey, err := checkKey(other)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != otherKey {
+		t.Errorf("key depends on check ID or labels: %v != %v", key, otherKey)
+	}
+
+	other = c
+	other.options = map[string]any{"a": 2, "b": "two"}
+	otherKey, err = checkKey(other)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key == otherKey {
+		t.Errorf("key does not depend on options: %v", key)
+	}
+}
+
+func TestCheckpointer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	checks := []check{
+		{
+			id:        "id1",
+			checktype: checkcatalog.Checktype{Name: "checktype1"},
+			target:    config.Target{Identifier: "example.com", AssetType: types.DomainName},
+		},
+		{
+			id:        "id2",
+			checktype: checkcatalog.Checktype{Name: "checktype2"},
+			target:    config.Target{Identifier: "example.com", AssetType: types.DomainName},
+		},
+	}
+
+	cpr, err := newCheckpointer(path, checks)
+	if err != nil {
+		t.Fatalf("new checkpointer: %v", err)
+	}
+
+	rep := Report{
+		"id1": {Report: report.Report{CheckData: report.CheckData{CheckID: "id1", ChecktypeName: "checktype1", Status: "FINISHED"}}},
+		"id2": {Report: report.Report{CheckData: report.CheckData{CheckID: "id2", ChecktypeName: "checktype2", Status: "FAILED"}}},
+	}
+	if err := cpr.save(rep); err != nil {
+		t.Fatalf("save checkpoint: %v", err)
+	}
+
+	// Check IDs change across scans.
+	checks[0].id = "newid1"
+	checks[0].target.Labels = map[string]string{"env": "prod"}
+	checks[1].id = "newid2"
+
+	cpr, err = newCheckpointer(path, checks)
+	if err != nil {
+		t.Fatalf("new checkpointer: %v", err)
+	}
+
+	got, ok := cpr.completed(checks[0])
+	if !ok {
+		t.Fatalf("check not completed")
+	}
+	want := CheckReport{
+		Report: report.Report{CheckData: report.CheckData{CheckID: "newid1", ChecktypeName: "checktype1", Status: "FINISHED"}},
+		Labels: map[string]string{"env": "prod"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%v", diff)
+	}
+
+	if _, ok := cpr.completed(checks[1]); ok {
+		t.Errorf("failed check is completed")
+	}
+
+	if err := cpr.remove(); err != nil {
+		t.Fatalf("remove checkpoint: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint was not removed: %v", err)
+	}
+}
+
+func TestNewCheckpointer_invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := os.WriteFile(path, []byte(`{"version": "0"}`), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if _, err := newCheckpointer(path, nil); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Errorf("unexpected error: got: %v, want: %v", err, ErrInvalidCheckpoint)
+	}
+}
+
+func TestEngine_Run_checkpoint(t *testing.T) {
+	var (
+		checktypeURLs = []config.ChecktypeURL{{URL: "testdata/engine/checktypes_lava_engine_test.json"}}
+		targets       = []config.Target{
+			{
+				Identifier: "https://192.0.2.1",
+				AssetType:  types.WebAddress,
+			},
+			{
+				Identifier: "https://192.0.2.2",
+				AssetType:  types.WebAddress,
+			},
+		}
+		checkpointFile = filepath.Join(t.TempDir(), "checkpoint.json")
+		agentConfig    = config.AgentConfig{Checkpoint: checkpointFile}
+	)
+
+	// The first scan is interrupted before the check against the
+	// second target finishes.
+	rt := &enginetest.Runtime{
+		ReportFunc: func(params backend.RunParams) report.Report {
+			if params.Target == targets[1].Identifier {
+				return report.Report{CheckData: report.CheckData{Status: "RUNNING"}}
+			}
+			return report.Report{}
+		},
+	}
+	eng, err := NewWithRuntime(rt, agentConfig, checktypeURLs)
+	if err != nil {
+		t.Fatalf("engine initialization error: %v", err)
+	}
+	defer eng.Close()
+
+	if _, err := eng.Run(targets); err != nil {
+		t.Fatalf("engine run error: %v", err)
+	}
+
+	if _, err := os.Stat(checkpointFile); err != nil {
+		t.Fatalf("checkpoint not found: %v", err)
+	}
+
+	// The second scan only runs the pending check.
+	rt = &enginetest.Runtime{}
+	eng, err = NewWithRuntime(rt, agentConfig, checktypeURLs)
+	if err != nil {
+		t.Fatalf("engine initialization error: %v", err)
+	}
+	defer eng.Close()
+
+	engineReport, err := eng.Run(targets)
+	if err != nil {
+		t.Fatalf("engine run error: %v", err)
+	}
+
+	runs := rt.Runs()
+	if len(runs) != 1 {
+		t.Fatalf("unexpected number of runs: %v", len(runs))
+	}
+	if runs[0].Params.Target != targets[1].Identifier {
+		t.Errorf("unexpected target: got: %v, want: %v", runs[0].Params.Target, targets[1].Identifier)
+	}
+
+	if len(engineReport) != 2 {
+		t.Fatalf("unexpected number of reports: %v", len(engineReport))
+	}
+	for checkID, r := range engineReport {
+		if r.CheckID != checkID {
+			t.Errorf("unexpected check ID: got: %v, want: %v", r.CheckID, checkID)
+		}
+		if r.Status != "FINISHED" {
+			t.Errorf("unexpected status: %v", r.Status)
+		}
+	}
+
+	if _, err := os.Stat(checkpointFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint was not removed: %v", err)
+	}
+}
+
+func TestEngine_RunContext_pause(t *testing.T) {
+	var (
+		checktypeURLs = []config.ChecktypeURL{{URL: "testdata/engine/checktypes_lava_engine_test.json"}}
+		targets       = []config.Target{
+			{
+				Identifier: "https://192.0.2.1",
+				AssetType:  types.WebAddress,
+			},
+			{
+				Identifier: "https://192.0.2.2",
+				AssetType:  types.WebAddress,
+			},
+		}
+		checkpointFile = filepath.Join(t.TempDir(), "checkpoint.json")
+		agentConfig    = config.AgentConfig{Checkpoint: checkpointFile}
+	)
+
+	// The scan is paused while the first check is running. The
+	// checks are run one at a time, so the second check is never
+	// started.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rt := &enginetest.Runtime{
+		ReportFunc: func(params backend.RunParams) report.Report {
+			cancel()
+			return report.Report{}
+		},
+	}
+	eng, err := NewWithRuntime(rt, agentConfig, checktypeURLs)
+	if err != nil {
+		t.Fatalf("engine initialization error: %v", err)
+	}
+	defer eng.Close()
+
+	engineReport, err := eng.RunContext(ctx, targets)
+	if err != nil {
+		t.Fatalf("engine run error: %v", err)
+	}
+
+	if n := len(rt.Runs()); n != 1 {
+		t.Fatalf("unexpected number of runs: %v", n)
+	}
+	if len(engineReport) != 1 {
+		t.Fatalf("unexpected number of reports: %v", len(engineReport))
+	}
+
+	cp, err := readCheckpoint(checkpointFile)
+	if err != nil {
+		t.Fatalf("could not read checkpoint: %v", err)
+	}
+	if len(cp.Checks) != 1 {
+		t.Errorf("unexpected number of checks in checkpoint: %v", len(cp.Checks))
+	}
+}
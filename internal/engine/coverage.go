@@ -0,0 +1,69 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"slices"
+
+	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/checktypes"
+	"github.com/adevinta/lava/internal/config"
+)
+
+// Coverage describes which checktypes and targets take part in a
+// scan. It allows to detect checktypes that are never run and
+// targets that are never scanned.
+type Coverage struct {
+	// UnusedChecktypes are the names of the checktypes that do
+	// not accept any of the targets. They are sorted by name.
+	UnusedChecktypes []string
+
+	// UnmatchedTargets are the targets that are not accepted by
+	// any checktype. Duplicated targets are only included once.
+	// They are in the order they were specified.
+	UnmatchedTargets []config.Target
+}
+
+// Coverage returns the coverage of a scan of the provided targets.
+// No check is run. Checks that would be skipped at run time, for
+// instance because they require Internet access, are considered
+// part of the scan.
+func (eng Engine) Coverage(targets []config.Target) Coverage {
+	return mkCoverage(eng.catalog, targets)
+}
+
+// mkCoverage calculates the coverage of a scan of the provided
+// targets using the specified catalog. It is based on the checks
+// generated by [generateChecks], so the checktypes selected by every
+// target are honored. Targets whose checks were removed for being
+// duplicated are considered matched by the checks of the equivalent
+// target. See [dedupChecks].
+func mkCoverage(catalog checktypes.Catalog, targets []config.Target) Coverage {
+	ts, _ := dedupTargets(targets)
+	checks := generateChecks(catalog, ts)
+
+	used := make(map[string]bool)
+	for _, c := range checks {
+		used[c.checktype.Name] = true
+	}
+
+	var cov Coverage
+	for _, t := range ts {
+		matched := slices.ContainsFunc(checks, func(c check) bool {
+			return c.target.AssetType == t.AssetType &&
+				assettypes.Canonical(t.AssetType, c.target.Identifier) == assettypes.Canonical(t.AssetType, t.Identifier)
+		})
+		if !matched {
+			cov.UnmatchedTargets = append(cov.UnmatchedTargets, t)
+		}
+	}
+
+	for name := range catalog {
+		if !used[name] {
+			cov.UnusedChecktypes = append(cov.UnusedChecktypes, name)
+		}
+	}
+	slices.Sort(cov.UnusedChecktypes)
+
+	return cov
+}
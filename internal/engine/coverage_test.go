@@ -0,0 +1,124 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"testing"
+
+	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/checktypes"
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestMkCoverage(t *testing.T) {
+	catalog := checktypes.Catalog{
+		"checktype1": {
+			Checktype: checkcatalog.Checktype{
+				Name:   "checktype1",
+				Assets: []string{"DomainName"},
+			},
+		},
+		"checktype2": {
+			Checktype: checkcatalog.Checktype{
+				Name:   "checktype2",
+				Assets: []string{"Hostname", "DomainName"},
+			},
+		},
+		"checktype3": {
+			Checktype: checkcatalog.Checktype{
+				Name:   "checktype3",
+				Assets: []string{"DockerImage"},
+			},
+		},
+		"checktype4": {
+			Checktype: checkcatalog.Checktype{
+				Name:   "checktype4",
+				Assets: []string{"GitRepository"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		targets []config.Target
+		want    Coverage
+	}{
+		{
+			name: "full coverage",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName},
+				{Identifier: "alpine:latest", AssetType: types.DockerImage},
+				{Identifier: ".", AssetType: assettypes.Path},
+			},
+			want: Coverage{},
+		},
+		{
+			name: "unused checktypes",
+			targets: []config.Target{
+				{Identifier: "www.example.com", AssetType: types.Hostname},
+			},
+			want: Coverage{
+				UnusedChecktypes: []string{"checktype1", "checktype3", "checktype4"},
+			},
+		},
+		{
+			name: "unmatched targets",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName},
+				{Identifier: "192.0.2.1", AssetType: types.IP},
+				{Identifier: "192.0.2.1", AssetType: types.IP},
+				{Identifier: "https://example.com", AssetType: types.WebAddress},
+			},
+			want: Coverage{
+				UnusedChecktypes: []string{"checktype3", "checktype4"},
+				UnmatchedTargets: []config.Target{
+					{Identifier: "192.0.2.1", AssetType: types.IP},
+					{Identifier: "https://example.com", AssetType: types.WebAddress},
+				},
+			},
+		},
+		{
+			name: "selected checktypes",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName, Include: []string{"checktype1"}},
+				{Identifier: "alpine:latest", AssetType: types.DockerImage, Exclude: []string{"checktype3"}},
+			},
+			want: Coverage{
+				UnusedChecktypes: []string{"checktype2", "checktype3", "checktype4"},
+				UnmatchedTargets: []config.Target{
+					{Identifier: "alpine:latest", AssetType: types.DockerImage, Exclude: []string{"checktype3"}},
+				},
+			},
+		},
+		{
+			name: "duplicated checks",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName},
+				{Identifier: "EXAMPLE.com", AssetType: types.DomainName},
+			},
+			want: Coverage{
+				UnusedChecktypes: []string{"checktype3", "checktype4"},
+			},
+		},
+		{
+			name:    "no targets",
+			targets: nil,
+			want: Coverage{
+				UnusedChecktypes: []string{"checktype1", "checktype2", "checktype3", "checktype4"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mkCoverage(catalog, tt.targets)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("coverage mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
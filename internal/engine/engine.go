@@ -6,10 +6,18 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"math"
 	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/adevinta/vulcan-agent/agent"
@@ -19,77 +27,319 @@ import (
 	"github.com/adevinta/vulcan-agent/jobrunner"
 	"github.com/adevinta/vulcan-agent/queue"
 	"github.com/adevinta/vulcan-agent/queue/chanqueue"
+	"github.com/adevinta/vulcan-agent/stateupdater"
 	report "github.com/adevinta/vulcan-report"
 	types "github.com/adevinta/vulcan-types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
 
 	"github.com/adevinta/lava/internal/checktypes"
 	"github.com/adevinta/lava/internal/config"
 	"github.com/adevinta/lava/internal/containers"
 	"github.com/adevinta/lava/internal/metrics"
+	"github.com/adevinta/lava/internal/urlutil"
 )
 
 // Report is a collection of reports returned by Vulcan checks and
-// indexed by check ID.
-type Report map[string]report.Report
+// indexed by check ID. It contains the full reports sent by the
+// checks, so callers can implement their own output formats and
+// gating logic on top of it.
+type Report map[string]CheckReport
+
+// CheckReport is the report returned by a Vulcan check along with
+// the metadata of the scanned target. The embedded report follows the
+// schema defined by [report.Report], which includes the check status,
+// the target, the execution times and the found vulnerabilities.
+// Targets served by Lava, like local paths or Git repositories, are
+// replaced with the original targets.
+type CheckReport struct {
+	report.Report
+
+	// Labels are the labels of the scanned target.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Logs are the logs written by the check. They are only set
+	// for the checks that did not finish successfully and are
+	// truncated according to [config.AgentConfig.MaxLogSize].
+	Logs string `json:"logs,omitempty"`
+}
 
 // Engine represents a Lava engine able to run Vulcan checks and
 // retrieve the generated reports.
+//
+// An Engine can be used to run several batches of targets. The Docker
+// client, the checktype catalog, the agent configuration and the
+// observers are shared by all the runs. The agent API listener, the
+// target server and the report store hold per-run state, so they are
+// created for every run.
 type Engine struct {
-	cli     containers.DockerdClient
-	catalog checktypes.Catalog
-	cfg     agentconfig.Config
-	runtime containers.Runtime
+	cli         Runtime
+	catalog     checktypes.Catalog
+	cfg         agentconfig.Config
+	listenAddr  string
+	obs         *observerSet
+	maxFindings int
+	maxLogSize  int
+	probeURL    string
+	overrides   map[string]config.CommandOverride
+	catalogErrs []checktypes.SourceError
+	retry       *config.RetryConfig
+	checkpoint  string
+	reportsDir  string
+	privileges  map[string]config.Privileges
+	noTimeout   bool
+	grace       int
+
+	// unsetAPIVersion is true if the DOCKER_API_VERSION
+	// environment variable was set by [New], so it must be unset
+	// by [Engine.Close].
+	unsetAPIVersion bool
 }
 
-// New returns a new [Engine].
-func New(cfg config.AgentConfig, checktypeURLs []string) (eng Engine, err error) {
+// defaultMaxFindings is the default maximum number of findings
+// accepted per check.
+const defaultMaxFindings = 10000
+
+// defaultMaxLogSize is the default maximum number of bytes of logs
+// stored per check.
+const defaultMaxLogSize = 1 << 20
+
+// New returns a new [Engine]. The checks are run using the container
+// runtime specified by the environment. See
+// [containers.GetenvRuntime].
+func New(cfg config.AgentConfig, checktypeURLs []config.ChecktypeURL) (eng Engine, err error) {
 	rt, err := containers.GetenvRuntime()
 	if err != nil {
 		return Engine{}, fmt.Errorf("get env runtime: %w", err)
 	}
 
+	// The Vulcan agent creates its own Docker client, which only
+	// honors the DOCKER_API_VERSION environment variable. Like in
+	// the Docker CLI, the environment variable takes precedence.
+	// The variable is unset by [Engine.Close] or if the engine
+	// cannot be created.
+	setAPIVersion := false
+	if cfg.DockerAPIVersion != "" && os.Getenv(client.EnvOverrideAPIVersion) == "" {
+		if err := os.Setenv(client.EnvOverrideAPIVersion, cfg.DockerAPIVersion); err != nil {
+			return Engine{}, fmt.Errorf("set Docker API version: %w", err)
+		}
+		setAPIVersion = true
+		defer func() {
+			if err != nil {
+				os.Unsetenv(client.EnvOverrideAPIVersion)
+			}
+		}()
+	}
+
 	cli, err := containers.NewDockerdClient(rt)
 	if err != nil {
 		return Engine{}, fmt.Errorf("new dockerd client: %w", err)
 	}
 
-	catalog, err := checktypes.NewCatalog(checktypeURLs)
+	if err := cli.CheckAPIVersion(); err != nil {
+		return Engine{}, fmt.Errorf("check Docker API version: %w", err)
+	}
+
+	if err := cli.SetNetwork(cfg.Network); err != nil {
+		return Engine{}, fmt.Errorf("set network: %w", err)
+	}
+
+	drt := dockerdRuntime{
+		DockerdClient:  &cli,
+		snapshotFailed: cfg.SnapshotFailedChecks,
+	}
+
+	// Checks send their reports to the host. If it is not
+	// reachable, the checks run but no report is received. So,
+	// the probe uses the same address and hostname as the
+	// checks.
+	if cfg.GatewayProbe != "" {
+		addr, err := reportListenAddr(drt, cfg.ReportAddr)
+		if err != nil {
+			return Engine{}, fmt.Errorf("get report listen address: %w", err)
+		}
+		if err := cli.ProbeHostGateway(cfg.GatewayProbe, addr, cfg.ReportHost); err != nil {
+			if errors.Is(err, containers.ErrHostGatewayUnreachable) {
+				slog.Error("checks cannot reach the Lava host", "hint", "review the firewall rules of the host and the agent network")
+			}
+			return Engine{}, fmt.Errorf("probe host gateway: %w", err)
+		}
+	}
+
+	eng, err = NewWithRuntime(drt, cfg, checktypeURLs)
+	if err != nil {
+		return Engine{}, err
+	}
+	eng.unsetAPIVersion = setAPIVersion
+	return eng, nil
+}
+
+// NewWithRuntime returns a new [Engine] that runs the checks using
+// the provided [Runtime]. The network of the agent configuration is
+// ignored, so the runtime must be already configured. The runtime is
+// closed by [Engine.Close].
+func NewWithRuntime(cli Runtime, cfg config.AgentConfig, checktypeURLs []config.ChecktypeURL) (eng Engine, err error) {
+	catalog, catalogErrs, err := newCatalog(checktypeURLs, cfg.PartialCatalogs)
 	if err != nil {
 		return Engine{}, fmt.Errorf("get checkype catalog: %w", err)
 	}
 
+	if err := rewriteImages(catalog, cfg.ImageTemplate); err != nil {
+		return Engine{}, fmt.Errorf("rewrite images: %w", err)
+	}
+
+	if err := catalog.Validate(); err != nil {
+		if cfg.ValidateCatalogs {
+			return Engine{}, fmt.Errorf("validate checktype catalog: %w", err)
+		}
+		slog.Warn("checktype catalog contains invalid checktypes", "err", err)
+	}
+
 	metrics.Collect("checktypes", catalog)
 
-	agentCfg, err := newAgentConfig(cli, cfg)
+	listenAddr, err := reportListenAddr(cli, cfg.ReportAddr)
 	if err != nil {
-		return Engine{}, fmt.Errorf("get agent config: %w", err)
+		return Engine{}, fmt.Errorf("get report listen address: %w", err)
+	}
+
+	noTimeout, err := getenvNoTimeout()
+	if err != nil {
+		return Engine{}, err
+	}
+
+	agentCfg := newAgentConfig(cli, cfg)
+
+	maxFindings := cfg.MaxFindings
+	if maxFindings == 0 {
+		maxFindings = defaultMaxFindings
+	}
+
+	maxLogSize := cfg.MaxLogSize
+	if maxLogSize == 0 {
+		maxLogSize = defaultMaxLogSize
 	}
 
 	eng = Engine{
-		cli:     cli,
-		catalog: catalog,
-		cfg:     agentCfg,
-		runtime: rt,
+		cli:         cli,
+		catalog:     catalog,
+		cfg:         agentCfg,
+		listenAddr:  listenAddr,
+		obs:         &observerSet{},
+		maxFindings: maxFindings,
+		maxLogSize:  maxLogSize,
+		probeURL:    cfg.InternetProbe,
+		overrides:   cfg.Overrides,
+		catalogErrs: catalogErrs,
+		retry:       cfg.Retry,
+		checkpoint:  cfg.Checkpoint,
+		reportsDir:  cfg.ReportsDir,
+		privileges:  cfg.Privileges,
+		noTimeout:   noTimeout,
+		grace:       cfg.GracePeriod,
 	}
 	return eng, nil
 }
 
-// newAgentConfig creates a new [agentconfig.Config] based on the
-// provided Vulcan agent configuration.
-func newAgentConfig(cli containers.DockerdClient, cfg config.AgentConfig) (agentconfig.Config, error) {
-	listenHost, err := cli.HostGatewayInterfaceAddr()
+// reportListenAddr returns the address where the engine listens for
+// the reports of the checks. The empty parts of the provided address
+// are replaced with the address of the interface that is reachable
+// from the checks and a random port.
+func reportListenAddr(cli Runtime, addr string) (string, error) {
+	var host, port string
+	if addr != "" {
+		var err error
+		if host, port, err = net.SplitHostPort(addr); err != nil {
+			return "", fmt.Errorf("split host port: %w", err)
+		}
+	}
+
+	if host == "" {
+		gwaddr, err := cli.HostGatewayInterfaceAddr()
+		if err != nil {
+			return "", fmt.Errorf("get gateway interface address: %w", err)
+		}
+		host = gwaddr
+	}
+
+	if port == "" {
+		port = "0"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// newCatalog retrieves the specified checktype catalogs. If partial
+// is true, the catalogs that cannot be retrieved are logged and
+// returned instead of making it fail. See
+// [checktypes.NewPartialCatalog].
+func newCatalog(urls []config.ChecktypeURL, partial bool) (checktypes.Catalog, []checktypes.SourceError, error) {
+	if !partial {
+		catalog, err := checktypes.NewCatalog(urls)
+		return catalog, nil, err
+	}
+
+	catalog, srcErrs, err := checktypes.NewPartialCatalog(urls)
 	if err != nil {
-		return agentconfig.Config{}, fmt.Errorf("get gateway interface address: %w", err)
+		return nil, nil, err
 	}
 
+	var failed []string
+	for _, srcErr := range srcErrs {
+		slog.Warn("could not retrieve checktype catalog", "url", srcErr.URL, "err", srcErr.Err)
+		failed = append(failed, srcErr.URL)
+	}
+	if len(failed) > 0 {
+		metrics.Collect("failed_checktype_urls", failed)
+	}
+	return catalog, srcErrs, nil
+}
+
+// CatalogErrors returns the checktype catalogs that could not be
+// retrieved when the engine was created. It is always empty unless
+// partial catalogs are allowed by the agent configuration.
+func (eng Engine) CatalogErrors() []checktypes.SourceError {
+	return eng.catalogErrs
+}
+
+// rewriteImages rewrites the image references of the checktypes in
+// the provided catalog using the specified template. References to
+// environment variables in the template variables are expanded. If
+// the template is nil, the catalog is not modified.
+func rewriteImages(catalog checktypes.Catalog, it *config.ImageTemplate) error {
+	if it == nil {
+		return nil
+	}
+
+	vars := make(map[string]string)
+	for k, v := range it.Vars {
+		vars[k] = os.ExpandEnv(v)
+	}
+	return catalog.RewriteImages(it.Template, vars)
+}
+
+// Observe registers an observer that is called every time a check
+// sends its report. Like in the report returned by [Engine.Run], the
+// targets served by Lava are replaced with the original targets and
+// the labels of the targets are set. Observers are called
+// concurrently and do not block the engine. If an observer cannot
+// keep up, some reports may not be delivered to it. The returned
+// function unregisters the observer and waits for the queued reports
+// to be delivered.
+func (eng Engine) Observe(fn ObserverFunc) (unregister func()) {
+	return eng.obs.add(fn)
+}
+
+// newAgentConfig creates a new [agentconfig.Config] based on the
+// provided Vulcan agent configuration. The listener of the agent API
+// is not set, given that it must be created for every run.
+func newAgentConfig(cli Runtime, cfg config.AgentConfig) agentconfig.Config {
 	parallel := cfg.Parallel
 	if parallel == 0 {
 		parallel = 1
 	}
 
-	ln, err := net.Listen("tcp", net.JoinHostPort(listenHost, "0"))
-	if err != nil {
-		return agentconfig.Config{}, fmt.Errorf("listen: %w", err)
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
 	}
 
 	auths := []agentconfig.Auth{}
@@ -101,16 +351,20 @@ func newAgentConfig(cli containers.DockerdClient, cfg config.AgentConfig) (agent
 		})
 	}
 
+	reportHost := cfg.ReportHost
+	if reportHost == "" {
+		reportHost = cli.HostGatewayHostname()
+	}
+
 	acfg := agentconfig.Config{
 		Agent: agentconfig.AgentConfig{
 			ConcurrentJobs:         parallel,
-			MaxNoMsgsInterval:      5,   // Low as all the messages will be in the queue before starting the agent.
-			MaxProcessMessageTimes: 1,   // No retry.
-			Timeout:                180, // Default timeout of 3 minutes.
+			MaxNoMsgsInterval:      5, // Low as all the messages will be in the queue before starting the agent.
+			MaxProcessMessageTimes: 1, // No retry.
+			Timeout:                timeout,
 		},
 		API: agentconfig.APIConfig{
-			Host:     cli.HostGatewayHostname(),
-			Listener: ln,
+			Host: reportHost,
 		},
 		Check: agentconfig.CheckConfig{
 			Vars: cfg.Vars,
@@ -127,11 +381,18 @@ func newAgentConfig(cli containers.DockerdClient, cfg config.AgentConfig) (agent
 			},
 		},
 	}
-	return acfg, nil
+	return acfg
 }
 
-// Close releases the internal resources used by the Lava engine.
+// Close releases the internal resources used by the Lava engine. It
+// also unsets the DOCKER_API_VERSION environment variable if it was
+// set by [New].
 func (eng Engine) Close() error {
+	if eng.unsetAPIVersion {
+		if err := os.Unsetenv(client.EnvOverrideAPIVersion); err != nil {
+			return fmt.Errorf("unset Docker API version: %w", err)
+		}
+	}
 	if err := eng.cli.Close(); err != nil {
 		return fmt.Errorf("close dockerd client: %w", err)
 	}
@@ -141,27 +402,187 @@ func (eng Engine) Close() error {
 // Run runs vulcan checks and returns the generated report. The check
 // list is based on the configured checktype catalogs and the provided
 // targets. These checks are run by a Vulcan agent, which is
-// configured using the specified configuration.
+// configured using the specified configuration. Failed checks are
+// retried according to the configured retry policy.
+//
+// If a checkpoint file is configured, the checks that finished
+// successfully in a previous interrupted scan are not run again and
+// their stored reports are returned instead. The checkpoint is
+// removed once all the checks have finished.
 func (eng Engine) Run(targets []config.Target) (Report, error) {
-	jobs, err := generateJobs(eng.catalog, targets)
+	return eng.RunContext(context.Background(), targets)
+}
+
+// RunContext is like [Engine.Run] but allows to pause the scan. When
+// the provided context is done, no more checks are started and the
+// engine waits for the running checks to finish. Then, the
+// checkpoint is saved, if configured, and the report of the checks
+// that have been run is returned. The failed checks of a paused scan
+// are not retried.
+func (eng Engine) RunContext(ctx context.Context, targets []config.Target) (Report, error) {
+	checks, skipped := eng.filterChecks(generateChecks(eng.catalog, targets))
+
+	rep := make(Report)
+	for _, c := range skipped {
+		rep[c.id] = skippedReport(c)
+	}
+
+	var cpr *checkpointer
+	if eng.checkpoint != "" {
+		var err error
+		if cpr, err = newCheckpointer(eng.checkpoint, checks); err != nil {
+			return nil, fmt.Errorf("new checkpointer: %w", err)
+		}
+
+		var pending []check
+		for _, c := range checks {
+			if r, ok := cpr.completed(c); ok {
+				rep[c.id] = r
+				continue
+			}
+			pending = append(pending, c)
+		}
+		if len(pending) != len(checks) {
+			slog.Info("resuming scan from checkpoint", "completed", len(checks)-len(pending), "pending", len(pending))
+		}
+		checks = pending
+	}
+
+	eng.disableTimeouts(checks)
+	eng.addGracePeriods(checks)
+
+	jobs, err := generateJobs(checks, eng.cfg.Agent.Timeout)
 	if err != nil {
 		return nil, fmt.Errorf("generate jobs: %w", err)
 	}
 
-	if len(jobs) == 0 {
+	if len(jobs) > 0 {
+		creds := checkCredentials(checks)
+		agentRep, err := eng.runAgent(ctx, jobs, creds, cpr)
+		if err != nil {
+			return nil, err
+		}
+
+		if eng.retry != nil && ctx.Err() == nil {
+			if agentRep, err = eng.retryFailed(ctx, agentRep, jobs, creds, cpr); err != nil {
+				return nil, fmt.Errorf("retry failed checks: %w", err)
+			}
+		}
+		maps.Copy(rep, agentRep)
+	}
+
+	if cpr != nil && completed(rep, jobs) {
+		if err := cpr.remove(); err != nil {
+			return nil, fmt.Errorf("remove checkpoint: %w", err)
+		}
+	}
+
+	if len(jobs) == 0 && len(rep) == 0 {
 		return nil, nil
 	}
+	return rep, nil
+}
+
+// completed reports whether all the provided jobs have a report with
+// a terminal status in the provided report.
+func completed(rep Report, jobs []jobrunner.Job) bool {
+	for _, job := range jobs {
+		r, ok := rep[job.CheckID]
+		if !ok {
+			return false
+		}
+		if _, ok := stateupdater.TerminalStatuses[r.Status]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// StatusSkipped is the status of the checks that are not run because
+// the environment does not meet their requirements.
+const StatusSkipped = "SKIPPED"
+
+// probeTimeout is the timeout of the Internet reachability probe.
+const probeTimeout = 5 * time.Second
+
+// filterChecks splits the provided checks into the checks that can be
+// run and the checks that must be skipped because the environment
+// does not meet their network requirements.
+func (eng Engine) filterChecks(checks []check) (run, skipped []check) {
+	if eng.probeURL == "" || isReachable(eng.probeURL) {
+		return checks, nil
+	}
+
+	for _, c := range checks {
+		if eng.catalog[c.checktype.Name].Network == checktypes.NetworkInternet {
+			slog.Warn("Internet is not reachable, skipping check", "checktype", c.checktype.Name, "target", c.target.Identifier)
+			skipped = append(skipped, c)
+			continue
+		}
+		run = append(run, c)
+	}
+	return run, skipped
+}
+
+// isReachable reports whether the provided URL can be reached. The
+// probe is sent with [urlutil.DefaultClient], so it honors the
+// configured CA bundle and proxy.
+func isReachable(url string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		slog.Debug("reachability probe failed", "url", url, "err", err)
+		return false
+	}
+	resp, err := urlutil.DefaultClient.Do(req)
+	if err != nil {
+		slog.Debug("reachability probe failed", "url", url, "err", err)
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
 
-	return eng.runAgent(jobs)
+// skippedReport returns the report of a skipped check.
+func skippedReport(c check) CheckReport {
+	return CheckReport{
+		Report: report.Report{
+			CheckData: report.CheckData{
+				CheckID:       c.id,
+				ChecktypeName: c.checktype.Name,
+				Target:        c.target.Identifier,
+				Status:        StatusSkipped,
+			},
+		},
+		Labels: c.target.Labels,
+	}
 }
 
 // summaryInterval is the time between summary logs.
 const summaryInterval = 15 * time.Second
 
 // runAgent creates a Vulcan agent using the configured Vulcan agent
-// config and uses it to run the provided jobs.
-func (eng Engine) runAgent(jobs []jobrunner.Job) (Report, error) {
-	srv, err := newTargetServer(eng.runtime)
+// config and uses it to run the provided jobs. The provided
+// credentials, indexed by check ID, are passed to the corresponding
+// checks. If the provided [checkpointer] is not nil, the progress of
+// the scan is saved periodically and when the agent finishes. When
+// the provided context is done, the agent stops starting checks and
+// waits for the running ones to finish.
+func (eng Engine) runAgent(ctx context.Context, jobs []jobrunner.Job, creds map[string]config.Credentials, cpr *checkpointer) (Report, error) {
+	// The agent shuts down its API server when it finishes,
+	// which closes the listener. So, a new one is created for
+	// every run.
+	ln, err := net.Listen("tcp", eng.listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+
+	acfg := eng.cfg
+	acfg.API.Listener = ln
+
+	srv, err := newTargetServer(eng.cli)
 	if err != nil {
 		return nil, fmt.Errorf("new target server: %w", err)
 	}
@@ -170,32 +591,49 @@ func (eng Engine) runAgent(jobs []jobrunner.Job) (Report, error) {
 	alogger := newAgentLogger(slog.Default())
 
 	br := func(params backend.RunParams, rc *docker.RunConfig) error {
-		return eng.beforeRun(params, rc, srv)
+		return eng.beforeRun(params, rc, srv, creds)
 	}
 
-	backend, err := docker.NewBackend(alogger, eng.cfg, br)
+	backend, err := eng.cli.NewBackend(alogger, acfg, br)
 	if err != nil {
-		return nil, fmt.Errorf("new Docker backend: %w", err)
+		return nil, fmt.Errorf("new backend: %w", err)
+	}
+	if signaler, ok := eng.cli.(checkSignaler); ok {
+		backend = eng.newGraceBackend(backend, signaler)
 	}
 
 	// Create a state queue and discard all messages.
 	stateQueue := chanqueue.New(queue.Discard())
 	stateQueue.StartReading(context.Background())
 
-	jobsQueue := chanqueue.New(nil)
+	jobsQueue := pausableQueue{ChanQueue: chanqueue.New(nil), ctx: ctx}
 	if err := sendJobs(jobs, jobsQueue); err != nil {
 		return nil, fmt.Errorf("send jobs: %w", err)
 	}
 
-	rs := &reportStore{}
+	labels := jobLabels(jobs)
+	rs := &reportStore{
+		obs: eng.obs,
+		checkReport: func(checkID string, r report.Report) CheckReport {
+			return mkCheckReport(srv, labels, checkID, r)
+		},
+		maxFindings: eng.maxFindings,
+		maxLogSize:  eng.maxLogSize,
+		dir:         eng.reportsDir,
+	}
 
 	done := make(chan bool)
+	defer close(done)
 	go func() {
 		for {
 			select {
 			case <-done:
 				return
 			case <-time.After(summaryInterval):
+				completed, avg := rs.Progress()
+				pct, eta := estimateProgress(len(jobs), completed, avg, eng.cfg.Agent.ConcurrentJobs)
+				slog.Info("scan progress", "completed", completed, "total", len(jobs), "percent", fmt.Sprintf("%.0f%%", pct), "eta", eta)
+
 				sums := rs.Summary()
 				if len(sums) == 0 {
 					slog.Info("waiting for updates")
@@ -208,45 +646,132 @@ func (eng Engine) runAgent(jobs []jobrunner.Job) (Report, error) {
 		}
 	}()
 
-	exitCode := agent.RunWithQueues(eng.cfg, rs, backend, stateQueue, jobsQueue, alogger)
+	// The checkpoint goroutine is waited for, so it does not
+	// save the checkpoint after it has been removed.
+	var cpWg sync.WaitGroup
+	cpDone := make(chan bool)
+	defer cpWg.Wait()
+	defer close(cpDone)
+	if cpr != nil {
+		cpWg.Add(1)
+		go func() {
+			defer cpWg.Done()
+			for {
+				select {
+				case <-cpDone:
+					return
+				case <-time.After(checkpointInterval):
+					// The reports are copied under the
+					// lock of the store, so the agent can
+					// keep uploading reports while the
+					// checkpoint is saved.
+					if err := cpr.save(eng.mkReport(srv, rs.Reports(), jobs)); err != nil {
+						slog.Error("could not save checkpoint", "err", err)
+					}
+				}
+			}
+		}()
+	}
+
+	exitCode := agent.RunWithQueues(acfg, rs, backend, stateQueue, jobsQueue, alogger)
 	if exitCode != 0 {
 		return nil, fmt.Errorf("run agent: exit code %v", exitCode)
 	}
 
-	done <- true
+	rep := eng.mkReport(srv, rs.Reports(), jobs)
+
+	// The logs are only kept for the checks that did not finish
+	// successfully, which are the ones worth investigating.
+	for checkID, r := range rep {
+		if r.Status == "FINISHED" {
+			continue
+		}
+		if logs, ok := rs.Logs(checkID); ok {
+			r.Logs = string(logs)
+			rep[checkID] = r
+		}
+	}
+
+	if cpr != nil {
+		if err := cpr.save(rep); err != nil {
+			slog.Error("could not save checkpoint", "err", err)
+		}
+	}
+
+	return rep, nil
+}
+
+// estimateProgress returns the percentage of completed checks and the
+// estimated time to complete the remaining ones. The estimation is
+// based on the average duration of the completed checks and the
+// number of checks that run in parallel. If there is no data to
+// estimate the remaining time, the returned duration is zero.
+func estimateProgress(total, done int, avg time.Duration, parallel int) (pct float64, eta time.Duration) {
+	if total == 0 {
+		return 100, 0
+	}
+	pct = float64(done) * 100 / float64(total)
 
-	return eng.mkReport(srv, rs), nil
+	if parallel < 1 {
+		parallel = 1
+	}
+	remaining := total - done
+	batches := (remaining + parallel - 1) / parallel
+	eta = time.Duration(batches) * avg
+	return pct, eta.Round(time.Second)
 }
 
-// mkReport generates a report from the information stored in the
-// provided [reportStore]. It uses the specified [targetServer] to
+// mkReport generates a report from the provided reports, indexed by
+// check ID, which must not be modified concurrently. See
+// [reportStore.Reports]. It uses the specified [targetServer] to
 // replace the targets sent to the checks with the original targets.
-func (eng Engine) mkReport(srv *targetServer, rs *reportStore) Report {
+// The labels of the targets are taken from the metadata of the
+// provided jobs.
+func (eng Engine) mkReport(srv *targetServer, reports map[string]report.Report, jobs []jobrunner.Job) Report {
+	labels := jobLabels(jobs)
+
 	rep := make(Report)
-	for checkID, r := range rs.Reports() {
-		tm, ok := srv.TargetMap(checkID)
-		if !ok {
-			rep[checkID] = r
-			continue
-		}
+	for checkID, r := range reports {
+		rep[checkID] = mkCheckReport(srv, labels, checkID, r)
+	}
+	return rep
+}
 
-		tmAddrs := tm.Addrs()
+// jobLabels returns the labels of the targets of the provided jobs
+// indexed by check ID. They are taken from the metadata of the jobs.
+func jobLabels(jobs []jobrunner.Job) map[string]map[string]string {
+	labels := make(map[string]map[string]string)
+	for _, job := range jobs {
+		labels[job.CheckID] = job.Metadata
+	}
+	return labels
+}
 
-		slog.Info("applying target map", "check", checkID, "tm", tm, "tmAddr", tmAddrs)
+// mkCheckReport generates the [CheckReport] of the provided report.
+// It uses the specified [targetServer] to replace the target sent to
+// the check with the original target. The labels of the target are
+// taken from the provided labels, indexed by check ID.
+func mkCheckReport(srv *targetServer, labels map[string]map[string]string, checkID string, r report.Report) CheckReport {
+	tm, ok := srv.TargetMap(checkID)
+	if !ok {
+		return CheckReport{Report: r, Labels: labels[checkID]}
+	}
 
-		r.Target = tm.OldIdentifier
+	tmAddrs := tm.Addrs()
 
-		var vulns []report.Vulnerability
-		for _, vuln := range r.Vulnerabilities {
-			vuln = vulnReplaceAll(vuln, tm.NewIdentifier, tm.OldIdentifier)
-			vuln = vulnReplaceAll(vuln, tmAddrs.NewIdentifier, tmAddrs.OldIdentifier)
-			vulns = append(vulns, vuln)
-		}
-		r.Vulnerabilities = vulns
+	slog.Info("applying target map", "check", checkID, "tm", tm, "tmAddr", tmAddrs)
 
-		rep[checkID] = r
+	r.Target = tm.OldIdentifier
+
+	var vulns []report.Vulnerability
+	for _, vuln := range r.Vulnerabilities {
+		vuln = vulnReplaceAll(vuln, tm.NewIdentifier, tm.OldIdentifier)
+		vuln = vulnReplaceAll(vuln, tmAddrs.NewIdentifier, tmAddrs.OldIdentifier)
+		vulns = append(vulns, vuln)
 	}
-	return rep
+	r.Vulnerabilities = vulns
+
+	return CheckReport{Report: r, Labels: labels[checkID]}
 }
 
 // vulnReplaceAll returns a copy of the vulnerability vuln with all
@@ -320,12 +845,17 @@ func rscReplaceAll(rsc report.ResourcesGroup, old, new string) report.ResourcesG
 
 // beforeRun is called by the agent before creating each check
 // container.
-func (eng Engine) beforeRun(params backend.RunParams, rc *docker.RunConfig, srv *targetServer) error {
+func (eng Engine) beforeRun(params backend.RunParams, rc *docker.RunConfig, srv *targetServer, creds map[string]config.Credentials) error {
 	// Register a host pointing to the host gateway.
 	if gwmap := eng.cli.HostGatewayMapping(); gwmap != "" {
 		rc.HostConfig.ExtraHosts = []string{gwmap}
 	}
 
+	// Attach the check to the configured network.
+	if network := eng.cli.Network(); network != "" {
+		rc.HostConfig.NetworkMode = container.NetworkMode(network)
+	}
+
 	// Allow all checks to scan local assets.
 	rc.ContainerConfig.Env = setenv(rc.ContainerConfig.Env, "VULCAN_ALLOW_PRIVATE_IPS", "true")
 
@@ -347,6 +877,30 @@ func (eng Engine) beforeRun(params backend.RunParams, rc *docker.RunConfig, srv
 		}
 	}
 
+	// Grant the privileges required by the checktype. They
+	// weaken the isolation of the check, so they are always
+	// logged.
+	if ct, ok := eng.catalog[params.CheckTypeName]; ok && ct.Privileges != nil {
+		if err := grantPrivileges(rc.HostConfig, *ct.Privileges, eng.privileges[params.CheckTypeName]); err != nil {
+			slog.Error("refusing to run check", "checktype", params.CheckTypeName, "check", params.CheckID, "err", err)
+			return fmt.Errorf("grant privileges: %w", err)
+		}
+		slog.Warn("granting privileges to check", "checktype", params.CheckTypeName, "check", params.CheckID, "privileged", ct.Privileges.Privileged, "capabilities", ct.Privileges.Capabilities)
+	}
+
+	// Override the command of the checktype. It changes the
+	// behavior of the check, so it is always logged.
+	if o, ok := eng.overrides[params.CheckTypeName]; ok && (len(o.Entrypoint) > 0 || len(o.Args) > 0) {
+		slog.Warn("overriding checktype command", "checktype", params.CheckTypeName, "check", params.CheckID, "entrypoint", o.Entrypoint, "args", o.Args)
+		applyOverride(rc.ContainerConfig, o)
+	}
+
+	// Pass the target credentials to the check. Secrets are
+	// never logged.
+	if c, ok := creds[params.CheckID]; ok {
+		rc.ContainerConfig.Env = setCredentialsEnv(rc.ContainerConfig.Env, c)
+	}
+
 	// Proxy local targets and serve Git repositories.
 	target := config.Target{
 		Identifier: params.Target,
@@ -364,6 +918,122 @@ func (eng Engine) beforeRun(params backend.RunParams, rc *docker.RunConfig, srv
 	return nil
 }
 
+// applyOverride applies the provided command override to a container
+// configuration. Empty fields of the override are ignored. Note that
+// overriding the entrypoint resets the default command of the image.
+func applyOverride(cfg *container.Config, o config.CommandOverride) {
+	if len(o.Entrypoint) > 0 {
+		cfg.Entrypoint = o.Entrypoint
+	}
+	if len(o.Args) > 0 {
+		cfg.Cmd = o.Args
+	}
+}
+
+// defaultTimeout is the timeout in seconds of the checks whose
+// checktype does not define one, unless it is set in the agent
+// configuration.
+const defaultTimeout = 180
+
+// unlimitedTimeout is the timeout in seconds of the checks whose
+// timeout is disabled. The agent does not support checks without
+// timeout, so a timeout of more than 60 years is used instead.
+const unlimitedTimeout = math.MaxInt32
+
+// getenvNoTimeout reports whether the timeout of all the checks is
+// disabled by the LAVA_NO_TIMEOUT environment variable.
+func getenvNoTimeout() (bool, error) {
+	env := os.Getenv("LAVA_NO_TIMEOUT")
+	if env == "" {
+		return false, nil
+	}
+	noTimeout, err := strconv.ParseBool(env)
+	if err != nil {
+		return false, fmt.Errorf("invalid LAVA_NO_TIMEOUT value: %v", env)
+	}
+	return noTimeout, nil
+}
+
+// disableTimeouts disables the timeout of the provided checks if it
+// is disabled globally or by the command override of their
+// checktype. It takes precedence over the timeout of the checktype
+// manifest. It is intended for checktype development, so it is
+// always logged.
+func (eng Engine) disableTimeouts(checks []check) {
+	for i, c := range checks {
+		if !eng.noTimeout && !eng.overrides[c.checktype.Name].NoTimeout {
+			continue
+		}
+		slog.Warn("DISABLING CHECK TIMEOUT: the check will run until it exits", "checktype", c.checktype.Name, "check", c.id, "timeout", c.checktype.Timeout)
+		checks[i].checktype.Timeout = unlimitedTimeout
+	}
+}
+
+// ErrPrivilegesNotAllowed means that a checktype requires privileges
+// that are not allowed by the configuration.
+var ErrPrivilegesNotAllowed = errors.New("privileges not allowed")
+
+// grantPrivileges grants the privileges required by a checktype to
+// the provided container host configuration. It returns an error if
+// the required privileges are not allowed by the provided policy.
+func grantPrivileges(hc *container.HostConfig, required checktypes.Privileges, allowed config.Privileges) error {
+	if required.Privileged && !allowed.Privileged {
+		return fmt.Errorf("%w: privileged mode", ErrPrivilegesNotAllowed)
+	}
+
+	if !allowed.Privileged {
+		for _, c := range required.Capabilities {
+			if !slices.ContainsFunc(allowed.Capabilities, func(a string) bool {
+				return normalizeCapability(a) == normalizeCapability(c)
+			}) {
+				return fmt.Errorf("%w: capability %v", ErrPrivilegesNotAllowed, c)
+			}
+		}
+	}
+
+	hc.Privileged = required.Privileged
+	hc.CapAdd = append(hc.CapAdd, required.Capabilities...)
+	return nil
+}
+
+// normalizeCapability returns the canonical name of the provided
+// Linux capability. For instance, "cap_net_raw" and "NET_RAW" are
+// both normalized to "NET_RAW".
+func normalizeCapability(c string) string {
+	c = strings.ToUpper(c)
+	return strings.TrimPrefix(c, "CAP_")
+}
+
+// Environment variables used to pass the target credentials to the
+// checks.
+const (
+	envAuthType     = "LAVA_TARGET_AUTH_TYPE"
+	envAuthUsername = "LAVA_TARGET_AUTH_USERNAME"
+	envAuthPassword = "LAVA_TARGET_AUTH_PASSWORD"
+	envAuthToken    = "LAVA_TARGET_AUTH_TOKEN"
+	envAuthSSHKey   = "LAVA_TARGET_AUTH_SSH_KEY"
+)
+
+// setCredentialsEnv sets the environment variables that pass the
+// provided credentials to a check. References to environment
+// variables in the credentials are expanded.
+func setCredentialsEnv(env []string, c config.Credentials) []string {
+	if c.Token != "" {
+		env = setenv(env, envAuthType, "bearer")
+		env = setenv(env, envAuthToken, os.ExpandEnv(c.Token))
+		return env
+	}
+	if c.SSHKey != "" {
+		env = setenv(env, envAuthType, "ssh")
+		env = setenv(env, envAuthSSHKey, os.ExpandEnv(c.SSHKey))
+		return env
+	}
+	env = setenv(env, envAuthType, "basic")
+	env = setenv(env, envAuthUsername, os.ExpandEnv(c.Username))
+	env = setenv(env, envAuthPassword, os.ExpandEnv(c.Password))
+	return env
+}
+
 // setenv sets the value of the variable named by the key in the
 // provided environment. An environment consists on a slice of strings
 // with the format "key=value".
@@ -0,0 +1,253 @@
+// Copyright 2023 Adevinta
+
+// Package enginetest provides utilities for testing the Lava engine
+// without a container runtime.
+package enginetest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	"github.com/adevinta/vulcan-agent/backend/docker"
+	agentconfig "github.com/adevinta/vulcan-agent/config"
+	"github.com/adevinta/vulcan-agent/log"
+	report "github.com/adevinta/vulcan-report"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+// Runtime is an in-memory container runtime that implements the
+// runtime interface required by the engine. Checks are not executed.
+// Instead, the runtime records every check and sends the report
+// returned by ReportFunc to the engine, as a real check would do. The
+// zero value is ready to use.
+type Runtime struct {
+	// ReportFunc returns the report of the check with the
+	// provided parameters. The check ID of the returned report is
+	// always set to the ID of the check. The checktype name, the
+	// target, the status and the start time are set to default
+	// values if they are empty. If ReportFunc is nil, an empty
+	// report with status "FINISHED" is sent.
+	ReportFunc func(params backend.RunParams) report.Report
+
+	// OutputFunc returns the output of the check with the
+	// provided parameters, which is stored by the agent as the
+	// logs of the check. If OutputFunc is nil, the check does not
+	// write any output.
+	OutputFunc func(params backend.RunParams) []byte
+
+	// Host is the address of the container daemon returned by
+	// DaemonHost. For instance, "unix:///var/run/docker.sock".
+	// If empty, there is no daemon.
+	Host string
+
+	mu   sync.Mutex
+	runs []Run
+}
+
+// Run is a check run recorded by [Runtime].
+type Run struct {
+	// Params are the parameters of the check.
+	Params backend.RunParams
+
+	// Config is the container configuration of the check after
+	// being updated by the engine.
+	Config docker.RunConfig
+}
+
+// Runs returns the checks run so far.
+func (rt *Runtime) Runs() []Run {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	return append([]Run(nil), rt.runs...)
+}
+
+// HostGatewayHostname returns "localhost".
+func (rt *Runtime) HostGatewayHostname() string {
+	return "localhost"
+}
+
+// HostGatewayMapping returns an empty string, because no mapping is
+// required.
+func (rt *Runtime) HostGatewayMapping() string {
+	return ""
+}
+
+// HostGatewayInterfaceAddr returns the loopback address.
+func (rt *Runtime) HostGatewayInterfaceAddr() (string, error) {
+	return "127.0.0.1", nil
+}
+
+// Network returns an empty string, which means the default network.
+func (rt *Runtime) Network() string {
+	return ""
+}
+
+// DaemonHost returns [Runtime.Host].
+func (rt *Runtime) DaemonHost() string {
+	return rt.Host
+}
+
+// Close does nothing.
+func (rt *Runtime) Close() error {
+	return nil
+}
+
+// NewBackend returns a Vulcan agent backend that runs the checks in
+// memory. The checks send their reports to the agent API listener
+// specified in the provided configuration. Like in the Docker
+// backend, the address of the agent API passed to the checks is
+// built from the configured API host and the port of the listener.
+func (rt *Runtime) NewBackend(_ log.Logger, cfg agentconfig.Config, updater docker.ConfigUpdater) (backend.Backend, error) {
+	if cfg.API.Listener == nil {
+		return nil, errors.New("missing agent API listener")
+	}
+
+	addr := cfg.API.Listener.Addr().String()
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("split host port %q: %w", addr, err)
+	}
+
+	b := &fakeBackend{
+		rt:        rt,
+		addr:      addr,
+		agentAddr: net.JoinHostPort(cfg.API.Host, port),
+		updater:   updater,
+	}
+	return b, nil
+}
+
+// fakeBackend is the backend returned by [Runtime.NewBackend].
+type fakeBackend struct {
+	rt        *Runtime
+	addr      string
+	agentAddr string
+	updater   docker.ConfigUpdater
+}
+
+// Run runs the check with the provided parameters. The engine is
+// given the chance to update the container configuration of the
+// check, which is recorded. Then, the report of the check is sent to
+// the agent API.
+func (b *fakeBackend) Run(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+	rc := docker.RunConfig{
+		ContainerConfig: &container.Config{
+			Hostname: params.CheckID,
+			Image:    params.Image,
+			Env: []string{
+				backend.CheckIDVar + "=" + params.CheckID,
+				backend.ChecktypeNameVar + "=" + params.CheckTypeName,
+				backend.CheckTargetVar + "=" + params.Target,
+				backend.CheckAssetTypeVar + "=" + params.AssetType,
+				backend.CheckOptionsVar + "=" + params.Options,
+				backend.AgentAddressVar + "=" + b.agentAddr,
+			},
+		},
+		HostConfig: &container.HostConfig{},
+		NetConfig:  &network.NetworkingConfig{},
+	}
+	if b.updater != nil {
+		if err := b.updater(params, &rc); err != nil {
+			return nil, fmt.Errorf("update config: %w", err)
+		}
+	}
+
+	b.rt.mu.Lock()
+	b.rt.runs = append(b.rt.runs, Run{Params: params, Config: rc})
+	b.rt.mu.Unlock()
+
+	// Like real checks, use the target passed through the
+	// environment, which could have been modified by the engine.
+	params.Target = getenv(rc.ContainerConfig.Env, backend.CheckTargetVar)
+
+	ch := make(chan backend.RunResult, 1)
+	go func() {
+		r := b.rt.report(params)
+		res := backend.RunResult{Error: b.sendReport(ctx, r)}
+		if b.rt.OutputFunc != nil {
+			res.Output = b.rt.OutputFunc(params)
+		}
+		ch <- res
+	}()
+	return ch, nil
+}
+
+// report returns the report of the check with the provided
+// parameters. See [Runtime.ReportFunc].
+func (rt *Runtime) report(params backend.RunParams) report.Report {
+	var r report.Report
+	if rt.ReportFunc != nil {
+		r = rt.ReportFunc(params)
+	}
+
+	r.CheckID = params.CheckID
+	if r.ChecktypeName == "" {
+		r.ChecktypeName = params.CheckTypeName
+	}
+	if r.Target == "" {
+		r.Target = params.Target
+	}
+	if r.Status == "" {
+		r.Status = "FINISHED"
+	}
+	if r.StartTime.IsZero() {
+		r.StartTime = time.Now()
+	}
+	return r
+}
+
+// sendReport sends the provided report to the agent API.
+func (b *fakeBackend) sendReport(ctx context.Context, r report.Report) error {
+	state := struct {
+		Status string        `json:"status"`
+		Report report.Report `json:"report"`
+	}{
+		Status: r.Status,
+		Report: r,
+	}
+	body, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encode check state: %w", err)
+	}
+
+	url := fmt.Sprintf("http://%v/check/%v", b.addr, r.CheckID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send check state: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("send check state: invalid status code: %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// getenv returns the value of the environment variable with the
+// provided name. If it is defined several times, the last value is
+// returned.
+func getenv(env []string, name string) string {
+	var value string
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == name {
+			value = v
+		}
+	}
+	return value
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+)
+
+// checkSignaler is implemented by the runtimes that are able to send
+// signals to the checks.
+type checkSignaler interface {
+	// SignalCheck sends the provided signal to the check with the
+	// specified ID.
+	SignalCheck(ctx context.Context, checkID, signal string) error
+}
+
+// gracePeriod returns the grace period in seconds of the specified
+// checktype. The grace period of the checktype takes precedence
+// over the one of the engine.
+func (eng Engine) gracePeriod(checktype string) int {
+	if ct := eng.catalog[checktype]; ct.GracePeriod > 0 {
+		return ct.GracePeriod
+	}
+	return eng.grace
+}
+
+// addGracePeriods extends the timeout of the provided checks with
+// their grace period, so the Vulcan agent does not stop them before
+// the grace period expires. Checks without timeout are left
+// untouched. Grace periods are ignored if the runtime is not able to
+// send signals to the checks.
+func (eng Engine) addGracePeriods(checks []check) {
+	if _, ok := eng.cli.(checkSignaler); !ok {
+		return
+	}
+
+	for i, c := range checks {
+		grace := eng.gracePeriod(c.checktype.Name)
+		if grace == 0 || c.checktype.Timeout == unlimitedTimeout {
+			continue
+		}
+
+		timeout := c.checktype.Timeout
+		if timeout == 0 {
+			timeout = eng.cfg.Agent.Timeout
+		}
+		checks[i].checktype.Timeout = timeout + grace
+	}
+}
+
+// graceBackend is a [backend.Backend] that sends a SIGTERM signal to
+// the checks when their timeout expires and gives them a grace
+// period to exit before being stopped by the Vulcan agent. So, the
+// timeout of the jobs must include the grace period. See
+// [Engine.addGracePeriods].
+type graceBackend struct {
+	backend.Backend
+	signaler checkSignaler
+
+	// grace returns the grace period of the specified checktype.
+	grace func(checktype string) time.Duration
+}
+
+// newGraceBackend returns a [graceBackend] that wraps the provided
+// backend. It uses the catalog of the engine to get the grace period
+// of the checks.
+func (eng Engine) newGraceBackend(b backend.Backend, signaler checkSignaler) graceBackend {
+	grace := func(checktype string) time.Duration {
+		return time.Duration(eng.gracePeriod(checktype)) * time.Second
+	}
+	return graceBackend{Backend: b, signaler: signaler, grace: grace}
+}
+
+// Run runs the check with the provided parameters using the
+// underlying backend. If the check has a grace period and it is
+// still running when its timeout expires, it is sent a SIGTERM
+// signal. In that case, the returned result reports that the
+// deadline was exceeded.
+func (b graceBackend) Run(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+	inner, err := b.Backend.Run(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	grace := b.grace(params.CheckTypeName)
+	deadline, ok := ctx.Deadline()
+	if grace == 0 || !ok {
+		return inner, nil
+	}
+
+	res := make(chan backend.RunResult)
+	go func() {
+		timer := time.NewTimer(time.Until(deadline.Add(-grace)))
+		defer timer.Stop()
+
+		select {
+		case r := <-inner:
+			res <- r
+		case <-timer.C:
+			slog.Warn("check timed out, sending SIGTERM", "checktype", params.CheckTypeName, "check", params.CheckID, "grace", grace)
+			if err := b.signaler.SignalCheck(context.Background(), params.CheckID, "SIGTERM"); err != nil {
+				slog.Error("could not terminate check", "check", params.CheckID, "err", err)
+			}
+			r := <-inner
+			if r.Error == nil || errors.Is(r.Error, backend.ErrNonZeroExitCode) {
+				r.Error = context.DeadlineExceeded
+			}
+			res <- r
+		}
+	}()
+	return res, nil
+}
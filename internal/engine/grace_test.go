@@ -0,0 +1,190 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	agentconfig "github.com/adevinta/vulcan-agent/config"
+	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/checktypes"
+	"github.com/adevinta/lava/internal/engine/enginetest"
+)
+
+// signalerRuntime is a [Runtime] able to send signals to the
+// checks.
+type signalerRuntime struct {
+	*enginetest.Runtime
+
+	mu      sync.Mutex
+	signals map[string]string
+	term    chan struct{}
+}
+
+func (rt *signalerRuntime) SignalCheck(ctx context.Context, checkID, signal string) error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if rt.signals == nil {
+		rt.signals = make(map[string]string)
+	}
+	rt.signals[checkID] = signal
+	if rt.term != nil {
+		close(rt.term)
+	}
+	return nil
+}
+
+// backendFunc is a [backend.Backend] that calls itself to run the
+// checks.
+type backendFunc func(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error)
+
+func (f backendFunc) Run(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+	return f(ctx, params)
+}
+
+func TestEngine_addGracePeriods(t *testing.T) {
+	tests := []struct {
+		name    string
+		rt      Runtime
+		grace   int
+		catalog checktypes.Catalog
+		want    []int
+	}{
+		{
+			name: "no grace period",
+			rt:   &signalerRuntime{},
+			want: []int{60, 0, unlimitedTimeout},
+		},
+		{
+			name:  "global",
+			rt:    &signalerRuntime{},
+			grace: 10,
+			want:  []int{70, 190, unlimitedTimeout},
+		},
+		{
+			name:  "checktype",
+			rt:    &signalerRuntime{},
+			grace: 10,
+			catalog: checktypes.Catalog{
+				"vulcan-drupal": {GracePeriod: 30},
+			},
+			want: []int{90, 190, unlimitedTimeout},
+		},
+		{
+			name:  "runtime without signals",
+			rt:    &enginetest.Runtime{},
+			grace: 10,
+			want:  []int{60, 0, unlimitedTimeout},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := []check{
+				{
+					id:        "check1",
+					checktype: checkcatalog.Checktype{Name: "vulcan-drupal", Timeout: 60},
+				},
+				{
+					id:        "check2",
+					checktype: checkcatalog.Checktype{Name: "vulcan-nessus"},
+				},
+				{
+					id:        "check3",
+					checktype: checkcatalog.Checktype{Name: "vulcan-nmap", Timeout: unlimitedTimeout},
+				},
+			}
+
+			eng := Engine{
+				cli:     tt.rt,
+				catalog: tt.catalog,
+				cfg:     agentconfig.Config{Agent: agentconfig.AgentConfig{Timeout: 180}},
+				grace:   tt.grace,
+			}
+			eng.addGracePeriods(checks)
+
+			var got []int
+			for _, c := range checks {
+				got = append(got, c.checktype.Timeout)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("timeouts mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestGraceBackend_Run(t *testing.T) {
+	tests := []struct {
+		name        string
+		grace       time.Duration
+		wantSignals map[string]string
+		wantErr     error
+	}{
+		{
+			name:        "grace period",
+			grace:       time.Hour,
+			wantSignals: map[string]string{"check1": "SIGTERM"},
+			wantErr:     context.DeadlineExceeded,
+		},
+		{
+			name:    "no grace period",
+			grace:   0,
+			wantErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &signalerRuntime{term: make(chan struct{})}
+
+			// The check exits when it receives the signal or,
+			// if it has no grace period, immediately.
+			inner := backendFunc(func(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+				res := make(chan backend.RunResult)
+				go func() {
+					if tt.grace != 0 {
+						<-rt.term
+					}
+					res <- backend.RunResult{Output: []byte("output")}
+				}()
+				return res, nil
+			})
+
+			b := graceBackend{
+				Backend:  inner,
+				signaler: rt,
+				grace:    func(string) time.Duration { return tt.grace },
+			}
+
+			// The deadline is within the grace period, so the
+			// check is signaled immediately.
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			ch, err := b.Run(ctx, backend.RunParams{CheckID: "check1", CheckTypeName: "vulcan-drupal"})
+			if err != nil {
+				t.Fatalf("run error: %v", err)
+			}
+
+			r := <-ch
+			if !errors.Is(r.Error, tt.wantErr) {
+				t.Errorf("unexpected error: got: %v, want: %v", r.Error, tt.wantErr)
+			}
+			if string(r.Output) != "output" {
+				t.Errorf("unexpected output: %q", r.Output)
+			}
+			if diff := cmp.Diff(tt.wantSignals, rt.signals); diff != "" {
+				t.Errorf("signals mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -3,8 +3,10 @@
 package engine
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"maps"
 	"reflect"
 	"slices"
@@ -12,18 +14,22 @@ import (
 
 	"github.com/adevinta/vulcan-agent/jobrunner"
 	"github.com/adevinta/vulcan-agent/queue"
+	"github.com/adevinta/vulcan-agent/queue/chanqueue"
 	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
 	"github.com/google/uuid"
 
 	"github.com/adevinta/lava/internal/assettypes"
 	"github.com/adevinta/lava/internal/checktypes"
 	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/metrics"
 )
 
-// generateJobs generates the jobs to be sent to the agent.
-func generateJobs(catalog checktypes.Catalog, targets []config.Target) ([]jobrunner.Job, error) {
+// generateJobs generates the jobs to be sent to the agent from the
+// provided checks. The timeout of the jobs is the timeout of their
+// checktype or, if it is zero, the provided default timeout.
+func generateJobs(checks []check, defaultTimeout int) ([]jobrunner.Job, error) {
 	var jobs []jobrunner.Job
-	for _, check := range generateChecks(catalog, targets) {
+	for _, check := range checks {
 		// Convert the options to a marshalled json string.
 		jsonOpts, err := json.Marshal(check.options)
 		if err != nil {
@@ -49,19 +55,38 @@ func generateJobs(catalog checktypes.Catalog, targets []config.Target) ([]jobrun
 			}
 		}
 
+		timeout := check.checktype.Timeout
+		if timeout == 0 {
+			timeout = defaultTimeout
+		}
+
 		jobs = append(jobs, jobrunner.Job{
 			CheckID:      check.id,
 			Image:        check.checktype.Image,
 			Target:       check.target.Identifier,
-			Timeout:      check.checktype.Timeout,
+			Timeout:      timeout,
 			AssetType:    string(check.target.AssetType),
 			Options:      string(jsonOpts),
 			RequiredVars: reqVars,
+			Metadata:     check.target.Labels,
 		})
 	}
 	return jobs, nil
 }
 
+// checkCredentials returns the credentials of the targets of the
+// provided checks indexed by check ID. Checks whose target does not
+// have credentials are omitted.
+func checkCredentials(checks []check) map[string]config.Credentials {
+	creds := make(map[string]config.Credentials)
+	for _, check := range checks {
+		if check.target.Credentials != nil {
+			creds[check.id] = *check.target.Credentials
+		}
+	}
+	return creds
+}
+
 // check represents an instance of a checktype.
 type check struct {
 	id        string
@@ -71,50 +96,178 @@ type check struct {
 }
 
 // generateChecks generates a list of checks combining a map of
-// checktypes and a list of targets.
+// checktypes and a list of targets. Duplicated targets and checks are
+// removed and reported. See [dedupTargets] and [dedupChecks].
 func generateChecks(catalog checktypes.Catalog, targets []config.Target) []check {
+	ts, dups := dedupTargets(targets)
+	reportDuplicates(dups)
+
 	var checks []check
-	for _, t := range dedup(targets) {
+	for _, t := range ts {
 		for _, ct := range catalog {
 			at := assettypes.ToVulcan(t.AssetType)
-			if !checktypes.Accepts(ct, at) {
+			if !checktypes.Accepts(ct.Checktype, at) {
+				continue
+			}
+			if !t.Selects(ct.Name) {
 				continue
 			}
 
-			// Merge target and check options. Target
-			// options take precedence for being more
-			// restrictive.
-			opts := make(map[string]interface{})
-			maps.Copy(opts, ct.Options)
+			// Merge checktype, asset type and target
+			// options. Target options take precedence for
+			// being more restrictive.
+			opts := ct.OptionsFor(at)
 			maps.Copy(opts, t.Options)
 			checks = append(checks, check{
 				id:        uuid.New().String(),
-				checktype: ct,
+				checktype: ct.Checktype,
 				target:    t,
 				options:   opts,
 			})
 		}
 	}
+
+	checks, dupChecks := dedupChecks(checks)
+	reportDuplicateChecks(dupChecks)
+
 	return checks
 }
 
-// dedup returns a deduplicated slice.
-func dedup[S ~[]E, E any](targets S) S {
-	var ts S
-	for _, target := range targets {
-		if !contains(ts, target) {
-			ts = append(ts, target)
+// dedupChecks returns a deduplicated list of checks. Checks that run
+// the same checktype with the same options and credentials against
+// targets that resolve to the same asset are considered duplicated.
+// See [assettypes.Canonical]. The first check is kept and the labels
+// of its duplicates are merged into its target. It also returns the
+// removed duplicates in the order they are found.
+func dedupChecks(checks []check) (cs, dups []check) {
+	idx := make(map[dedupKey]int)
+	for _, c := range checks {
+		key := mkDedupKey(c)
+		i, ok := idx[key]
+		if !ok {
+			idx[key] = len(cs)
+			cs = append(cs, c)
+			continue
+		}
+
+		dups = append(dups, c)
+
+		if c.target.Labels == nil {
+			continue
+		}
+
+		// The labels map is shared by all the checks of a
+		// target, so it is cloned before being modified.
+		labels := maps.Clone(cs[i].target.Labels)
+		if labels == nil {
+			labels = make(map[string]string)
 		}
+		maps.Copy(labels, c.target.Labels)
+		cs[i].target.Labels = labels
 	}
-	return ts
+	return cs, dups
+}
+
+// dedupKey identifies the checks that run the same checktype with
+// the same options and credentials against the same asset.
+type dedupKey struct {
+	checktype   string
+	assetType   string
+	identifier  string
+	options     string
+	credentials config.Credentials
+	hasCreds    bool
 }
 
-// contains reports whether v is present in s. It uses
-// [reflect.DeepEqual] to compare elements.
-func contains[S ~[]E, E any](s S, v E) bool {
-	return slices.ContainsFunc(s, func(e E) bool {
-		return reflect.DeepEqual(e, v)
-	})
+// mkDedupKey returns the [dedupKey] of the provided check. The
+// identifier of the target is canonicalized with
+// [assettypes.Canonical]. The options are encoded as JSON, which
+// sorts the keys of the maps.
+func mkDedupKey(c check) dedupKey {
+	at := c.target.AssetType
+	key := dedupKey{
+		checktype:  c.checktype.Name,
+		assetType:  string(at),
+		identifier: assettypes.Canonical(at, c.target.Identifier),
+	}
+	if data, err := json.Marshal(c.options); err == nil {
+		key.options = string(data)
+	} else {
+		key.options = fmt.Sprintf("%#v", c.options)
+	}
+	if c.target.Credentials != nil {
+		key.credentials = *c.target.Credentials
+		key.hasCreds = true
+	}
+	return key
+}
+
+// reportDuplicateChecks logs the number of duplicated checks and
+// collects it as a metric. Every duplicated check is logged with
+// debug level.
+func reportDuplicateChecks(dups []check) {
+	metrics.Collect("duplicated_check_count", len(dups))
+
+	if len(dups) == 0 {
+		return
+	}
+
+	slog.Info("duplicated checks removed", "count", len(dups))
+	for _, c := range dups {
+		slog.Debug("duplicated check", "checktype", c.checktype.Name, "identifier", c.target.Identifier, "type", c.target.AssetType)
+	}
+}
+
+// dedupTargets returns a deduplicated list of targets. Targets that
+// only differ in their labels are considered duplicated and their
+// labels are merged. If a label is defined more than once, the last
+// value wins. It also returns the removed duplicates in the order
+// they are found.
+func dedupTargets(targets []config.Target) (ts, dups []config.Target) {
+	for _, t := range targets {
+		i := slices.IndexFunc(ts, func(e config.Target) bool {
+			return sameTarget(e, t)
+		})
+		if i < 0 {
+			t.Labels = maps.Clone(t.Labels)
+			ts = append(ts, t)
+			continue
+		}
+
+		dups = append(dups, t)
+
+		if t.Labels == nil {
+			continue
+		}
+		if ts[i].Labels == nil {
+			ts[i].Labels = make(map[string]string)
+		}
+		maps.Copy(ts[i].Labels, t.Labels)
+	}
+	return ts, dups
+}
+
+// reportDuplicates logs the number of duplicated targets and collects
+// it as a metric. Every duplicated target is logged with debug level.
+func reportDuplicates(dups []config.Target) {
+	metrics.Collect("duplicated_target_count", len(dups))
+
+	if len(dups) == 0 {
+		return
+	}
+
+	slog.Info("duplicated targets removed", "count", len(dups))
+	for _, t := range dups {
+		slog.Debug("duplicated target", "identifier", t.Identifier, "type", t.AssetType)
+	}
+}
+
+// sameTarget reports whether the provided targets are equal ignoring
+// their labels. It uses [reflect.DeepEqual] to compare them.
+func sameTarget(a, b config.Target) bool {
+	a.Labels = nil
+	b.Labels = nil
+	return reflect.DeepEqual(a, b)
 }
 
 // sendJobs feeds the provided queue with jobs.
@@ -131,3 +284,60 @@ func sendJobs(jobs []jobrunner.Job, qw queue.Writer) error {
 	}
 	return nil
 }
+
+// pausableQueue is a [chanqueue.ChanQueue] that stops dispatching
+// jobs when its context is done. The running jobs are not affected,
+// so the agent waits for them to finish before exiting.
+type pausableQueue struct {
+	*chanqueue.ChanQueue
+	ctx context.Context
+}
+
+// SetMessageProcessor sets the message processor of the queue. The
+// provided processor is wrapped, so messages received after the
+// context of the queue is done are discarded without being
+// processed.
+func (q pausableQueue) SetMessageProcessor(proc queue.MessageProcessor) {
+	q.ChanQueue.SetMessageProcessor(pausableProcessor{MessageProcessor: proc, ctx: q.ctx})
+}
+
+// StartReading starts reading messages from the queue. It stops
+// reading when either the provided context or the context of the
+// queue is done. See [chanqueue.ChanQueue.StartReading].
+func (q pausableQueue) StartReading(ctx context.Context) <-chan error {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(q.ctx, cancel)
+
+	errs := make(chan error)
+	go func() {
+		defer cancel()
+		defer stop()
+
+		for err := range q.ChanQueue.StartReading(ctx) {
+			errs <- err
+		}
+		close(errs)
+	}()
+	return errs
+}
+
+// pausableProcessor is a [queue.MessageProcessor] that discards the
+// messages received after its context is done.
+type pausableProcessor struct {
+	queue.MessageProcessor
+	ctx context.Context
+}
+
+// ProcessMessage processes the provided message using the wrapped
+// processor. If the context of the processor is done, the message
+// is deleted without being processed and the token is returned.
+func (p pausableProcessor) ProcessMessage(msg queue.Message, token any) <-chan bool {
+	if p.ctx.Err() == nil {
+		return p.MessageProcessor.ProcessMessage(msg, token)
+	}
+
+	p.MessageProcessor.FreeTokens() <- token
+	processed := make(chan bool, 1)
+	processed <- true
+	return processed
+}
@@ -4,6 +4,7 @@ package engine
 
 import (
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/adevinta/vulcan-agent/jobrunner"
@@ -28,11 +29,13 @@ func TestGenerateChecks(t *testing.T) {
 			name: "one checktype and one target",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
 					},
 				},
 			},
@@ -64,16 +67,18 @@ func TestGenerateChecks(t *testing.T) {
 			name: "target overrides checktype options",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
-					},
-					Options: map[string]interface{}{
-						"option1": "checktype value 1",
-						"option2": "checktype value 2",
-						"option3": "checktype value 3",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
+						Options: map[string]interface{}{
+							"option1": "checktype value 1",
+							"option2": "checktype value 2",
+							"option3": "checktype value 3",
+						},
 					},
 				},
 			},
@@ -120,19 +125,23 @@ func TestGenerateChecks(t *testing.T) {
 			name: "two checktypes and one target",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
 					},
 				},
 				"checktype2": {
-					Name:        "checktype2",
-					Description: "checktype2 description",
-					Image:       "namespace2/repository2:tag",
-					Assets: []string{
-						"DomainName",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype2",
+						Description: "checktype2 description",
+						Image:       "namespace2/repository2:tag",
+						Assets: []string{
+							"DomainName",
+						},
 					},
 				},
 			},
@@ -179,11 +188,13 @@ func TestGenerateChecks(t *testing.T) {
 			name: "incompatible target",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
 					},
 				},
 			},
@@ -199,11 +210,13 @@ func TestGenerateChecks(t *testing.T) {
 			name: "invalid target asset type",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"Hostname",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"Hostname",
+						},
 					},
 				},
 			},
@@ -230,11 +243,13 @@ func TestGenerateChecks(t *testing.T) {
 			name: "no targets",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
 					},
 				},
 			},
@@ -245,11 +260,13 @@ func TestGenerateChecks(t *testing.T) {
 			name: "target without asset type",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
 					},
 				},
 			},
@@ -264,12 +281,14 @@ func TestGenerateChecks(t *testing.T) {
 			name: "one checktype with two asset types and one target",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"Hostname",
-						"WebAddress",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"Hostname",
+							"WebAddress",
+						},
 					},
 				},
 			},
@@ -302,12 +321,14 @@ func TestGenerateChecks(t *testing.T) {
 			name: "one checktype with two asset types and one target identifier with two asset types",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"Hostname",
-						"DomainName",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"Hostname",
+							"DomainName",
+						},
 					},
 				},
 			},
@@ -360,11 +381,13 @@ func TestGenerateChecks(t *testing.T) {
 			name: "one target identifier with two asset types",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"Hostname",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"Hostname",
+						},
 					},
 				},
 			},
@@ -400,11 +423,13 @@ func TestGenerateChecks(t *testing.T) {
 			name: "duplicated targets",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
 					},
 				},
 			},
@@ -437,21 +462,34 @@ func TestGenerateChecks(t *testing.T) {
 			},
 		},
 		{
-			name: "lava asset type",
+			name: "duplicated targets with different labels",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"GitRepository",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
 					},
 				},
 			},
 			targets: []config.Target{
 				{
-					Identifier: ".",
-					AssetType:  assettypes.Path,
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Labels: map[string]string{
+						"env":   "dev",
+						"owner": "team-a",
+					},
+				},
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Labels: map[string]string{
+						"env": "prod",
+					},
 				},
 			},
 			want: []check{
@@ -461,161 +499,859 @@ func TestGenerateChecks(t *testing.T) {
 						Description: "checktype1 description",
 						Image:       "namespace/repository:tag",
 						Assets: []string{
-							"GitRepository",
+							"DomainName",
 						},
 					},
 					target: config.Target{
-						Identifier: ".",
-						AssetType:  assettypes.Path,
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+						Labels: map[string]string{
+							"env":   "prod",
+							"owner": "team-a",
+						},
 					},
 					options: map[string]any{},
 				},
 			},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := generateChecks(tt.catalog, tt.targets)
-			diffOpts := []cmp.Option{
-				cmp.AllowUnexported(check{}),
-				cmpopts.SortSlices(checkLess),
-				cmpopts.IgnoreFields(check{}, "id"),
-			}
-			if diff := cmp.Diff(tt.want, got, diffOpts...); diff != "" {
-				t.Errorf("checks mismatch (-want +got):\n%v", diff)
-			}
-		})
-	}
-}
-
-func TestGenerateJobs(t *testing.T) {
-	tests := []struct {
-		name       string
-		catalog    checktypes.Catalog
-		targets    []config.Target
-		want       []jobrunner.Job
-		wantNilErr bool
-	}{
 		{
-			name: "one checktype and one target",
+			name: "lava asset type",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"GitRepository",
+						},
 					},
 				},
 			},
 			targets: []config.Target{
 				{
-					Identifier: "example.com",
-					AssetType:  types.DomainName,
+					Identifier: ".",
+					AssetType:  assettypes.Path,
 				},
 			},
-			want: []jobrunner.Job{
+			want: []check{
 				{
-					Image:     "namespace/repository:tag",
-					Target:    "example.com",
-					AssetType: "DomainName",
-					Options:   "{}",
+					checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"GitRepository",
+						},
+					},
+					target: config.Target{
+						Identifier: ".",
+						AssetType:  assettypes.Path,
+					},
+					options: map[string]any{},
 				},
 			},
-			wantNilErr: true,
 		},
 		{
-			name: "two checktypes and one target",
+			name: "checktype, asset type and target options",
 			catalog: checktypes.Catalog{
 				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"IP",
+							"DomainName",
+						},
+						Options: map[string]any{
+							"option1": "checktype",
+							"option2": "checktype",
+							"option3": "checktype",
+						},
 					},
-				},
-				"checktype2": {
-					Name:        "checktype2",
-					Description: "checktype2 description",
-					Image:       "namespace2/repository2:tag",
-					Assets: []string{
-						"DomainName",
+					AssetTypeOptions: map[string]map[string]any{
+						"IP": {
+							"option2": "asset type",
+							"option3": "asset type",
+						},
 					},
 				},
 			},
 			targets: []config.Target{
+				{
+					Identifier: "192.0.2.1",
+					AssetType:  types.IP,
+					Options: map[string]any{
+						"option3": "target",
+					},
+				},
 				{
 					Identifier: "example.com",
 					AssetType:  types.DomainName,
 				},
 			},
-			want: []jobrunner.Job{
+			want: []check{
 				{
-					Image:     "namespace/repository:tag",
-					Target:    "example.com",
-					AssetType: "DomainName",
-					Options:   "{}",
+					checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"IP",
+							"DomainName",
+						},
+						Options: map[string]any{
+							"option1": "checktype",
+							"option2": "checktype",
+							"option3": "checktype",
+						},
+					},
+					target: config.Target{
+						Identifier: "192.0.2.1",
+						AssetType:  types.IP,
+						Options: map[string]any{
+							"option3": "target",
+						},
+					},
+					options: map[string]any{
+						"option1": "checktype",
+						"option2": "asset type",
+						"option3": "target",
+					},
 				},
 				{
-					Image:     "namespace2/repository2:tag",
-					Target:    "example.com",
-					AssetType: "DomainName",
-					Options:   "{}",
-				},
-			},
-			wantNilErr: true,
-		},
-		{
-			name: "one checktype and one target with valid required vars",
-			catalog: checktypes.Catalog{
-				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
+					checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"IP",
+							"DomainName",
+						},
+						Options: map[string]any{
+							"option1": "checktype",
+							"option2": "checktype",
+							"option3": "checktype",
+						},
 					},
-					RequiredVars: []any{
-						"REQUIRED_VAR_1",
-						"REQUIRED_VAR_2",
+					target: config.Target{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+					options: map[string]any{
+						"option1": "checktype",
+						"option2": "checktype",
+						"option3": "checktype",
 					},
 				},
 			},
-			targets: []config.Target{
-				{
-					Identifier: "example.com",
-					AssetType:  types.DomainName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := generateChecks(tt.catalog, tt.targets)
+			diffOpts := []cmp.Option{
+				cmp.AllowUnexported(check{}),
+				cmpopts.SortSlices(checkLess),
+				cmpopts.IgnoreFields(check{}, "id"),
+			}
+			if diff := cmp.Diff(tt.want, got, diffOpts...); diff != "" {
+				t.Errorf("checks mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestGenerateChecks_targetDefaults(t *testing.T) {
+	catalog := checktypes.Catalog{
+		"checktype1": {
+			Checktype: checkcatalog.Checktype{
+				Name:  "checktype1",
+				Image: "namespace/repository:tag",
+				Assets: []string{
+					"IP",
+				},
+				Options: map[string]any{
+					"option1": "checktype",
+					"option2": "checktype",
+					"option3": "checktype",
+					"option4": "checktype",
 				},
 			},
-			want: []jobrunner.Job{
-				{
-					Image:     "namespace/repository:tag",
-					Target:    "example.com",
-					AssetType: "DomainName",
-					Options:   "{}",
-					RequiredVars: []string{
-						"REQUIRED_VAR_1",
-						"REQUIRED_VAR_2",
-					},
+			AssetTypeOptions: map[string]map[string]any{
+				"IP": {
+					"option2": "asset type",
+					"option3": "asset type",
+					"option4": "asset type",
 				},
 			},
-			wantNilErr: true,
 		},
-		{
-			name: "one checktype and one target with invalid required vars",
-			catalog: checktypes.Catalog{
-				"checktype1": {
-					Name:        "checktype1",
-					Description: "checktype1 description",
-					Image:       "namespace/repository:tag",
-					Assets: []string{
-						"DomainName",
+	}
+
+	defaults := config.TargetDefaults{
+		Options: map[string]any{
+			"option3": "defaults",
+			"option4": "defaults",
+		},
+	}
+
+	targets := []config.Target{
+		{
+			Identifier: "192.0.2.1",
+			AssetType:  types.IP,
+			Options: map[string]any{
+				"option4": "target",
+			},
+		},
+	}
+
+	checks := generateChecks(catalog, defaults.Apply(targets))
+	if len(checks) != 1 {
+		t.Fatalf("unexpected number of checks: %v", len(checks))
+	}
+
+	want := map[string]any{
+		"option1": "checktype",
+		"option2": "asset type",
+		"option3": "defaults",
+		"option4": "target",
+	}
+	if diff := cmp.Diff(want, checks[0].options); diff != "" {
+		t.Errorf("options mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestGenerateChecks_dedup(t *testing.T) {
+	checktype := checkcatalog.Checktype{
+		Name:   "checktype1",
+		Image:  "namespace/repository:tag",
+		Assets: []string{"Hostname", "DomainName", "WebAddress"},
+	}
+	catalog := checktypes.Catalog{
+		"checktype1": {Checktype: checktype},
+	}
+
+	tests := []struct {
+		name    string
+		targets []config.Target
+		want    []check
+	}{
+		{
+			name: "equivalent hostnames",
+			targets: []config.Target{
+				{
+					Identifier: "www.example.com",
+					AssetType:  types.Hostname,
+					Labels:     map[string]string{"owner": "team-a"},
+				},
+				{
+					Identifier: "WWW.Example.com.",
+					AssetType:  types.Hostname,
+					Labels:     map[string]string{"env": "prod"},
+				},
+			},
+			want: []check{
+				{
+					checktype: checktype,
+					target: config.Target{
+						Identifier: "www.example.com",
+						AssetType:  types.Hostname,
+						Labels:     map[string]string{"owner": "team-a", "env": "prod"},
 					},
-					RequiredVars: []int{
-						1,
-						2,
+					options: map[string]any{},
+				},
+			},
+		},
+		{
+			name: "equivalent web addresses",
+			targets: []config.Target{
+				{
+					Identifier: "https://example.com",
+					AssetType:  types.WebAddress,
+				},
+				{
+					Identifier: "https://EXAMPLE.com:443/",
+					AssetType:  types.WebAddress,
+				},
+			},
+			want: []check{
+				{
+					checktype: checktype,
+					target: config.Target{
+						Identifier: "https://example.com",
+						AssetType:  types.WebAddress,
+					},
+					options: map[string]any{},
+				},
+			},
+		},
+		{
+			name: "distinct options",
+			targets: []config.Target{
+				{
+					Identifier: "www.example.com",
+					AssetType:  types.Hostname,
+					Options:    map[string]any{"depth": 1},
+				},
+				{
+					Identifier: "WWW.example.com",
+					AssetType:  types.Hostname,
+					Options:    map[string]any{"depth": 2},
+				},
+			},
+			want: []check{
+				{
+					checktype: checktype,
+					target: config.Target{
+						Identifier: "www.example.com",
+						AssetType:  types.Hostname,
+						Options:    map[string]any{"depth": 1},
+					},
+					options: map[string]any{"depth": 1},
+				},
+				{
+					checktype: checktype,
+					target: config.Target{
+						Identifier: "WWW.example.com",
+						AssetType:  types.Hostname,
+						Options:    map[string]any{"depth": 2},
+					},
+					options: map[string]any{"depth": 2},
+				},
+			},
+		},
+		{
+			name: "distinct credentials",
+			targets: []config.Target{
+				{
+					Identifier:  "https://example.com/",
+					AssetType:   types.WebAddress,
+					Credentials: &config.Credentials{Token: "token1"},
+				},
+				{
+					Identifier:  "https://example.com",
+					AssetType:   types.WebAddress,
+					Credentials: &config.Credentials{Token: "token2"},
+				},
+			},
+			want: []check{
+				{
+					checktype: checktype,
+					target: config.Target{
+						Identifier:  "https://example.com/",
+						AssetType:   types.WebAddress,
+						Credentials: &config.Credentials{Token: "token1"},
+					},
+					options: map[string]any{},
+				},
+				{
+					checktype: checktype,
+					target: config.Target{
+						Identifier:  "https://example.com",
+						AssetType:   types.WebAddress,
+						Credentials: &config.Credentials{Token: "token2"},
+					},
+					options: map[string]any{},
+				},
+			},
+		},
+		{
+			name: "distinct asset types",
+			targets: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.Hostname,
+				},
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+			},
+			want: []check{
+				{
+					checktype: checktype,
+					target: config.Target{
+						Identifier: "example.com",
+						AssetType:  types.Hostname,
+					},
+					options: map[string]any{},
+				},
+				{
+					checktype: checktype,
+					target: config.Target{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+					options: map[string]any{},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := generateChecks(catalog, tt.targets)
+			diffOpts := []cmp.Option{
+				cmp.AllowUnexported(check{}),
+				cmpopts.IgnoreFields(check{}, "id"),
+			}
+			if diff := cmp.Diff(tt.want, got, diffOpts...); diff != "" {
+				t.Errorf("checks mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestGenerateChecks_selection(t *testing.T) {
+	catalog := checktypes.Catalog{}
+	for _, name := range []string{"vulcan-nessus", "vulcan-nuclei", "vulcan-trivy", "vulcan-zap"} {
+		catalog[name] = checktypes.Checktype{
+			Checktype: checkcatalog.Checktype{
+				Name:   name,
+				Image:  "namespace/" + name + ":tag",
+				Assets: []string{"DomainName"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{
+			name: "no selection",
+			want: []string{"vulcan-nessus", "vulcan-nuclei", "vulcan-trivy", "vulcan-zap"},
+		},
+		{
+			name:    "include names",
+			include: []string{"vulcan-trivy", "vulcan-zap"},
+			want:    []string{"vulcan-trivy", "vulcan-zap"},
+		},
+		{
+			name:    "exclude names",
+			exclude: []string{"vulcan-nessus"},
+			want:    []string{"vulcan-nuclei", "vulcan-trivy", "vulcan-zap"},
+		},
+		{
+			name:    "include glob",
+			include: []string{"vulcan-n*"},
+			want:    []string{"vulcan-nessus", "vulcan-nuclei"},
+		},
+		{
+			name:    "exclude takes precedence",
+			include: []string{"vulcan-n*"},
+			exclude: []string{"*nessus"},
+			want:    []string{"vulcan-nuclei"},
+		},
+		{
+			name:    "no matches",
+			include: []string{"vulcan-unknown"},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets := []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Include:    tt.include,
+					Exclude:    tt.exclude,
+				},
+			}
+
+			var got []string
+			for _, c := range generateChecks(catalog, targets) {
+				got = append(got, c.checktype.Name)
+			}
+			slices.Sort(got)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestDedupTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		targets  []config.Target
+		want     []config.Target
+		wantDups []config.Target
+	}{
+		{
+			name: "no duplicates",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName},
+				{Identifier: "example.com", AssetType: types.Hostname},
+			},
+			want: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName},
+				{Identifier: "example.com", AssetType: types.Hostname},
+			},
+			wantDups: nil,
+		},
+		{
+			name: "duplicates with labels",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName, Labels: map[string]string{"a": "1"}},
+				{Identifier: "192.0.2.1", AssetType: types.IP},
+				{Identifier: "example.com", AssetType: types.DomainName, Labels: map[string]string{"b": "2"}},
+				{Identifier: "192.0.2.1", AssetType: types.IP},
+			},
+			want: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName, Labels: map[string]string{"a": "1", "b": "2"}},
+				{Identifier: "192.0.2.1", AssetType: types.IP},
+			},
+			wantDups: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName, Labels: map[string]string{"b": "2"}},
+				{Identifier: "192.0.2.1", AssetType: types.IP},
+			},
+		},
+		{
+			name: "different options",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName, Options: map[string]any{"a": 1}},
+				{Identifier: "example.com", AssetType: types.DomainName, Options: map[string]any{"a": 2}},
+			},
+			want: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName, Options: map[string]any{"a": 1}},
+				{Identifier: "example.com", AssetType: types.DomainName, Options: map[string]any{"a": 2}},
+			},
+			wantDups: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotDups := dedupTargets(tt.targets)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+			if diff := cmp.Diff(tt.wantDups, gotDups); diff != "" {
+				t.Errorf("duplicates mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestGenerateJobs(t *testing.T) {
+	tests := []struct {
+		name           string
+		catalog        checktypes.Catalog
+		targets        []config.Target
+		defaultTimeout int
+		want           []jobrunner.Job
+		wantNilErr     bool
+	}{
+		{
+			name: "one checktype and one target",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+			},
+			want: []jobrunner.Job{
+				{
+					Image:     "namespace/repository:tag",
+					Target:    "example.com",
+					AssetType: "DomainName",
+					Options:   "{}",
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "DockerImage target",
+			catalog: checktypes.Catalog{
+				"vulcan-trivy": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-trivy",
+						Description: "vulcan-trivy description",
+						Image:       "vulcansec/vulcan-trivy:edge",
+						Assets: []string{
+							"DockerImage",
+							"GitRepository",
+						},
+					},
+				},
+				"vulcan-nessus": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-nessus",
+						Description: "vulcan-nessus description",
+						Image:       "vulcansec/vulcan-nessus:edge",
+						Assets: []string{
+							"Hostname",
+						},
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "registry.example.com/namespace/image:1.0",
+					AssetType:  types.DockerImage,
+				},
+			},
+			want: []jobrunner.Job{
+				{
+					Image:     "vulcansec/vulcan-trivy:edge",
+					Target:    "registry.example.com/namespace/image:1.0",
+					AssetType: "DockerImage",
+					Options:   "{}",
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "GitRepository target with credentials",
+			catalog: checktypes.Catalog{
+				"vulcan-trivy": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "vulcan-trivy",
+						Description: "vulcan-trivy description",
+						Image:       "vulcansec/vulcan-trivy:edge",
+						Assets: []string{
+							"GitRepository",
+						},
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "https://example.com/private.git",
+					AssetType:  types.GitRepository,
+					Credentials: &config.Credentials{
+						Token: "secret-token",
+					},
+				},
+			},
+			want: []jobrunner.Job{
+				{
+					Image:     "vulcansec/vulcan-trivy:edge",
+					Target:    "https://example.com/private.git",
+					AssetType: "GitRepository",
+					Options:   "{}",
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "two checktypes and one target",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
+					},
+				},
+				"checktype2": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype2",
+						Description: "checktype2 description",
+						Image:       "namespace2/repository2:tag",
+						Assets: []string{
+							"DomainName",
+						},
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+			},
+			want: []jobrunner.Job{
+				{
+					Image:     "namespace/repository:tag",
+					Target:    "example.com",
+					AssetType: "DomainName",
+					Options:   "{}",
+				},
+				{
+					Image:     "namespace2/repository2:tag",
+					Target:    "example.com",
+					AssetType: "DomainName",
+					Options:   "{}",
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "one checktype and one target with valid required vars",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
+						RequiredVars: []any{
+							"REQUIRED_VAR_1",
+							"REQUIRED_VAR_2",
+						},
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+			},
+			want: []jobrunner.Job{
+				{
+					Image:     "namespace/repository:tag",
+					Target:    "example.com",
+					AssetType: "DomainName",
+					Options:   "{}",
+					RequiredVars: []string{
+						"REQUIRED_VAR_1",
+						"REQUIRED_VAR_2",
+					},
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "one checktype and one target with labels",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Labels: map[string]string{
+						"owner": "team-a",
+					},
+				},
+			},
+			want: []jobrunner.Job{
+				{
+					Image:     "namespace/repository:tag",
+					Target:    "example.com",
+					AssetType: "DomainName",
+					Options:   "{}",
+					Metadata: map[string]string{
+						"owner": "team-a",
+					},
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "checktype timeout",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Checktype: checkcatalog.Checktype{
+						Name:    "checktype1",
+						Image:   "namespace/repository:tag",
+						Timeout: 600,
+						Assets: []string{
+							"DomainName",
+						},
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+			},
+			defaultTimeout: 180,
+			want: []jobrunner.Job{
+				{
+					Image:     "namespace/repository:tag",
+					Target:    "example.com",
+					Timeout:   600,
+					AssetType: "DomainName",
+					Options:   "{}",
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "default timeout",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Checktype: checkcatalog.Checktype{
+						Name:  "checktype1",
+						Image: "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+			},
+			defaultTimeout: 180,
+			want: []jobrunner.Job{
+				{
+					Image:     "namespace/repository:tag",
+					Target:    "example.com",
+					Timeout:   180,
+					AssetType: "DomainName",
+					Options:   "{}",
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "one checktype and one target with invalid required vars",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
+						RequiredVars: []int{
+							1,
+							2,
+						},
 					},
 				},
 			},
@@ -632,7 +1368,7 @@ func TestGenerateJobs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := generateJobs(tt.catalog, tt.targets)
+			got, err := generateJobs(generateChecks(tt.catalog, tt.targets), tt.defaultTimeout)
 			if (err == nil) != tt.wantNilErr {
 				t.Fatalf("unexpected error value: %v", err)
 			}
@@ -0,0 +1,104 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// ObserverFunc is called every time a check sends its report. The
+// provided report is shared among all the observers, so it must not
+// be modified.
+type ObserverFunc func(checkID string, r CheckReport)
+
+// observerBufferSize is the maximum number of reports that can be
+// queued for a single observer. When the buffer is full, new reports
+// are dropped for that observer.
+const observerBufferSize = 64
+
+// observerSet is a set of observers that are notified about the
+// reports received by the engine. Every observer runs in its own
+// goroutine and has its own bounded buffer, so a slow observer does
+// not block the others.
+type observerSet struct {
+	mu   sync.Mutex
+	next int
+	obs  map[int]*observer
+}
+
+// observer is a registered [ObserverFunc].
+type observer struct {
+	fn ObserverFunc
+	ch chan observerEvent
+
+	// stopped is closed when all the reports queued for the
+	// observer have been delivered.
+	stopped chan struct{}
+}
+
+// observerEvent is a report pending to be delivered to an observer.
+type observerEvent struct {
+	checkID string
+	report  CheckReport
+}
+
+// add registers the provided observer. It returns a function that
+// unregisters it. Reports already queued for the observer are
+// delivered before it stops and the returned function waits for
+// them, so it must not be called from the observer itself.
+func (s *observerSet) add(fn ObserverFunc) (remove func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.obs == nil {
+		s.obs = make(map[int]*observer)
+	}
+
+	id := s.next
+	s.next++
+
+	o := &observer{
+		fn:      fn,
+		ch:      make(chan observerEvent, observerBufferSize),
+		stopped: make(chan struct{}),
+	}
+	s.obs[id] = o
+
+	go func() {
+		defer close(o.stopped)
+		for ev := range o.ch {
+			o.fn(ev.checkID, ev.report)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.obs, id)
+			close(o.ch)
+			s.mu.Unlock()
+		})
+		<-o.stopped
+	}
+}
+
+// notify sends the provided report to all the registered observers.
+// It never blocks.
+func (s *observerSet) notify(checkID string, r CheckReport) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, o := range s.obs {
+		select {
+		case o.ch <- observerEvent{checkID: checkID, report: r}:
+		default:
+			slog.Warn("observer buffer is full, dropping report", "checkID", checkID)
+		}
+	}
+}
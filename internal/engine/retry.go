@@ -0,0 +1,154 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/jobrunner"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/metrics"
+)
+
+// retryInterval is the base time between retry rounds. The waiting
+// time increases exponentially with every round and is jittered. It
+// is a variable, so tests can modify it.
+var retryInterval = 5 * time.Second
+
+// maxRetryInterval is the maximum time between retry rounds before
+// applying jitter.
+const maxRetryInterval = time.Minute
+
+// defaultRetryStatuses are the statuses of the checks that are
+// retried when [config.RetryConfig.Statuses] is empty.
+var defaultRetryStatuses = []string{"FAILED", "TIMEOUT"}
+
+// retryBudget keeps track of the retries consumed during a run.
+type retryBudget struct {
+	// maxRetries is the maximum number of retries per check.
+	maxRetries int
+
+	// budget is the maximum number of retries shared by all the
+	// checks. Zero means no limit.
+	budget int
+
+	// attempts contains the number of retries consumed by every
+	// check, indexed by check ID.
+	attempts map[string]int
+
+	// used is the total number of retries consumed.
+	used int
+
+	// exhausted reports whether a retry has been denied because
+	// the budget is exhausted.
+	exhausted bool
+}
+
+// newRetryBudget returns a [retryBudget] based on the provided retry
+// configuration.
+func newRetryBudget(cfg config.RetryConfig) *retryBudget {
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 1
+	}
+	return &retryBudget{
+		maxRetries: maxRetries,
+		budget:     cfg.Budget,
+		attempts:   make(map[string]int),
+	}
+}
+
+// take consumes a retry for the specified check. It reports whether
+// the check can be retried.
+func (b *retryBudget) take(checkID string) bool {
+	if b.attempts[checkID] >= b.maxRetries {
+		return false
+	}
+	if b.budget > 0 && b.used >= b.budget {
+		b.exhausted = true
+		return false
+	}
+	b.attempts[checkID]++
+	b.used++
+	return true
+}
+
+// retryBackoff returns the time to wait before the specified retry
+// round, starting at 1. The returned duration is in the range
+// [d/2, 3d/2), where d is [retryInterval] doubled for every round and
+// capped to [maxRetryInterval].
+func retryBackoff(round int) time.Duration {
+	d := retryInterval
+	for i := 1; i < round && d < maxRetryInterval; i++ {
+		d *= 2
+	}
+	if d > maxRetryInterval {
+		d = maxRetryInterval
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
+
+// retryFailed retries the failed checks of the provided report
+// according to the configured retry policy and returns the updated
+// report. A check is considered failed if its status is one of the
+// configured retry statuses. The reports of the retried checks are replaced with the
+// reports of the last attempt. When the retry budget is exhausted,
+// the remaining failed checks are reported as they are. The provided
+// [checkpointer] can be nil. When the provided context is done, no
+// more retry rounds are started. See [Engine.runAgent].
+func (eng Engine) retryFailed(ctx context.Context, rep Report, jobs []jobrunner.Job, creds map[string]config.Credentials, cpr *checkpointer) (Report, error) {
+	budget := newRetryBudget(*eng.retry)
+	statuses := eng.retry.Statuses
+	if len(statuses) == 0 {
+		statuses = defaultRetryStatuses
+	}
+loop:
+	for round := 1; ; round++ {
+		var retry []jobrunner.Job
+		for _, job := range jobs {
+			r, ok := rep[job.CheckID]
+			if !ok || !slices.Contains(statuses, r.Status) {
+				continue
+			}
+			if budget.take(job.CheckID) {
+				retry = append(retry, job)
+			}
+		}
+		if len(retry) == 0 {
+			break loop
+		}
+
+		wait := retryBackoff(round)
+		slog.Info("retrying failed checks", "round", round, "checks", len(retry), "wait", wait)
+		select {
+		case <-ctx.Done():
+			slog.Info("scan paused, failed checks will not be retried")
+			break loop
+		case <-time.After(wait):
+		}
+
+		retryRep, err := eng.runAgent(ctx, retry, creds, cpr)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(rep, retryRep)
+	}
+
+	if budget.exhausted {
+		slog.Warn("retry budget exhausted, some failed checks were not retried", "budget", budget.budget)
+	}
+
+	metrics.Collect("retry_count", budget.used)
+	metrics.Collect("retry_budget_exhausted", budget.exhausted)
+
+	return rep, nil
+}
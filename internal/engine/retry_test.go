@@ -0,0 +1,200 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	report "github.com/adevinta/vulcan-report"
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/engine/enginetest"
+)
+
+func TestRetryBudget_take(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           config.RetryConfig
+		checkIDs      []string
+		want          []bool
+		wantUsed      int
+		wantExhausted bool
+	}{
+		{
+			name:     "default max retries",
+			cfg:      config.RetryConfig{},
+			checkIDs: []string{"a", "a", "b"},
+			want:     []bool{true, false, true},
+			wantUsed: 2,
+		},
+		{
+			name:     "per-check max retries",
+			cfg:      config.RetryConfig{MaxRetries: 2},
+			checkIDs: []string{"a", "a", "a", "b"},
+			want:     []bool{true, true, false, true},
+			wantUsed: 3,
+		},
+		{
+			name:          "budget exhausted",
+			cfg:           config.RetryConfig{MaxRetries: 2, Budget: 3},
+			checkIDs:      []string{"a", "b", "a", "b", "c"},
+			want:          []bool{true, true, true, false, false},
+			wantUsed:      3,
+			wantExhausted: true,
+		},
+		{
+			name:     "per-check limit before budget",
+			cfg:      config.RetryConfig{MaxRetries: 1, Budget: 2},
+			checkIDs: []string{"a", "a"},
+			want:     []bool{true, false},
+			wantUsed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newRetryBudget(tt.cfg)
+			for i, id := range tt.checkIDs {
+				if got := b.take(id); got != tt.want[i] {
+					t.Errorf("take %v (%v): got: %v, want: %v", i, id, got, tt.want[i])
+				}
+			}
+			if b.used != tt.wantUsed {
+				t.Errorf("unexpected used retries: got: %v, want: %v", b.used, tt.wantUsed)
+			}
+			if b.exhausted != tt.wantExhausted {
+				t.Errorf("unexpected exhausted: got: %v, want: %v", b.exhausted, tt.wantExhausted)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	oldRetryInterval := retryInterval
+	defer func() { retryInterval = oldRetryInterval }()
+	retryInterval = 10 * time.Second
+
+	tests := []struct {
+		round int
+		base  time.Duration
+	}{
+		{round: 1, base: 10 * time.Second},
+		{round: 2, base: 20 * time.Second},
+		{round: 3, base: 40 * time.Second},
+		{round: 4, base: maxRetryInterval},
+		{round: 10, base: maxRetryInterval},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			got := retryBackoff(tt.round)
+			if got < tt.base/2 || got >= tt.base*3/2 {
+				t.Fatalf("round %v: backoff out of range: %v", tt.round, got)
+			}
+		}
+	}
+}
+
+func TestEngine_Run_retry(t *testing.T) {
+	oldRetryInterval := retryInterval
+	defer func() { retryInterval = oldRetryInterval }()
+	retryInterval = 0
+
+	tests := []struct {
+		name       string
+		retry      *config.RetryConfig
+		failStatus string
+		failures   int
+		wantStatus string
+		wantRuns   int
+	}{
+		{
+			name:       "retried",
+			retry:      &config.RetryConfig{MaxRetries: 2},
+			failStatus: "FAILED",
+			failures:   1,
+			wantStatus: "FINISHED",
+			wantRuns:   2,
+		},
+		{
+			name:       "budget exhausted",
+			retry:      &config.RetryConfig{MaxRetries: 3, Budget: 1},
+			failStatus: "FAILED",
+			failures:   2,
+			wantStatus: "FAILED",
+			wantRuns:   2,
+		},
+		{
+			name:       "timeout retried by default",
+			retry:      &config.RetryConfig{MaxRetries: 2},
+			failStatus: "TIMEOUT",
+			failures:   1,
+			wantStatus: "FINISHED",
+			wantRuns:   2,
+		},
+		{
+			name:       "status not retried",
+			retry:      &config.RetryConfig{MaxRetries: 2, Statuses: []string{"FAILED"}},
+			failStatus: "TIMEOUT",
+			failures:   1,
+			wantStatus: "TIMEOUT",
+			wantRuns:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				attempts int
+			)
+			rt := &enginetest.Runtime{
+				ReportFunc: func(params backend.RunParams) report.Report {
+					mu.Lock()
+					defer mu.Unlock()
+
+					attempts++
+					if attempts <= tt.failures {
+						return report.Report{CheckData: report.CheckData{Status: tt.failStatus}}
+					}
+					return report.Report{}
+				},
+			}
+
+			agentConfig := config.AgentConfig{Retry: tt.retry}
+			eng, err := NewWithRuntime(rt, agentConfig, []config.ChecktypeURL{{URL: "testdata/engine/checktypes_lava_engine_test.json"}})
+			if err != nil {
+				t.Fatalf("engine initialization error: %v", err)
+			}
+			defer eng.Close()
+
+			targets := []config.Target{
+				{
+					Identifier: "https://192.0.2.1",
+					AssetType:  types.WebAddress,
+				},
+			}
+			engineReport, err := eng.Run(targets)
+			if err != nil {
+				t.Fatalf("engine run error: %v", err)
+			}
+
+			if len(engineReport) != 1 {
+				t.Fatalf("unexpected number of reports: %v", len(engineReport))
+			}
+			for _, v := range engineReport {
+				if v.Status != tt.wantStatus {
+					t.Errorf("unexpected status: got: %v, want: %v", v.Status, tt.wantStatus)
+				}
+			}
+
+			if got := len(rt.Runs()); got != tt.wantRuns {
+				t.Errorf("unexpected number of runs: got: %v, want: %v", got, tt.wantRuns)
+			}
+		})
+	}
+}
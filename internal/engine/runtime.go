@@ -0,0 +1,174 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	"github.com/adevinta/vulcan-agent/backend/docker"
+	agentconfig "github.com/adevinta/vulcan-agent/config"
+	"github.com/adevinta/vulcan-agent/log"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+
+	"github.com/adevinta/lava/internal/containers"
+)
+
+// Runtime is the container runtime used by the engine to run the
+// checks. The production implementation is based on a
+// [containers.DockerdClient]. The package enginetest provides an
+// in-memory implementation for testing.
+type Runtime interface {
+	// HostGatewayHostname returns a hostname that points to the
+	// host and is reachable from the checks.
+	HostGatewayHostname() string
+
+	// HostGatewayMapping returns the host-to-IP mapping required
+	// by the checks to reach the host. It returns an empty string
+	// if this mapping is not required.
+	HostGatewayMapping() string
+
+	// HostGatewayInterfaceAddr returns the address of a local
+	// interface that is reachable from the checks.
+	HostGatewayInterfaceAddr() (string, error)
+
+	// Network returns the network the checks are attached to. An
+	// empty string means the default network of the runtime.
+	Network() string
+
+	// DaemonHost returns the address of the container runtime
+	// daemon. It is shared with the checks that require access to
+	// it. An empty string means that the daemon cannot be shared.
+	DaemonHost() string
+
+	// NewBackend returns the Vulcan agent backend used to run
+	// the checks. The provided updater must be called before
+	// running every check.
+	NewBackend(logger log.Logger, cfg agentconfig.Config, updater docker.ConfigUpdater) (backend.Backend, error)
+
+	// Close releases the resources used by the runtime.
+	Close() error
+}
+
+// dockerdRuntime is a [Runtime] backed by a Docker API compatible
+// container runtime.
+type dockerdRuntime struct {
+	*containers.DockerdClient
+
+	// snapshotFailed makes the runtime take a snapshot of the
+	// checks that fail. See [snapshotBackend].
+	snapshotFailed bool
+}
+
+// NewBackend returns a Vulcan agent Docker backend.
+func (rt dockerdRuntime) NewBackend(logger log.Logger, cfg agentconfig.Config, updater docker.ConfigUpdater) (backend.Backend, error) {
+	b, err := docker.NewBackend(logger, cfg, updater)
+	if err != nil {
+		return nil, err
+	}
+	if rt.snapshotFailed {
+		b = snapshotBackend{Backend: b, cli: rt.DockerdClient}
+	}
+	return b, nil
+}
+
+// SignalCheck sends the provided signal to the containers of the
+// check with the specified ID.
+func (rt dockerdRuntime) SignalCheck(ctx context.Context, checkID, signal string) error {
+	conts, err := rt.ContainerList(ctx, container.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", "CheckID="+checkID)),
+	})
+	if err != nil {
+		return fmt.Errorf("container list: %w", err)
+	}
+	for _, c := range conts {
+		if err := rt.ContainerKill(ctx, c.ID, signal); err != nil {
+			return fmt.Errorf("container kill: %w", err)
+		}
+	}
+	return nil
+}
+
+// snapshotBackend is a [backend.Backend] that takes a snapshot of
+// the checks that exit with a non-zero exit code, so they can be
+// inspected after the scan. The Docker backend of the Vulcan agent
+// always removes the containers of the checks. However, it does not
+// remove them until the result of the check is received. So, the
+// container is committed into an image before delivering the result.
+// Only the image outlives the scan.
+type snapshotBackend struct {
+	backend.Backend
+	cli *containers.DockerdClient
+}
+
+// Run runs the check with the provided parameters using the
+// underlying backend. If the container of the check exits with a
+// non-zero exit code, a snapshot is taken before returning the
+// result of the check.
+func (b snapshotBackend) Run(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+	evctx, cancel := context.WithCancel(context.Background())
+	msgs, errs := b.cli.Events(evctx, types.EventsOptions{
+		Since: strconv.FormatInt(time.Now().Unix(), 10),
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("event", string(events.ActionDie)),
+			filters.Arg("label", "CheckID="+params.CheckID),
+		),
+	})
+
+	inner, err := b.Backend.Run(ctx, params)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The channel is buffered, so the goroutine does not block if
+	// the agent stops reading the result. For instance, because
+	// the context is canceled.
+	res := make(chan backend.RunResult, 1)
+	go func() {
+		defer cancel()
+
+		select {
+		case msg := <-msgs:
+			if msg.Actor.Attributes["exitCode"] != "0" {
+				b.snapshot(params, msg.Actor.ID)
+			}
+			res <- <-inner
+		case err := <-errs:
+			slog.Warn("could not watch check container", "check", params.CheckID, "err", err)
+			res <- <-inner
+		case r := <-inner:
+			res <- r
+		}
+	}()
+	return res, nil
+}
+
+// snapshot commits the provided container into an image labeled as
+// managed by Lava.
+func (b snapshotBackend) snapshot(params backend.RunParams, contID string) {
+	ref := "lava-failed-check:" + params.CheckID
+	opts := container.CommitOptions{
+		Reference: ref,
+		Comment:   fmt.Sprintf("Failed check %v (%v)", params.CheckID, params.CheckTypeName),
+		Config: &container.Config{
+			Labels: map[string]string{
+				containers.ManagedLabel: "true",
+				"CheckID":               params.CheckID,
+			},
+		},
+	}
+	if _, err := b.cli.ContainerCommit(context.Background(), contID, opts); err != nil {
+		slog.Error("could not snapshot failed check", "check", params.CheckID, "err", err)
+		return
+	}
+	slog.Warn("snapshotted failed check", "checktype", params.CheckTypeName, "check", params.CheckID, "image", ref)
+}
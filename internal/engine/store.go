@@ -3,38 +3,68 @@
 package engine
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/adevinta/vulcan-agent/stateupdater"
 	"github.com/adevinta/vulcan-agent/storage"
 	report "github.com/adevinta/vulcan-report"
+
+	"github.com/adevinta/lava/internal/config"
 )
 
-// reportStore stores the reports generated by the Vulcan agent in
-// memory. It implements [storage.Store].
+// reportStore stores the reports and logs generated by the Vulcan
+// agent in memory. It implements [storage.Store].
 type reportStore struct {
 	mu      sync.Mutex
 	reports map[string]report.Report
+	logs    map[string][]byte
+
+	// obs are notified about every received report. It can be
+	// nil.
+	obs *observerSet
+
+	// checkReport converts the received reports into the
+	// reports notified to the observers. If nil, the received
+	// reports are notified as they are.
+	checkReport func(checkID string, r report.Report) CheckReport
+
+	// maxFindings is the maximum number of findings accepted per
+	// report. Zero means no limit.
+	maxFindings int
+
+	// maxLogSize is the maximum number of bytes of logs stored
+	// per check. Zero means no limit.
+	maxLogSize int
+
+	// dir is the directory where every received report is also
+	// written as a JSON file named after the check ID. If empty,
+	// the reports are only kept in memory.
+	dir string
 }
 
 var _ storage.Store = &reportStore{}
 
 // UploadCheckData decodes the provided content and stores it in
 // memory indexed by checkID. If kind is "reports", it decodes content
-// as [report.Report]. If kind is "logs", the data is ignored.
+// as [report.Report] and notifies the observers of the store. If the
+// report contains more findings than allowed, the exceeding findings
+// are discarded and a note is added to the report. If the store has a
+// directory, the report is also written to disk. Disk errors are
+// logged but not returned. If kind is "logs", the content replaces
+// the stored logs of the check. See [reportStore.Logs].
 func (rs *reportStore) UploadCheckData(checkID, kind string, startedAt time.Time, content []byte) (link string, err error) {
-	rs.mu.Lock()
-	defer rs.mu.Unlock()
-
 	logger := slog.With("checkID", checkID)
 
-	if rs.reports == nil {
-		rs.reports = make(map[string]report.Report)
-	}
-
 	switch kind {
 	case "reports":
 		logger.Debug("received reports from check", "content", fmt.Sprintf("%#q", content))
@@ -43,28 +73,163 @@ func (rs *reportStore) UploadCheckData(checkID, kind string, startedAt time.Time
 		if err := r.UnmarshalJSONTimeAsString(content); err != nil {
 			return "", fmt.Errorf("decode content: %w", err)
 		}
+
+		if rs.maxFindings > 0 && len(r.Vulnerabilities) > rs.maxFindings {
+			n := len(r.Vulnerabilities) - rs.maxFindings
+			logger.Warn("too many findings, discarding", "max", rs.maxFindings, "discarded", n)
+			r.Vulnerabilities = r.Vulnerabilities[:rs.maxFindings]
+			r.Notes = addNote(r.Notes, fmt.Sprintf("lava: %v findings discarded: findings limit (%v) exceeded", n, rs.maxFindings))
+		}
+
+		rs.mu.Lock()
+		if rs.reports == nil {
+			rs.reports = make(map[string]report.Report)
+		}
 		rs.reports[checkID] = r
+		rs.mu.Unlock()
+
+		if rs.dir != "" {
+			if err := writeReportFile(rs.dir, checkID, r); err != nil {
+				logger.Error("could not write report to disk", "dir", rs.dir, "err", err)
+			}
+		}
+
+		// Observers are notified without holding the lock.
+		cr := CheckReport{Report: r}
+		if rs.checkReport != nil {
+			cr = rs.checkReport(checkID, r)
+		}
+		rs.obs.notify(checkID, cr)
 	case "logs":
 		logger.Debug("received logs from check", "content", fmt.Sprintf("%#q", content))
+
+		logs := truncateLogs(content, rs.maxLogSize)
+
+		rs.mu.Lock()
+		if rs.logs == nil {
+			rs.logs = make(map[string][]byte)
+		}
+		rs.logs[checkID] = logs
+		rs.mu.Unlock()
 	default:
 		return "", fmt.Errorf("unknown data kind: %v", kind)
 	}
 	return "", nil
 }
 
-// Summary returns a human-readable summary per report.
+// truncateLogs returns a copy of the provided logs with at most max
+// bytes. If the logs are longer, the last max bytes are kept, because
+// the end of the logs usually explains why a check failed, and a
+// truncation marker is prepended. Zero means no limit.
+func truncateLogs(logs []byte, max int) []byte {
+	if max <= 0 || len(logs) <= max {
+		return slices.Clone(logs)
+	}
+	n := len(logs) - max
+	marker := fmt.Sprintf("[lava: %v bytes truncated]\n", n)
+	return append([]byte(marker), logs[n:]...)
+}
+
+// addNote appends the provided note to notes.
+func addNote(notes, note string) string {
+	if notes == "" {
+		return note
+	}
+	return notes + "\n" + note
+}
+
+// checkSummary is the summary of a report.
+type checkSummary struct {
+	CheckID   string                  `json:"check_id"`
+	Checktype string                  `json:"checktype"`
+	Target    string                  `json:"target"`
+	StartTime time.Time               `json:"start_time"`
+	Status    string                  `json:"status"`
+	Findings  map[config.Severity]int `json:"findings"`
+}
+
+// String returns a human-readable representation of the summary.
+func (s checkSummary) String() string {
+	return fmt.Sprintf("checktype=%v target=%v start=%v status=%v", s.Checktype, s.Target, s.StartTime, s.Status)
+}
+
+// Summary returns a human-readable summary per report. See
+// [reportStore.SummaryJSON].
 func (rs *reportStore) Summary() []string {
+	var sums []string
+	for _, s := range rs.SummaryJSON() {
+		sums = append(sums, s.String())
+	}
+	return sums
+}
+
+// SummaryJSON returns a summary per report that can be encoded as
+// JSON. It includes the number of findings per severity. The
+// summaries are sorted by checktype, target and check ID.
+func (rs *reportStore) SummaryJSON() []checkSummary {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
-	var sums []string
-	for _, r := range rs.reports {
-		s := fmt.Sprintf("checktype=%v target=%v start=%v status=%v", r.ChecktypeName, r.Target, r.StartTime, r.Status)
+	var sums []checkSummary
+	for checkID, r := range rs.reports {
+		findings := make(map[config.Severity]int)
+		for _, v := range r.Vulnerabilities {
+			findings[config.ScoreToSeverity(v.Score)]++
+		}
+
+		s := checkSummary{
+			CheckID:   checkID,
+			Checktype: r.ChecktypeName,
+			Target:    r.Target,
+			StartTime: r.StartTime,
+			Status:    r.Status,
+			Findings:  findings,
+		}
 		sums = append(sums, s)
 	}
+
+	slices.SortFunc(sums, func(a, b checkSummary) int {
+		if c := cmp.Compare(a.Checktype, b.Checktype); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Target, b.Target); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.CheckID, b.CheckID)
+	})
 	return sums
 }
 
+// Progress returns the number of reports with a terminal status and
+// the average duration of the corresponding checks. Reports without
+// start or end time are not considered to calculate the average
+// duration.
+func (rs *reportStore) Progress() (done int, avg time.Duration) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	var (
+		total time.Duration
+		n     int
+	)
+	for _, r := range rs.reports {
+		if _, ok := stateupdater.TerminalStatuses[r.Status]; !ok {
+			continue
+		}
+		done++
+
+		if r.StartTime.IsZero() || r.EndTime.IsZero() {
+			continue
+		}
+		total += r.EndTime.Sub(r.StartTime)
+		n++
+	}
+	if n > 0 {
+		avg = total / time.Duration(n)
+	}
+	return done, avg
+}
+
 // Reports returns the stored reports.
 func (rs *reportStore) Reports() map[string]report.Report {
 	rs.mu.Lock()
@@ -72,3 +237,132 @@ func (rs *reportStore) Reports() map[string]report.Report {
 
 	return maps.Clone(rs.reports)
 }
+
+// MarshalJSON implements [json.Marshaler]. The stored reports are
+// encoded as a JSON object whose keys are the check IDs. The keys are
+// sorted, so the output is deterministic and the documents of
+// different runs can be compared.
+func (rs *reportStore) MarshalJSON() ([]byte, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	reports := rs.reports
+	if reports == nil {
+		reports = make(map[string]report.Report)
+	}
+	return json.Marshal(reports)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. It decodes a document
+// generated by [reportStore.MarshalJSON] and replaces the stored
+// reports with the decoded ones.
+func (rs *reportStore) UnmarshalJSON(data []byte) error {
+	var reports map[string]report.Report
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return err
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.reports = reports
+	return nil
+}
+
+// Logs returns the stored logs of the specified check. It reports
+// whether the logs exist.
+func (rs *reportStore) Logs(checkID string) ([]byte, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	logs, ok := rs.logs[checkID]
+	return slices.Clone(logs), ok
+}
+
+// Report returns the stored report of the specified check. It
+// reports whether the report exists. Unlike [reportStore.Reports], it
+// does not copy the whole set of reports, so it is suitable to poll
+// the result of a single check while the scan is running.
+func (rs *reportStore) Report(checkID string) (report.Report, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r, ok := rs.reports[checkID]
+	return r, ok
+}
+
+// reportFileExt is the extension of the report files written by a
+// [reportStore] with a directory.
+const reportFileExt = ".json"
+
+// writeReportFile writes the provided report into the file of the
+// specified check in dir. The directory is created if it does not
+// exist. The file is replaced atomically, so it is consistent even
+// if Lava is killed while writing it.
+func writeReportFile(dir, checkID string, r report.Report) error {
+	if checkID == "" || filepath.Base(checkID) != checkID {
+		return fmt.Errorf("invalid check ID: %q", checkID)
+	}
+
+	data, err := r.MarshalJSONTimeAsString()
+	if err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "report-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("write report: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close report: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), filepath.Join(dir, checkID+reportFileExt)); err != nil {
+		return fmt.Errorf("rename report: %w", err)
+	}
+	return nil
+}
+
+// loadReportStore returns a [reportStore] with the reports stored in
+// the provided directory. See [reportStore.UploadCheckData]. The
+// returned store keeps writing the received reports to the
+// directory. If the directory does not exist, the store is empty.
+func loadReportStore(dir string) (*reportStore, error) {
+	rs := &reportStore{dir: dir, reports: make(map[string]report.Report)}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rs, nil
+		}
+		return nil, fmt.Errorf("read directory: %w", err)
+	}
+
+	for _, e := range entries {
+		if !e.Type().IsRegular() || filepath.Ext(e.Name()) != reportFileExt {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read report: %w", err)
+		}
+
+		var r report.Report
+		if err := r.UnmarshalJSONTimeAsString(data); err != nil {
+			return nil, fmt.Errorf("decode report %v: %w", e.Name(), err)
+		}
+		rs.reports[strings.TrimSuffix(e.Name(), reportFileExt)] = r
+	}
+	return rs, nil
+}
@@ -3,13 +3,19 @@
 package engine
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	report "github.com/adevinta/vulcan-report"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/adevinta/lava/internal/config"
 )
 
 func TestReportStoreUploadCheckData(t *testing.T) {
@@ -147,3 +153,583 @@ func TestReportStoreSummary(t *testing.T) {
 		}
 	}
 }
+
+func TestReportStoreUploadCheckData_observers(t *testing.T) {
+	checkIDs := []string{"check1", "check2", "check3"}
+
+	var (
+		obs = &observerSet{}
+		rs  = reportStore{obs: obs}
+		wg  sync.WaitGroup
+	)
+
+	got := make([][]string, 2)
+	for i := range got {
+		i := i
+		wg.Add(len(checkIDs))
+		unregister := obs.add(func(checkID string, r CheckReport) {
+			got[i] = append(got[i], checkID)
+			wg.Done()
+		})
+		defer unregister()
+	}
+
+	for _, checkID := range checkIDs {
+		r := report.Report{CheckData: report.CheckData{CheckID: checkID}}
+		content, err := r.MarshalJSONTimeAsString()
+		if err != nil {
+			t.Fatalf("unexpected marshal error: %v", err)
+		}
+		if _, err := rs.UploadCheckData(checkID, "reports", time.Now(), content); err != nil {
+			t.Fatalf("unexpected upload error: %v", err)
+		}
+	}
+
+	wg.Wait()
+
+	for i, g := range got {
+		if diff := cmp.Diff(checkIDs, g); diff != "" {
+			t.Errorf("observer %v: check IDs mismatch (-want +got):\n%v", i, diff)
+		}
+	}
+}
+
+func TestObserverSet_unregister(t *testing.T) {
+	var (
+		obs   = &observerSet{}
+		calls atomic.Int32
+	)
+
+	unregister := obs.add(func(checkID string, r CheckReport) {
+		calls.Add(1)
+	})
+	unregister()
+	unregister()
+
+	obs.notify("check1", CheckReport{})
+
+	if n := len(obs.obs); n != 0 {
+		t.Errorf("unexpected number of observers: %v", n)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("unexpected number of calls: %v", n)
+	}
+}
+
+func TestObserverSet_unregister_pending(t *testing.T) {
+	var (
+		obs     = &observerSet{}
+		release = make(chan struct{})
+		got     []string
+	)
+
+	unregister := obs.add(func(checkID string, r CheckReport) {
+		<-release
+		got = append(got, checkID)
+	})
+
+	checkIDs := []string{"check1", "check2", "check3"}
+	for _, checkID := range checkIDs {
+		obs.notify(checkID, CheckReport{})
+	}
+	close(release)
+
+	// unregister must not return until the queued reports have
+	// been delivered.
+	unregister()
+
+	if diff := cmp.Diff(checkIDs, got); diff != "" {
+		t.Errorf("check IDs mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestReportStoreUploadCheckData_maxFindings(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxFindings int
+		nvulns      int
+		notes       string
+		wantVulns   int
+		wantNotes   string
+	}{
+		{
+			name:        "below limit",
+			maxFindings: 3,
+			nvulns:      2,
+			wantVulns:   2,
+			wantNotes:   "",
+		},
+		{
+			name:        "limit exceeded",
+			maxFindings: 3,
+			nvulns:      5,
+			wantVulns:   3,
+			wantNotes:   "lava: 2 findings discarded: findings limit (3) exceeded",
+		},
+		{
+			name:        "limit exceeded with notes",
+			maxFindings: 3,
+			nvulns:      5,
+			notes:       "check notes",
+			wantVulns:   3,
+			wantNotes:   "check notes\nlava: 2 findings discarded: findings limit (3) exceeded",
+		},
+		{
+			name:        "no limit",
+			maxFindings: 0,
+			nvulns:      5,
+			wantVulns:   5,
+			wantNotes:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := report.Report{
+				CheckData: report.CheckData{CheckID: "check1"},
+				ResultData: report.ResultData{
+					Vulnerabilities: make([]report.Vulnerability, tt.nvulns),
+					Notes:           tt.notes,
+				},
+			}
+			content, err := r.MarshalJSONTimeAsString()
+			if err != nil {
+				t.Fatalf("unexpected marshal error: %v", err)
+			}
+
+			rs := reportStore{maxFindings: tt.maxFindings}
+			if _, err := rs.UploadCheckData(r.CheckID, "reports", time.Now(), content); err != nil {
+				t.Fatalf("unexpected upload error: %v", err)
+			}
+
+			got := rs.Reports()[r.CheckID]
+			if n := len(got.Vulnerabilities); n != tt.wantVulns {
+				t.Errorf("unexpected number of vulnerabilities: got: %v, want: %v", n, tt.wantVulns)
+			}
+			if got.Notes != tt.wantNotes {
+				t.Errorf("unexpected notes: got: %q, want: %q", got.Notes, tt.wantNotes)
+			}
+		})
+	}
+}
+
+func TestReportStoreProgress(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	reports := []report.Report{
+		{
+			CheckData: report.CheckData{
+				CheckID:   "check1",
+				Status:    "FINISHED",
+				StartTime: start,
+				EndTime:   start.Add(10 * time.Second),
+			},
+		},
+		{
+			CheckData: report.CheckData{
+				CheckID:   "check2",
+				Status:    "FAILED",
+				StartTime: start,
+				EndTime:   start.Add(20 * time.Second),
+			},
+		},
+		{
+			CheckData: report.CheckData{
+				CheckID: "check3",
+				Status:  "INCONCLUSIVE",
+			},
+		},
+		{
+			CheckData: report.CheckData{
+				CheckID:   "check4",
+				Status:    "RUNNING",
+				StartTime: start,
+			},
+		},
+	}
+
+	var rs reportStore
+	for _, r := range reports {
+		content, err := r.MarshalJSONTimeAsString()
+		if err != nil {
+			t.Fatalf("unexpected marshal error: %v", err)
+		}
+		if _, err := rs.UploadCheckData(r.CheckID, "reports", time.Now(), content); err != nil {
+			t.Fatalf("unexpected upload error: %v", err)
+		}
+	}
+
+	done, avg := rs.Progress()
+	if done != 3 {
+		t.Errorf("unexpected number of completed checks: got: %v, want: %v", done, 3)
+	}
+	if want := 15 * time.Second; avg != want {
+		t.Errorf("unexpected average duration: got: %v, want: %v", avg, want)
+	}
+}
+
+func TestReportStoreReport(t *testing.T) {
+	want := report.Report{
+		CheckData: report.CheckData{
+			CheckID:       "check1",
+			ChecktypeName: "vulcan-drupal",
+			Status:        "FINISHED",
+		},
+	}
+
+	var rs reportStore
+	content, err := want.MarshalJSONTimeAsString()
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if _, err := rs.UploadCheckData(want.CheckID, "reports", time.Now(), content); err != nil {
+		t.Fatalf("unexpected upload error: %v", err)
+	}
+
+	got, ok := rs.Report("check1")
+	if !ok {
+		t.Fatal("report not found")
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%v", diff)
+	}
+
+	if _, ok := rs.Report("unknown"); ok {
+		t.Error("unexpected report for unknown check")
+	}
+}
+
+func TestReportStoreLogs(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxLogSize int
+		logs       string
+		want       string
+	}{
+		{
+			name:       "no limit",
+			maxLogSize: 0,
+			logs:       "line 1\nline 2\n",
+			want:       "line 1\nline 2\n",
+		},
+		{
+			name:       "below limit",
+			maxLogSize: 14,
+			logs:       "line 1\nline 2\n",
+			want:       "line 1\nline 2\n",
+		},
+		{
+			name:       "truncated",
+			maxLogSize: 7,
+			logs:       "line 1\nline 2\n",
+			want:       "[lava: 7 bytes truncated]\nline 2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := reportStore{maxLogSize: tt.maxLogSize}
+			if _, err := rs.UploadCheckData("check1", "logs", time.Now(), []byte(tt.logs)); err != nil {
+				t.Fatalf("unexpected upload error: %v", err)
+			}
+
+			got, ok := rs.Logs("check1")
+			if !ok {
+				t.Fatal("logs not found")
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("logs mismatch (-want +got):\n%v", diff)
+			}
+
+			if _, ok := rs.Logs("unknown"); ok {
+				t.Error("unexpected logs for unknown check")
+			}
+		})
+	}
+}
+
+func TestReportStoreUploadCheckData_dir(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	reports := []report.Report{
+		{
+			CheckData: report.CheckData{
+				CheckID:       "check1",
+				ChecktypeName: "vulcan-drupal",
+				Status:        "FINISHED",
+				StartTime:     start,
+				EndTime:       start.Add(10 * time.Second),
+			},
+			ResultData: report.ResultData{
+				Vulnerabilities: []report.Vulnerability{
+					{Summary: "Vulnerability 1", Score: 6.9},
+				},
+			},
+		},
+		{
+			CheckData: report.CheckData{
+				CheckID:       "check2",
+				ChecktypeName: "vulcan-nessus",
+				Status:        "RUNNING",
+				StartTime:     start,
+			},
+		},
+	}
+
+	dir := filepath.Join(t.TempDir(), "reports")
+
+	rs := reportStore{dir: dir}
+	for _, r := range reports {
+		content, err := r.MarshalJSONTimeAsString()
+		if err != nil {
+			t.Fatalf("unexpected marshal error: %v", err)
+		}
+		if _, err := rs.UploadCheckData(r.CheckID, "reports", time.Now(), content); err != nil {
+			t.Fatalf("unexpected upload error: %v", err)
+		}
+	}
+
+	// Invalid check IDs are not written to disk, but they are
+	// kept in memory.
+	content, err := reports[0].MarshalJSONTimeAsString()
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if _, err := rs.UploadCheckData("../check3", "reports", time.Now(), content); err != nil {
+		t.Fatalf("unexpected upload error: %v", err)
+	}
+	if _, ok := rs.Report("../check3"); !ok {
+		t.Errorf("report with invalid check ID not stored in memory")
+	}
+
+	got, err := loadReportStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+
+	want := map[string]report.Report{
+		"check1": rs.reports["check1"],
+		"check2": rs.reports["check2"],
+	}
+	if diff := cmp.Diff(want, got.Reports()); diff != "" {
+		t.Errorf("reports mismatch (-want +got):\n%v", diff)
+	}
+	if got.dir != dir {
+		t.Errorf("unexpected directory: got: %v, want: %v", got.dir, dir)
+	}
+}
+
+func TestLoadReportStore(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		want       map[string]report.Report
+		wantNilErr bool
+	}{
+		{
+			name:       "missing directory",
+			files:      nil,
+			want:       map[string]report.Report{},
+			wantNilErr: true,
+		},
+		{
+			name: "other files are ignored",
+			files: map[string]string{
+				"check1.json":      `{"check_id": "check1", "status": "FINISHED"}`,
+				"report-1.tmp":     "partial",
+				"README":           "reports",
+				"subdir/c2.json":   `{"check_id": "c2"}`,
+				"subdir/README.md": "",
+			},
+			want: map[string]report.Report{
+				"check1": {CheckData: report.CheckData{CheckID: "check1", Status: "FINISHED"}},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "malformed report",
+			files: map[string]string{
+				"check1.json": "malformed",
+			},
+			want:       nil,
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "reports")
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("create directory: %v", err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatalf("write file: %v", err)
+				}
+			}
+
+			rs, err := loadReportStore(dir)
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error value: %v", err)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, rs.Reports()); diff != "" {
+				t.Errorf("reports mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestReportStoreMarshalJSON(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	reports := []report.Report{
+		{
+			CheckData: report.CheckData{
+				CheckID:       "check2",
+				ChecktypeName: "vulcan-nessus",
+				Target:        "example.com",
+				Status:        "FINISHED",
+				StartTime:     start,
+				EndTime:       start.Add(10 * time.Second),
+			},
+			ResultData: report.ResultData{
+				Vulnerabilities: []report.Vulnerability{
+					{Summary: "Vulnerability 1", Score: 6.9},
+					{Summary: "Vulnerability 2", Score: 3.9},
+				},
+			},
+		},
+		{
+			CheckData: report.CheckData{
+				CheckID:       "check1",
+				ChecktypeName: "vulcan-drupal",
+				Target:        "https://example.com",
+				Status:        "FAILED",
+				StartTime:     start,
+			},
+		},
+	}
+
+	var rs reportStore
+	for _, r := range reports {
+		content, err := r.MarshalJSONTimeAsString()
+		if err != nil {
+			t.Fatalf("unexpected marshal error: %v", err)
+		}
+		if _, err := rs.UploadCheckData(r.CheckID, "reports", time.Now(), content); err != nil {
+			t.Fatalf("unexpected upload error: %v", err)
+		}
+	}
+
+	data, err := json.Marshal(&rs)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	again, err := json.Marshal(&rs)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if string(data) != string(again) {
+		t.Errorf("non-deterministic output:\n%s\n%s", data, again)
+	}
+
+	var got reportStore
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+
+	if diff := cmp.Diff(rs.Reports(), got.Reports()); diff != "" {
+		t.Errorf("reports mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestReportStoreMarshalJSON_empty(t *testing.T) {
+	var rs reportStore
+	data, err := json.Marshal(&rs)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if string(data) != "{}" {
+		t.Errorf("unexpected output: %s", data)
+	}
+}
+
+func TestReportStoreSummaryJSON(t *testing.T) {
+	reports := []report.Report{
+		{
+			CheckData: report.CheckData{
+				CheckID:       "check2",
+				ChecktypeName: "vulcan-trivy",
+				Target:        "https://example.com/",
+				Status:        "FINISHED",
+			},
+			ResultData: report.ResultData{
+				Vulnerabilities: []report.Vulnerability{
+					{Score: 9.8},
+					{Score: 7.5},
+					{Score: 7.0},
+					{Score: 0},
+				},
+			},
+		},
+		{
+			CheckData: report.CheckData{
+				CheckID:       "check1",
+				ChecktypeName: "vulcan-semgrep",
+				Target:        "https://example.com/",
+				Status:        "RUNNING",
+			},
+		},
+	}
+
+	var rs reportStore
+	for _, r := range reports {
+		content, err := r.MarshalJSONTimeAsString()
+		if err != nil {
+			t.Fatalf("unexpected marshal error: %v", err)
+		}
+		if _, err := rs.UploadCheckData(r.CheckID, "reports", time.Now(), content); err != nil {
+			t.Fatalf("unexpected upload error: %v", err)
+		}
+	}
+
+	want := []checkSummary{
+		{
+			CheckID:   "check1",
+			Checktype: "vulcan-semgrep",
+			Target:    "https://example.com/",
+			Status:    "RUNNING",
+			Findings:  map[config.Severity]int{},
+		},
+		{
+			CheckID:   "check2",
+			Checktype: "vulcan-trivy",
+			Target:    "https://example.com/",
+			Status:    "FINISHED",
+			Findings: map[config.Severity]int{
+				config.SeverityCritical: 1,
+				config.SeverityHigh:     2,
+				config.SeverityInfo:     1,
+			},
+		},
+	}
+
+	got := rs.SummaryJSON()
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("summaries mismatch (-want +got):\n%v", diff)
+	}
+
+	data, err := json.Marshal(got[1].Findings)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if want := `{"critical":1,"high":2,"info":1}`; string(data) != want {
+		t.Errorf("unexpected findings JSON: got: %s, want: %s", data, want)
+	}
+}
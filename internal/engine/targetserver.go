@@ -20,7 +20,6 @@ import (
 
 	"github.com/adevinta/lava/internal/assettypes"
 	"github.com/adevinta/lava/internal/config"
-	"github.com/adevinta/lava/internal/containers"
 	"github.com/adevinta/lava/internal/gitserver"
 )
 
@@ -70,7 +69,7 @@ func (tm targetMap) Addrs() targetMap {
 // targetServer represents Lava's internal target server. It is used
 // to serve local Git repositories and services.
 type targetServer struct {
-	cli     containers.DockerdClient
+	cli     Runtime
 	gs      *gitserver.Server
 	gitAddr string
 	pg      *proxy.Group
@@ -79,13 +78,9 @@ type targetServer struct {
 	maps map[string]targetMap
 }
 
-// newTargetServer returns a new [targetServer].
-func newTargetServer(rt containers.Runtime) (srv *targetServer, err error) {
-	cli, err := containers.NewDockerdClient(rt)
-	if err != nil {
-		return nil, fmt.Errorf("new dockerd client: %w", err)
-	}
-
+// newTargetServer returns a new [targetServer]. The provided
+// [Runtime] is not closed by [targetServer.Close].
+func newTargetServer(cli Runtime) (srv *targetServer, err error) {
 	gs, err := gitserver.New()
 	if err != nil {
 		return nil, fmt.Errorf("new GitServer: %w", err)
@@ -139,6 +134,10 @@ func (srv *targetServer) Handle(key string, target config.Target) (targetMap, er
 		err error
 	)
 	switch target.AssetType {
+	case types.DockerImage:
+		// Docker images are accessed through the Docker
+		// daemon, so there is nothing to serve.
+		return targetMap{}, nil
 	case types.GitRepository:
 		tm, err = srv.handleGitRepo(target)
 	case assettypes.Path:
@@ -279,10 +278,6 @@ func (srv *targetServer) TargetMap(key string) (tm targetMap, ok bool) {
 
 // Close closes the internal Git server and proxy.
 func (srv *targetServer) Close() error {
-	if err := srv.cli.Close(); err != nil {
-		return fmt.Errorf("close dockerd client: %w", err)
-	}
-
 	if err := srv.gs.Close(); err != nil {
 		return fmt.Errorf("close Git server: %w", err)
 	}
@@ -298,7 +298,13 @@ func TestTargetServer_mkIntIdentifier(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			srv, err := newTargetServer(testRuntime)
+			cli, err := containers.NewDockerdClient(testRuntime)
+			if err != nil {
+				t.Fatalf("could not create dockerd client: %v", err)
+			}
+			defer cli.Close()
+
+			srv, err := newTargetServer(dockerdRuntime{DockerdClient: &cli})
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -0,0 +1,94 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/distribution/reference"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/targetsources"
+)
+
+var (
+	// ErrMissingVar means that a variable required by a checktype
+	// is not defined in the agent configuration.
+	ErrMissingVar = errors.New("missing required var")
+
+	// ErrInvalidImage means that the image reference of a
+	// checktype is not valid.
+	ErrInvalidImage = errors.New("invalid image reference")
+)
+
+// Validate performs the static checks of the provided configuration
+// without running any check. If fetchCatalogs is true, the checktype
+// catalogs are retrieved and the checks that would be run are
+// generated and validated too, including the targets provided by the
+// target sources. If the configuration or the catalogs cannot be
+// processed, the first error is returned. Otherwise, all the errors
+// found in the checks are joined into the returned error.
+func Validate(cfg config.Config, fetchCatalogs bool) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("validate config: %w", err)
+	}
+
+	if !fetchCatalogs {
+		return nil
+	}
+
+	catalog, _, err := newCatalog(cfg.ChecktypeURLs, cfg.AgentConfig.PartialCatalogs)
+	if err != nil {
+		return fmt.Errorf("get checktype catalog: %w", err)
+	}
+
+	if err := rewriteImages(catalog, cfg.AgentConfig.ImageTemplate); err != nil {
+		return fmt.Errorf("rewrite images: %w", err)
+	}
+
+	srcTargets, err := targetsources.Targets(cfg.TargetSources)
+	if err != nil {
+		return fmt.Errorf("get targets from sources: %w", err)
+	}
+	targets := cfg.TargetDefaults.Apply(append(slices.Clone(cfg.Targets), srcTargets...))
+	if err := config.ValidateTargets(targets, cfg.TargetDefaults.DetectAssetType); err != nil {
+		return fmt.Errorf("validate targets: %w", err)
+	}
+	if cfg.TargetDefaults.DetectAssetType {
+		if targets, err = config.DetectAssetTypes(targets); err != nil {
+			return fmt.Errorf("detect asset types: %w", err)
+		}
+	}
+
+	jobs, err := generateJobs(generateChecks(catalog, targets), cfg.AgentConfig.Timeout)
+	if err != nil {
+		return fmt.Errorf("generate jobs: %w", err)
+	}
+
+	// Several jobs can share the same checktype. So, every image
+	// and variable is only reported once.
+	var errs []error
+	seen := make(map[string]bool)
+	for _, job := range jobs {
+		if !seen["image:"+job.Image] {
+			seen["image:"+job.Image] = true
+			if _, err := reference.ParseNormalizedNamed(job.Image); err != nil {
+				errs = append(errs, fmt.Errorf("%w: %v: %w", ErrInvalidImage, job.Image, err))
+			}
+		}
+
+		for _, v := range job.RequiredVars {
+			if seen["var:"+v] {
+				continue
+			}
+			seen["var:"+v] = true
+			if _, ok := cfg.AgentConfig.Vars[v]; !ok {
+				errs = append(errs, fmt.Errorf("%w: %v", ErrMissingVar, v))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/checktypes"
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           config.Config
+		fetchCatalogs bool
+		wantErrs      []error
+		wantNilErr    bool
+	}{
+		{
+			name: "valid config without catalogs",
+			cfg: config.Config{
+				LavaVersion:   "v1.0.0",
+				ChecktypeURLs: []config.ChecktypeURL{{URL: "testdata/validate/checktypes.json"}},
+				Targets: []config.Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+			},
+			fetchCatalogs: false,
+			wantNilErr:    true,
+		},
+		{
+			name: "invalid config",
+			cfg: config.Config{
+				LavaVersion: "v1.0.0",
+			},
+			fetchCatalogs: false,
+			wantErrs:      []error{config.ErrNoChecktypeURLs},
+		},
+		{
+			name: "invalid image and missing vars",
+			cfg: config.Config{
+				LavaVersion:   "v1.0.0",
+				ChecktypeURLs: []config.ChecktypeURL{{URL: "testdata/validate/checktypes.json"}},
+				Targets: []config.Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: config.AgentConfig{
+					Vars: map[string]string{
+						"VAR1": "value1",
+					},
+				},
+			},
+			fetchCatalogs: true,
+			wantErrs:      []error{ErrInvalidImage, ErrMissingVar},
+		},
+		{
+			name: "invalid image template",
+			cfg: config.Config{
+				LavaVersion:   "v1.0.0",
+				ChecktypeURLs: []config.ChecktypeURL{{URL: "testdata/validate/checktypes.json"}},
+				Targets: []config.Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: config.AgentConfig{
+					ImageTemplate: &config.ImageTemplate{
+						Template: "registry.example.com/{{.Name}}:{{.Tag}}",
+					},
+				},
+			},
+			fetchCatalogs: true,
+			wantErrs:      []error{checktypes.ErrInvalidImage},
+		},
+		{
+			name: "partial catalogs",
+			cfg: config.Config{
+				LavaVersion: "v1.0.0",
+				ChecktypeURLs: []config.ChecktypeURL{
+					{URL: "testdata/validate/not_found.json"},
+					{URL: "testdata/validate/checktypes.json"},
+				},
+				Targets: []config.Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: config.AgentConfig{
+					PartialCatalogs: true,
+				},
+			},
+			fetchCatalogs: true,
+			wantErrs:      []error{ErrInvalidImage, ErrMissingVar},
+		},
+		{
+			name: "target sources",
+			cfg: config.Config{
+				LavaVersion:   "v1.0.0",
+				ChecktypeURLs: []config.ChecktypeURL{{URL: "testdata/validate/checktypes.json"}},
+				TargetSources: []config.TargetSource{
+					{
+						Type: config.TargetSourceList,
+						URL:  "testdata/validate/targets.txt",
+					},
+				},
+				AgentConfig: config.AgentConfig{
+					Vars: map[string]string{
+						"VAR1": "value1",
+					},
+				},
+			},
+			fetchCatalogs: true,
+			wantErrs:      []error{ErrInvalidImage, ErrMissingVar},
+		},
+		{
+			name: "unreachable target source",
+			cfg: config.Config{
+				LavaVersion:   "v1.0.0",
+				ChecktypeURLs: []config.ChecktypeURL{{URL: "testdata/validate/checktypes.json"}},
+				TargetSources: []config.TargetSource{
+					{
+						Type: config.TargetSourceList,
+						URL:  "testdata/validate/not_found.txt",
+					},
+				},
+			},
+			fetchCatalogs: true,
+			wantNilErr:    false,
+		},
+		{
+			name: "unreachable catalog",
+			cfg: config.Config{
+				LavaVersion:   "v1.0.0",
+				ChecktypeURLs: []config.ChecktypeURL{{URL: "testdata/validate/not_found.json"}},
+				Targets: []config.Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+			},
+			fetchCatalogs: true,
+			wantNilErr:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.cfg, tt.fetchCatalogs)
+			if len(tt.wantErrs) == 0 && (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			for _, wantErr := range tt.wantErrs {
+				if !errors.Is(err, wantErr) {
+					t.Errorf("unexpected error: got: %v, want: %v", err, wantErr)
+				}
+			}
+		})
+	}
+}
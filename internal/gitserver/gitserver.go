@@ -20,6 +20,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"sync"
+
+	"github.com/adevinta/lava/internal/workdir"
 )
 
 // ErrGit is returned by [New] when the git command cannot be run.
@@ -41,7 +43,7 @@ func New() (*Server, error) {
 		return nil, fmt.Errorf("%w: %w", ErrGit, err)
 	}
 
-	tmpPath, err := os.MkdirTemp("", "")
+	tmpPath, err := workdir.MkdirTemp(workdir.Git, "")
 	if err != nil {
 		return nil, fmt.Errorf("make temp dir: %w", err)
 	}
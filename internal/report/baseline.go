@@ -0,0 +1,151 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// BaselineVersion is the version of the baseline file format. It
+// must be increased every time the format changes in a backwards
+// incompatible way.
+const BaselineVersion = "1"
+
+// ErrInvalidBaseline is returned by [ReadBaselineFile] when the
+// format of the baseline file is not valid.
+var ErrInvalidBaseline = errors.New("invalid baseline")
+
+// timeNow is used by tests to set the current time.
+var timeNow = time.Now
+
+// Baseline is a set of known findings identified by their
+// fingerprint. Findings in the baseline do not affect the result of
+// a scan. It allows to adopt Lava in existing projects and gate only
+// on new findings. See [Fingerprint].
+type Baseline struct {
+	// Version is the version of the baseline file format.
+	Version string `json:"version"`
+
+	// Entries are the known findings sorted by fingerprint.
+	Entries []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry is a known finding.
+type BaselineEntry struct {
+	// Fingerprint is the fingerprint of the finding.
+	Fingerprint string `json:"fingerprint"`
+
+	// Checktype is the name of the checktype that found the
+	// finding. It is informational.
+	Checktype string `json:"checktype,omitempty"`
+
+	// Target is the affected target. It is informational.
+	Target string `json:"target,omitempty"`
+
+	// Summary is the summary of the finding. It is
+	// informational.
+	Summary string `json:"summary,omitempty"`
+
+	// Expires is the time after which the entry is ignored. If
+	// nil, the entry does not expire.
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// expired reports whether the entry has expired.
+func (e BaselineEntry) expired() bool {
+	return e.Expires != nil && !timeNow().Before(*e.Expires)
+}
+
+// NewBaseline returns a [Baseline] with the provided findings. If the
+// same finding is found several times, it is included only once. The
+// expiration time of the findings that are also present in prev is
+// preserved.
+func NewBaseline(findings []Finding, prev Baseline) Baseline {
+	expires := make(map[string]*time.Time)
+	for _, e := range prev.Entries {
+		expires[e.Fingerprint] = e.Expires
+	}
+
+	seen := make(map[string]bool)
+	b := Baseline{Version: BaselineVersion}
+	for _, f := range findings {
+		fp := Fingerprint(f)
+		if seen[fp] {
+			continue
+		}
+		seen[fp] = true
+
+		b.Entries = append(b.Entries, BaselineEntry{
+			Fingerprint: fp,
+			Checktype:   f.CheckData.ChecktypeName,
+			Target:      f.CheckData.Target,
+			Summary:     f.Summary,
+			Expires:     expires[fp],
+		})
+	}
+	slices.SortFunc(b.Entries, func(a, b BaselineEntry) int {
+		return cmp.Compare(a.Fingerprint, b.Fingerprint)
+	})
+	return b
+}
+
+// ReadBaselineFile reads the baseline stored in the specified file.
+func ReadBaselineFile(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Baseline{}, fmt.Errorf("read file: %w", err)
+	}
+
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return Baseline{}, fmt.Errorf("%w: %w", ErrInvalidBaseline, err)
+	}
+	if b.Version != BaselineVersion {
+		return Baseline{}, fmt.Errorf("%w: unsupported version %q", ErrInvalidBaseline, b.Version)
+	}
+	for _, e := range b.Entries {
+		if e.Fingerprint == "" {
+			return Baseline{}, fmt.Errorf("%w: missing fingerprint", ErrInvalidBaseline)
+		}
+	}
+	return b, nil
+}
+
+// WriteFile writes the baseline to the specified file.
+func (b Baseline) WriteFile(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode baseline: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	return nil
+}
+
+// baselineIndex contains the entries of a [Baseline] indexed by
+// fingerprint, so findings can be looked up in constant time.
+type baselineIndex map[string]BaselineEntry
+
+// newBaselineIndex returns the index of the provided baseline.
+func newBaselineIndex(b Baseline) baselineIndex {
+	idx := make(baselineIndex, len(b.Entries))
+	for _, e := range b.Entries {
+		idx[e.Fingerprint] = e
+	}
+	return idx
+}
+
+// contains reports whether the index contains a non-expired entry
+// with the specified fingerprint.
+func (idx baselineIndex) contains(fingerprint string) bool {
+	e, ok := idx[fingerprint]
+	return ok && !e.expired()
+}
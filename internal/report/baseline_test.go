@@ -0,0 +1,263 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/engine"
+)
+
+const (
+	knownFingerprint   = "v1:2a8aa944417bf685d157bdb90a58378640a7baea69c2f7feb6439cd5d40f99e1"
+	newFingerprint     = "v1:b2a17597ce33fd0e086cb6a5a3efb4bf45efc5603d9b5d91245f29c741bead44"
+	expiredFingerprint = "v1:e4cf4d4ada1b2d75db4c549f36f1934fe5265393e44f32ea4bbaf3a44f743772"
+)
+
+func TestReadBaselineFile(t *testing.T) {
+	expires := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		file    string
+		want    Baseline
+		wantErr error
+	}{
+		{
+			name: "valid",
+			file: "testdata/baseline/baseline.json",
+			want: Baseline{
+				Version: BaselineVersion,
+				Entries: []BaselineEntry{
+					{
+						Fingerprint: knownFingerprint,
+						Checktype:   "lava-test",
+						Target:      "example.com",
+						Summary:     "Known vulnerability",
+					},
+					{
+						Fingerprint: expiredFingerprint,
+						Checktype:   "lava-test",
+						Target:      "example.com",
+						Summary:     "Expired vulnerability",
+						Expires:     &expires,
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid version",
+			file:    "testdata/baseline/invalid_version.json",
+			wantErr: ErrInvalidBaseline,
+		},
+		{
+			name:    "missing fingerprint",
+			file:    "testdata/baseline/missing_fingerprint.json",
+			wantErr: ErrInvalidBaseline,
+		},
+		{
+			name:    "malformed",
+			file:    "testdata/baseline/malformed.json",
+			wantErr: ErrInvalidBaseline,
+		},
+		{
+			name:    "not exist",
+			file:    "testdata/baseline/not_exist.json",
+			wantErr: os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadBaselineFile(tt.file)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("baseline mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestBaselineIndex_contains(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+
+	b, err := ReadBaselineFile("testdata/baseline/baseline.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	idx := newBaselineIndex(b)
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		fingerprint string
+		want        bool
+	}{
+		{
+			name:        "known",
+			now:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			fingerprint: knownFingerprint,
+			want:        true,
+		},
+		{
+			name:        "new",
+			now:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			fingerprint: newFingerprint,
+			want:        false,
+		},
+		{
+			name:        "not expired",
+			now:         time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			fingerprint: expiredFingerprint,
+			want:        true,
+		},
+		{
+			name:        "expired",
+			now:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			fingerprint: expiredFingerprint,
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeNow = func() time.Time { return tt.now }
+			if got := idx.contains(tt.fingerprint); got != tt.want {
+				t.Errorf("unexpected result: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBaseline(t *testing.T) {
+	expires := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	finding := func(summary string) Finding {
+		return Finding{
+			Vulnerability: vreport.Vulnerability{
+				Summary:          summary,
+				AffectedResource: "resource",
+			},
+			CheckData: vreport.CheckData{
+				ChecktypeName: "lava-test",
+				Target:        "example.com",
+			},
+		}
+	}
+
+	findings := []Finding{
+		finding("New vulnerability"),
+		finding("Expired vulnerability"),
+		finding("New vulnerability"),
+	}
+	prev := Baseline{
+		Version: BaselineVersion,
+		Entries: []BaselineEntry{
+			{
+				Fingerprint: expiredFingerprint,
+				Expires:     &expires,
+			},
+		},
+	}
+
+	want := Baseline{
+		Version: BaselineVersion,
+		Entries: []BaselineEntry{
+			{
+				Fingerprint: newFingerprint,
+				Checktype:   "lava-test",
+				Target:      "example.com",
+				Summary:     "New vulnerability",
+			},
+			{
+				Fingerprint: expiredFingerprint,
+				Checktype:   "lava-test",
+				Target:      "example.com",
+				Summary:     "Expired vulnerability",
+				Expires:     &expires,
+			},
+		},
+	}
+
+	got := NewBaseline(findings, prev)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("baseline mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestWriter_Write_baseline(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	data, err := os.ReadFile("testdata/baseline/report.json")
+	if err != nil {
+		t.Fatalf("could not read report: %v", err)
+	}
+	var er engine.Report
+	if err := json.Unmarshal(data, &er); err != nil {
+		t.Fatalf("could not decode report: %v", err)
+	}
+
+	tmpPath := t.TempDir()
+	cfg := config.ReportConfig{
+		Severity:       config.SeverityHigh,
+		Format:         config.OutputFormatJSON,
+		OutputFile:     filepath.Join(tmpPath, "output.json"),
+		Baseline:       "testdata/baseline/baseline.json",
+		BaselineOutput: filepath.Join(tmpPath, "baseline.json"),
+	}
+
+	writer, err := NewWriter(cfg)
+	if err != nil {
+		t.Fatalf("unable to create a report writer: %v", err)
+	}
+	defer writer.Close()
+
+	res, err := writer.Write(er)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.ExitCode != ExitCodeHigh {
+		t.Errorf("unexpected exit code: got: %v, want: %v", res.ExitCode, ExitCodeHigh)
+	}
+	if res.Baselined != 1 {
+		t.Errorf("unexpected number of baselined findings: got: %v, want: 1", res.Baselined)
+	}
+
+	var gotFingerprints []string
+	for _, f := range res.Findings {
+		gotFingerprints = append(gotFingerprints, Fingerprint(f))
+	}
+	wantFingerprints := []string{newFingerprint, expiredFingerprint}
+	if diff := cmp.Diff(wantFingerprints, gotFingerprints, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("findings mismatch (-want +got):\n%v", diff)
+	}
+
+	b, err := ReadBaselineFile(cfg.BaselineOutput)
+	if err != nil {
+		t.Fatalf("could not read output baseline: %v", err)
+	}
+	var gotBaseline []string
+	for _, e := range b.Entries {
+		gotBaseline = append(gotBaseline, e.Fingerprint)
+	}
+	wantBaseline := []string{knownFingerprint, newFingerprint, expiredFingerprint}
+	if diff := cmp.Diff(wantBaseline, gotBaseline); diff != "" {
+		t.Errorf("output baseline mismatch (-want +got):\n%v", diff)
+	}
+}
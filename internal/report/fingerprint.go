@@ -0,0 +1,49 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// FingerprintVersion is the version of the algorithm used by
+// [Fingerprint]. It is part of the generated fingerprints and it must
+// be increased every time the algorithm changes, so fingerprints
+// generated by different algorithms never collide.
+const FingerprintVersion = "v1"
+
+// Fingerprint returns a stable identifier of the provided finding.
+// The same logical finding produces the same fingerprint across runs
+// and machines. It is calculated from the following attributes:
+//
+//   - The name of the checktype.
+//   - The target.
+//   - The affected resource. If it is empty, the human-readable
+//     affected resource is used.
+//   - The summary of the vulnerability.
+//
+// Other attributes, like the check ID, the checktype version, the
+// score or the details of the vulnerability, do not affect the
+// fingerprint.
+func Fingerprint(f Finding) string {
+	resource := f.AffectedResource
+	if resource == "" {
+		resource = f.AffectedResourceString
+	}
+
+	h := sha256.New()
+	for _, s := range []string{
+		f.CheckData.ChecktypeName,
+		f.CheckData.Target,
+		resource,
+		f.Summary,
+	} {
+		// Length-prefix every attribute, so different
+		// combinations of attributes never produce the same
+		// input.
+		fmt.Fprintf(h, "%d:%s", len(s), s)
+	}
+	return FingerprintVersion + ":" + hex.EncodeToString(h.Sum(nil))
+}
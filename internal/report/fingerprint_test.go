@@ -0,0 +1,134 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"testing"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+var testFinding = Finding{
+	Vulnerability: report.Vulnerability{
+		ID:               "d4f8d7d5-0a2d-4d1a-8a3e-7c3a8b9b6f10",
+		Summary:          "Outdated Drupal",
+		Score:            6.9,
+		AffectedResource: "/CHANGELOG.txt",
+		Details:          "Drupal 7.0 detected.",
+	},
+	CheckData: report.CheckData{
+		CheckID:          "7a3e7d5c-0f1b-4b1e-9a0a-2b5e6d3c8f21",
+		ChecktypeName:    "vulcan-drupal",
+		ChecktypeVersion: "edge",
+		Target:           "example.com",
+		StartTime:        time.Date(2023, 12, 14, 14, 45, 31, 0, time.UTC),
+	},
+	Severity: config.SeverityMedium,
+}
+
+func TestFingerprint_stable(t *testing.T) {
+	// This value must not change unless FingerprintVersion is
+	// increased.
+	want := "v1:fcbe996976054959d3e634f612a1ca414e2c8af81e9757256c1dcb9d0300cfb4"
+
+	if got := Fingerprint(testFinding); got != want {
+		t.Errorf("unexpected fingerprint: got: %v, want: %v", got, want)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(f *Finding)
+		wantSame bool
+	}{
+		{
+			name:     "different check ID",
+			modify:   func(f *Finding) { f.CheckData.CheckID = "e2b1c9a4-6c0d-4f6e-8b7a-1d2c3e4f5a6b" },
+			wantSame: true,
+		},
+		{
+			name:     "different checktype version",
+			modify:   func(f *Finding) { f.CheckData.ChecktypeVersion = "latest" },
+			wantSame: true,
+		},
+		{
+			name:     "different start time",
+			modify:   func(f *Finding) { f.CheckData.StartTime = time.Now() },
+			wantSame: true,
+		},
+		{
+			name:     "different vulnerability ID",
+			modify:   func(f *Finding) { f.ID = "1b9e0c1f-5a3d-4f2a-9e8b-7c6d5e4f3a2b" },
+			wantSame: true,
+		},
+		{
+			name:     "different score",
+			modify:   func(f *Finding) { f.Score = 9.8; f.Severity = config.SeverityCritical },
+			wantSame: true,
+		},
+		{
+			name:     "different details",
+			modify:   func(f *Finding) { f.Details = "Drupal 7.1 detected." },
+			wantSame: true,
+		},
+		{
+			name:     "different labels",
+			modify:   func(f *Finding) { f.TargetLabels = map[string]string{"owner": "team-a"} },
+			wantSame: true,
+		},
+		{
+			name:     "different checktype",
+			modify:   func(f *Finding) { f.CheckData.ChecktypeName = "vulcan-nuclei" },
+			wantSame: false,
+		},
+		{
+			name:     "different target",
+			modify:   func(f *Finding) { f.CheckData.Target = "www.example.com" },
+			wantSame: false,
+		},
+		{
+			name:     "different affected resource",
+			modify:   func(f *Finding) { f.AffectedResource = "/README.txt" },
+			wantSame: false,
+		},
+		{
+			name: "affected resource string fallback",
+			modify: func(f *Finding) {
+				f.AffectedResource = ""
+				f.AffectedResourceString = "/README.txt"
+			},
+			wantSame: false,
+		},
+		{
+			name:     "different summary",
+			modify:   func(f *Finding) { f.Summary = "Outdated WordPress" },
+			wantSame: false,
+		},
+		{
+			name: "shifted attributes",
+			modify: func(f *Finding) {
+				f.CheckData.Target = "example.com/CHANGELOG.txt"
+				f.AffectedResource = ""
+			},
+			wantSame: false,
+		},
+	}
+
+	want := Fingerprint(testFinding)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testFinding
+			tt.modify(&f)
+
+			got := Fingerprint(f)
+			if (got == want) != tt.wantSame {
+				t.Errorf("unexpected fingerprint: got: %v, original: %v, wantSame: %v", got, want, tt.wantSame)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// htmlPrinter represents an HTML report printer. It renders a single
+// self-contained page with the findings grouped by target and
+// severity.
+type htmlPrinter struct{}
+
+var (
+	//go:embed html.tmpl
+	htmlReport string
+
+	// htmlTmplFuncs stores the functions called from the template
+	// used to render the HTML report.
+	htmlTmplFuncs = template.FuncMap{
+		"upper": strings.ToUpper,
+		"trim":  strings.TrimSpace,
+	}
+
+	// htmlTmpl is the template used to render the HTML report.
+	htmlTmpl = template.Must(template.New("").Funcs(htmlTmplFuncs).Parse(htmlReport))
+)
+
+// htmlTarget contains the findings of a target grouped by severity.
+type htmlTarget struct {
+	Target     string
+	Count      int
+	Severities []htmlSeverity
+}
+
+// htmlSeverity contains the findings of a target with a given
+// severity.
+type htmlSeverity struct {
+	Severity string
+	Vulns    []vulnerability
+}
+
+// Print renders the scan results as an HTML page. The findings are
+// grouped by target and, then, by severity. Targets are sorted by
+// name and severities from critical to info. All the fields of the
+// findings are escaped.
+func (prn htmlPrinter) Print(w io.Writer, vulns []vulnerability, summ summary, status []checkStatus) error {
+	var total int
+	for _, n := range summ.count {
+		total += n
+	}
+
+	stats := make(map[string]int)
+	for s := config.SeverityCritical; s >= config.SeverityInfo; s-- {
+		stats[s.String()] = summ.count[s]
+	}
+
+	data := struct {
+		Stats     map[string]int
+		Total     int
+		Excluded  int
+		Baselined int
+		Dropped   int
+		Targets   []htmlTarget
+		Status    []checkStatus
+		Errored   int
+	}{
+		Stats:     stats,
+		Total:     total,
+		Excluded:  summ.excluded,
+		Baselined: summ.baselined,
+		Dropped:   summ.dropped,
+		Targets:   groupByTarget(vulns),
+		Status:    status,
+		Errored:   len(mkCheckErrors(status)),
+	}
+
+	if err := htmlTmpl.ExecuteTemplate(w, "report", data); err != nil {
+		return fmt.Errorf("execute template report: %w", err)
+	}
+	return nil
+}
+
+// groupByTarget groups the provided vulnerabilities by target and
+// severity. The order of the vulnerabilities with the same target and
+// severity is preserved.
+func groupByTarget(vulns []vulnerability) []htmlTarget {
+	bySev := make(map[string]map[config.Severity][]vulnerability)
+	var targets []string
+	for _, v := range vulns {
+		t := v.CheckData.Target
+		if _, ok := bySev[t]; !ok {
+			bySev[t] = make(map[config.Severity][]vulnerability)
+			targets = append(targets, t)
+		}
+		bySev[t][v.Severity] = append(bySev[t][v.Severity], v)
+	}
+	slices.Sort(targets)
+
+	var groups []htmlTarget
+	for _, t := range targets {
+		g := htmlTarget{Target: t}
+		for s := config.SeverityCritical; s >= config.SeverityInfo; s-- {
+			if vs := bySev[t][s]; len(vs) > 0 {
+				g.Severities = append(g.Severities, htmlSeverity{Severity: s.String(), Vulns: vs})
+				g.Count += len(vs)
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
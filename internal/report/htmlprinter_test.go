@@ -0,0 +1,144 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestHTMLPrinter_Print(t *testing.T) {
+	tests := []struct {
+		name        string
+		vulns       []vulnerability
+		summ        summary
+		status      []checkStatus
+		wantContain []string
+		wantAbsent  []string
+	}{
+		{
+			name:   "empty run",
+			vulns:  nil,
+			summ:   summary{},
+			status: nil,
+			wantContain: []string{
+				"<!DOCTYPE html>",
+				"No vulnerabilities found during the scan.",
+				"No status updates received during the scan.",
+				"There are no vulnerabilities to show.",
+			},
+		},
+		{
+			name: "escaped fields",
+			vulns: []vulnerability{
+				{
+					Vulnerability: vreport.Vulnerability{
+						Summary:          `<script>alert("summary")</script>`,
+						Description:      `<img src=x onerror="alert(1)">`,
+						AffectedResource: "a & b",
+						References:       []string{`"><b>reference</b>`},
+					},
+					CheckData: vreport.CheckData{
+						ChecktypeName: "<vulcan-xss>",
+						Target:        "<example.com>",
+					},
+					Severity: config.SeverityHigh,
+				},
+			},
+			summ: summary{count: map[config.Severity]int{config.SeverityHigh: 1}},
+			status: []checkStatus{
+				{Checktype: "<vulcan-xss>", Target: "<example.com>", Status: "FINISHED"},
+			},
+			wantContain: []string{
+				"&lt;script&gt;alert(&#34;summary&#34;)&lt;/script&gt;",
+				"&lt;img src=x onerror=&#34;alert(1)&#34;&gt;",
+				"a &amp; b",
+				"&#34;&gt;&lt;b&gt;reference&lt;/b&gt;",
+				"&lt;vulcan-xss&gt;",
+				"&lt;example.com&gt;",
+			},
+			wantAbsent: []string{
+				"<script>",
+				"<img",
+				"<b>reference",
+				"<vulcan-xss>",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (htmlPrinter{}).Print(&buf, tt.vulns, tt.summ, tt.status); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := buf.String()
+
+			for _, s := range tt.wantContain {
+				if !strings.Contains(got, s) {
+					t.Errorf("output does not contain %q:\n%v", s, got)
+				}
+			}
+			for _, s := range tt.wantAbsent {
+				if strings.Contains(got, s) {
+					t.Errorf("output contains %q:\n%v", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupByTarget(t *testing.T) {
+	mkVuln := func(target, summary string, sev config.Severity) vulnerability {
+		return vulnerability{
+			Vulnerability: vreport.Vulnerability{Summary: summary},
+			CheckData:     vreport.CheckData{Target: target},
+			Severity:      sev,
+		}
+	}
+
+	vulns := []vulnerability{
+		mkVuln("example.org", "vuln1", config.SeverityLow),
+		mkVuln("example.com", "vuln2", config.SeverityLow),
+		mkVuln("example.org", "vuln3", config.SeverityCritical),
+		mkVuln("example.com", "vuln4", config.SeverityLow),
+	}
+
+	want := []htmlTarget{
+		{
+			Target: "example.com",
+			Count:  2,
+			Severities: []htmlSeverity{
+				{
+					Severity: "low",
+					Vulns:    []vulnerability{vulns[1], vulns[3]},
+				},
+			},
+		},
+		{
+			Target: "example.org",
+			Count:  2,
+			Severities: []htmlSeverity{
+				{
+					Severity: "critical",
+					Vulns:    []vulnerability{vulns[2]},
+				},
+				{
+					Severity: "low",
+					Vulns:    []vulnerability{vulns[0]},
+				},
+			},
+		},
+	}
+
+	got := groupByTarget(vulns)
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(vulnerability{})); diff != "" {
+		t.Errorf("groups mismatch (-want +got):\n%v", diff)
+	}
+}
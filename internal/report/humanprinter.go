@@ -53,17 +53,23 @@ func (prn humanPrinter) Print(w io.Writer, vulns []vulnerability, summ summary,
 	}
 
 	data := struct {
-		Stats    map[string]int
-		Total    int
-		Excluded int
-		Vulns    []vulnerability
-		Status   []checkStatus
+		Stats     map[string]int
+		Total     int
+		Excluded  int
+		Baselined int
+		Dropped   int
+		Vulns     []vulnerability
+		Status    []checkStatus
+		Errored   int
 	}{
-		Stats:    stats,
-		Total:    total,
-		Excluded: summ.excluded,
-		Vulns:    vulns,
-		Status:   status,
+		Stats:     stats,
+		Total:     total,
+		Excluded:  summ.excluded,
+		Baselined: summ.baselined,
+		Dropped:   summ.dropped,
+		Vulns:     vulns,
+		Status:    status,
+		Errored:   len(mkCheckErrors(status)),
 	}
 
 	if err := humanTmpl.Execute(w, data); err != nil {
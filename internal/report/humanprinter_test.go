@@ -188,6 +188,57 @@ func TestUserFriendlyPrinter_Print(t *testing.T) {
 				"No vulnerabilities found during the scan.",
 			},
 		},
+		{
+			name:            "Check errors",
+			vulnerabilities: nil,
+			status: []checkStatus{
+				{
+					Checktype: "Check1",
+					Target:    ".",
+					Status:    "FINISHED",
+				},
+				{
+					Checktype: "Check2",
+					Target:    ".",
+					Status:    "FAILED",
+				},
+			},
+			want: []string{
+				"STATUS",
+				"FAILED",
+				"Number of checks that did not finish successfully: 1",
+				"SUMMARY",
+			},
+		},
+		{
+			name: "Baselined vulnerabilities",
+			vulnerabilities: []vulnerability{
+				{
+					Vulnerability: vreport.Vulnerability{
+						Summary: "Vulnerability Summary 1",
+					},
+					Severity:  config.SeverityHigh,
+					Baselined: true,
+				},
+			},
+			summ: summary{
+				baselined: 1,
+			},
+			status: []checkStatus{
+				{
+					Checktype: "Check1",
+					Target:    ".",
+					Status:    "FINISHED",
+				},
+			},
+			want: []string{
+				"SUMMARY",
+				"Number of baselined vulnerabilities not included in the summary table: 1",
+				"VULNERABILITIES",
+				"Vulnerability Summary 1",
+				"BASELINED",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
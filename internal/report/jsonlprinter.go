@@ -0,0 +1,91 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// jsonlPrinter represents a JSON Lines report printer. Every line
+// contains a single finding encoded like in the JSON output format.
+type jsonlPrinter struct{}
+
+// Print renders the scan results in JSON Lines format, writing one
+// finding per line.
+func (prn jsonlPrinter) Print(w io.Writer, vulns []vulnerability, _ summary, _ []checkStatus) error {
+	enc := json.NewEncoder(w)
+	for _, v := range vulns {
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("encode finding: %w", err)
+		}
+	}
+	return nil
+}
+
+// PrintCheck renders the findings of a single check in JSON Lines
+// format, writing one finding per line.
+func (prn jsonlPrinter) PrintCheck(w io.Writer, vulns []vulnerability, _ checkStatus) error {
+	return prn.Print(w, vulns, summary{}, nil)
+}
+
+// jsonlReportsPrinter represents a JSON Lines report printer. Every
+// line contains the report of a single check.
+type jsonlReportsPrinter struct{}
+
+// checkLine is a line of the JSON Lines output with one report per
+// line.
+type checkLine struct {
+	// CheckID is the ID of the check.
+	CheckID string `json:"check_id"`
+
+	// Checktype is the name of the checktype.
+	Checktype string `json:"checktype"`
+
+	// Target is the target of the check.
+	Target string `json:"target"`
+
+	// Status is the status of the check.
+	Status string `json:"status"`
+
+	// Findings are the findings reported by the check. They are
+	// encoded like in the JSON output format.
+	Findings []vulnerability `json:"findings"`
+}
+
+// Print renders the scan results in JSON Lines format, writing the
+// status and the findings of one check per line. Checks are sorted
+// by checktype, target, status and check ID.
+func (prn jsonlReportsPrinter) Print(w io.Writer, vulns []vulnerability, _ summary, status []checkStatus) error {
+	findings := make(map[string][]vulnerability)
+	for _, v := range vulns {
+		findings[v.CheckData.CheckID] = append(findings[v.CheckData.CheckID], v)
+	}
+
+	for _, cs := range status {
+		if err := prn.PrintCheck(w, findings[cs.CheckID], cs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrintCheck renders the status and the findings of a single check
+// in one JSON line.
+func (prn jsonlReportsPrinter) PrintCheck(w io.Writer, vulns []vulnerability, cs checkStatus) error {
+	line := checkLine{
+		CheckID:   cs.CheckID,
+		Checktype: cs.Checktype,
+		Target:    cs.Target,
+		Status:    cs.Status,
+		Findings:  vulns,
+	}
+	if line.Findings == nil {
+		line.Findings = []vulnerability{}
+	}
+	if err := json.NewEncoder(w).Encode(line); err != nil {
+		return fmt.Errorf("encode check: %w", err)
+	}
+	return nil
+}
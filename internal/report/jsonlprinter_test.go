@@ -0,0 +1,188 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestJsonlPrinter_Print(t *testing.T) {
+	tests := []struct {
+		name            string
+		vulnerabilities []vulnerability
+	}{
+		{
+			name: "multiple findings",
+			vulnerabilities: []vulnerability{
+				{
+					Vulnerability: vreport.Vulnerability{
+						Summary:     "Vulnerability Summary 1",
+						Description: "Description with\nnew lines",
+						Score:       9.0,
+					},
+					CheckData: vreport.CheckData{
+						CheckID:       "CheckID1",
+						ChecktypeName: "vulcan-trivy",
+						Target:        "example.com",
+					},
+					Severity: config.SeverityCritical,
+				},
+				{
+					Vulnerability: vreport.Vulnerability{
+						Summary: "Vulnerability Summary 2",
+						Score:   3.9,
+					},
+					CheckData: vreport.CheckData{
+						CheckID:       "CheckID2",
+						ChecktypeName: "vulcan-nuclei",
+						Target:        "example.com",
+					},
+					Severity:  config.SeverityLow,
+					Baselined: true,
+				},
+			},
+		},
+		{
+			name:            "no findings",
+			vulnerabilities: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (jsonlPrinter{}).Print(&buf, tt.vulnerabilities, summary{}, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []vulnerability
+			sc := bufio.NewScanner(&buf)
+			for sc.Scan() {
+				var v vulnerability
+				if err := json.Unmarshal(sc.Bytes(), &v); err != nil {
+					t.Fatalf("unmarshal line %q: %v", sc.Text(), err)
+				}
+				got = append(got, v)
+			}
+			if err := sc.Err(); err != nil {
+				t.Fatalf("scan output: %v", err)
+			}
+
+			diffOpts := []cmp.Option{
+				cmp.AllowUnexported(vulnerability{}),
+			}
+			if diff := cmp.Diff(tt.vulnerabilities, got, diffOpts...); diff != "" {
+				t.Errorf("vulnerabilities mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestJsonlReportsPrinter_Print(t *testing.T) {
+	vulns := []vulnerability{
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary: "Vulnerability Summary 1",
+				Score:   9.0,
+			},
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID1",
+				ChecktypeName: "vulcan-trivy",
+				Target:        "example.com",
+			},
+			Severity: config.SeverityCritical,
+		},
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary: "Vulnerability Summary 2",
+				Score:   6.9,
+			},
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID1",
+				ChecktypeName: "vulcan-trivy",
+				Target:        "example.com",
+			},
+			Severity: config.SeverityMedium,
+		},
+	}
+
+	// The checks "CheckID1" and "CheckID3" share checktype and
+	// target, but they run with different options.
+	status := []checkStatus{
+		{
+			CheckID:   "CheckID2",
+			Checktype: "vulcan-nuclei",
+			Target:    "example.com",
+			Status:    "FAILED",
+		},
+		{
+			CheckID:   "CheckID1",
+			Checktype: "vulcan-trivy",
+			Target:    "example.com",
+			Status:    "FINISHED",
+		},
+		{
+			CheckID:   "CheckID3",
+			Checktype: "vulcan-trivy",
+			Target:    "example.com",
+			Status:    "FINISHED",
+		},
+	}
+
+	want := []checkLine{
+		{
+			CheckID:   "CheckID2",
+			Checktype: "vulcan-nuclei",
+			Target:    "example.com",
+			Status:    "FAILED",
+			Findings:  []vulnerability{},
+		},
+		{
+			CheckID:   "CheckID1",
+			Checktype: "vulcan-trivy",
+			Target:    "example.com",
+			Status:    "FINISHED",
+			Findings:  vulns,
+		},
+		{
+			CheckID:   "CheckID3",
+			Checktype: "vulcan-trivy",
+			Target:    "example.com",
+			Status:    "FINISHED",
+			Findings:  []vulnerability{},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (jsonlReportsPrinter{}).Print(&buf, vulns, summary{}, status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []checkLine
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var line checkLine
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("unmarshal line %q: %v", sc.Text(), err)
+		}
+		got = append(got, line)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("scan output: %v", err)
+	}
+
+	diffOpts := []cmp.Option{
+		cmp.AllowUnexported(vulnerability{}),
+	}
+	if diff := cmp.Diff(want, got, diffOpts...); diff != "" {
+		t.Errorf("lines mismatch (-want +got):\n%v", diff)
+	}
+}
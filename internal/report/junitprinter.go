@@ -0,0 +1,179 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/adevinta/lava/internal/engine"
+)
+
+// junitPrinter represents a JUnit XML report printer. Every check is
+// rendered as a test case, so CI systems can show the results of the
+// scan in their test report UI.
+type junitPrinter struct{}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite is a set of test cases.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is the result of a check.
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+	Skipped   *junitMessage `xml:"skipped"`
+}
+
+// junitMessage is the failure, error or skip reason of a test case.
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// Print renders the scan results in JUnit XML format. There is one
+// test case per checktype and target. A test case fails if the check
+// reported findings that are not baselined. Only findings with a
+// severity higher or equal than the configured severity are
+// considered. Checks that did not finish successfully are reported as
+// errors and skipped checks as skipped.
+func (prn junitPrinter) Print(w io.Writer, vulns []vulnerability, _ summary, status []checkStatus) error {
+	type caseKey struct {
+		checktype string
+		target    string
+	}
+
+	findings := make(map[caseKey][]vulnerability)
+	for _, v := range vulns {
+		if v.Baselined {
+			continue
+		}
+		k := caseKey{checktype: v.CheckData.ChecktypeName, target: v.CheckData.Target}
+		findings[k] = append(findings[k], v)
+	}
+
+	// Checks with the same checktype and target, but different
+	// options, share the same test case. So, the statuses of the
+	// checks are grouped preserving their order.
+	var keys []caseKey
+	statuses := make(map[caseKey][]checkStatus)
+	for _, cs := range status {
+		k := caseKey{checktype: cs.Checktype, target: cs.Target}
+		if _, ok := statuses[k]; !ok {
+			keys = append(keys, k)
+		}
+		statuses[k] = append(statuses[k], cs)
+	}
+
+	suite := junitTestSuite{Name: "lava"}
+	for _, k := range keys {
+		tc := junitTestCase{ClassName: k.checktype, Name: k.target}
+
+		skipped := true
+		for _, cs := range statuses[k] {
+			if cs.errored() {
+				tc.Error = junitCheckError(cs)
+				break
+			}
+			skipped = skipped && cs.Status == engine.StatusSkipped
+		}
+
+		switch {
+		case tc.Error != nil:
+		case skipped:
+			tc.Skipped = &junitMessage{Message: "check skipped"}
+		case len(findings[k]) > 0:
+			tc.Failure = junitFailure(findings[k])
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	for _, tc := range suite.TestCases {
+		suite.Tests++
+		switch {
+		case tc.Failure != nil:
+			suite.Failures++
+		case tc.Error != nil:
+			suite.Errors++
+		case tc.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+
+	suites := junitTestSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+// junitCheckError returns the error of a check that did not finish
+// successfully.
+func junitCheckError(cs checkStatus) *junitMessage {
+	return &junitMessage{
+		Message: fmt.Sprintf("check status: %v", cs.Status),
+		Type:    cs.Status,
+	}
+}
+
+// junitFailure returns the failure of a check that reported the
+// provided findings. The message contains the number of findings and
+// the text contains one line per finding with its severity and
+// summary.
+func junitFailure(vulns []vulnerability) *junitMessage {
+	var lines []string
+	for _, v := range vulns {
+		line := fmt.Sprintf("[%v] %v", strings.ToUpper(v.Severity.String()), v.Summary)
+		if v.AffectedResource != "" {
+			line += ": " + v.AffectedResource
+		}
+		lines = append(lines, line)
+	}
+	msg := fmt.Sprintf("%v findings", len(vulns))
+	if len(vulns) == 1 {
+		msg = "1 finding"
+	}
+	return &junitMessage{
+		Message: msg,
+		Type:    "vulnerability",
+		Text:    strings.Join(lines, "\n"),
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/engine"
+)
+
+func TestJunitPrinter_Print(t *testing.T) {
+	tests := []struct {
+		name   string
+		vulns  []vulnerability
+		status []checkStatus
+		want   junitTestSuites
+	}{
+		{
+			name:   "empty run",
+			vulns:  nil,
+			status: nil,
+			want: junitTestSuites{
+				XMLName: xml.Name{Local: "testsuites"},
+				Name:    "lava",
+				Suites: []junitTestSuite{
+					{Name: "lava"},
+				},
+			},
+		},
+		{
+			name: "findings",
+			vulns: []vulnerability{
+				{
+					Vulnerability: vreport.Vulnerability{
+						Summary:          "Outdated Drupal",
+						AffectedResource: "/CHANGELOG.txt",
+					},
+					CheckData: vreport.CheckData{ChecktypeName: "vulcan-drupal", Target: "https://example.com"},
+					Severity:  config.SeverityHigh,
+				},
+				{
+					Vulnerability: vreport.Vulnerability{Summary: "Exposed admin"},
+					CheckData:     vreport.CheckData{ChecktypeName: "vulcan-drupal", Target: "https://example.com"},
+					Severity:      config.SeverityMedium,
+				},
+				{
+					Vulnerability: vreport.Vulnerability{Summary: "Known secret"},
+					CheckData:     vreport.CheckData{ChecktypeName: "vulcan-gitleaks", Target: "."},
+					Severity:      config.SeverityHigh,
+					Baselined:     true,
+				},
+			},
+			status: []checkStatus{
+				{Checktype: "vulcan-drupal", Target: "https://example.com", Status: "FINISHED"},
+				{Checktype: "vulcan-gitleaks", Target: ".", Status: "FINISHED"},
+				{Checktype: "vulcan-nessus", Target: "example.com", Status: "FAILED"},
+				{Checktype: "vulcan-nessus", Target: "example.com", Status: "FINISHED"},
+				{Checktype: "vulcan-trivy", Target: "alpine", Status: engine.StatusSkipped},
+			},
+			want: junitTestSuites{
+				XMLName:  xml.Name{Local: "testsuites"},
+				Name:     "lava",
+				Tests:    4,
+				Failures: 1,
+				Errors:   1,
+				Skipped:  1,
+				Suites: []junitTestSuite{
+					{
+						Name:     "lava",
+						Tests:    4,
+						Failures: 1,
+						Errors:   1,
+						Skipped:  1,
+						TestCases: []junitTestCase{
+							{
+								ClassName: "vulcan-drupal",
+								Name:      "https://example.com",
+								Failure: &junitMessage{
+									Message: "2 findings",
+									Type:    "vulnerability",
+									Text:    "[HIGH] Outdated Drupal: /CHANGELOG.txt\n[MEDIUM] Exposed admin",
+								},
+							},
+							{
+								ClassName: "vulcan-gitleaks",
+								Name:      ".",
+							},
+							{
+								ClassName: "vulcan-nessus",
+								Name:      "example.com",
+								Error: &junitMessage{
+									Message: "check status: FAILED",
+									Type:    "FAILED",
+								},
+							},
+							{
+								ClassName: "vulcan-trivy",
+								Name:      "alpine",
+								Skipped:   &junitMessage{Message: "check skipped"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (junitPrinter{}).Print(&buf, tt.vulns, summary{}, tt.status); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.HasPrefix(buf.String(), xml.Header) {
+				t.Errorf("missing XML header")
+			}
+
+			var got junitTestSuites
+			if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("unmarshal report: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("report mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -9,9 +9,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"regexp"
 	"slices"
+	"sync"
+	"time"
 
 	report "github.com/adevinta/vulcan-report"
 
@@ -26,7 +29,24 @@ type Writer struct {
 	w           io.WriteCloser
 	isStdout    bool
 	minSeverity config.Severity
-	exclusions  []config.Exclusion
+	floor       *config.Severity
+	exclusions  []exclusion
+	webhook     *config.WebhookConfig
+	ignoreErrs  bool
+	dedup       bool
+	baseline    Baseline
+	baselineIdx baselineIndex
+	baselineOut string
+
+	// stream keeps track of the checks rendered by
+	// [Writer.Stream]. It is nil if streaming is not supported.
+	stream *streamState
+}
+
+// streamState keeps track of the checks rendered by [Writer.Stream].
+type streamState struct {
+	mu     sync.Mutex
+	checks map[string]bool
 }
 
 // NewWriter creates a new instance of a report writer.
@@ -37,6 +57,16 @@ func NewWriter(cfg config.ReportConfig) (Writer, error) {
 		prn = humanPrinter{}
 	case config.OutputFormatJSON:
 		prn = jsonPrinter{}
+	case config.OutputFormatJSONL:
+		prn = jsonlPrinter{}
+	case config.OutputFormatJSONLReports:
+		prn = jsonlReportsPrinter{}
+	case config.OutputFormatSARIF:
+		prn = sarifPrinter{}
+	case config.OutputFormatJUnit:
+		prn = junitPrinter{}
+	case config.OutputFormatHTML:
+		prn = htmlPrinter{}
 	default:
 		return Writer{}, errors.New("unsupported output format")
 	}
@@ -52,42 +82,184 @@ func NewWriter(cfg config.ReportConfig) (Writer, error) {
 		isStdout = false
 	}
 
+	// Duplicated findings can only be detected once all the
+	// checks have finished. So, streaming is disabled if
+	// deduplication is enabled.
+	var stream *streamState
+	if _, ok := prn.(streamPrinter); ok && !cfg.Dedup {
+		stream = &streamState{checks: make(map[string]bool)}
+	}
+
+	var baseline Baseline
+	if cfg.Baseline != "" {
+		b, err := ReadBaselineFile(cfg.Baseline)
+		if err != nil {
+			return Writer{}, fmt.Errorf("read baseline: %w", err)
+		}
+		baseline = b
+	}
+
 	return Writer{
 		prn:         prn,
 		w:           w,
 		isStdout:    isStdout,
 		minSeverity: cfg.Severity,
-		exclusions:  cfg.Exclusions,
+		floor:       cfg.Floor,
+		exclusions:  activeExclusions(cfg.Exclusions, time.Now()),
+		webhook:     cfg.Webhook,
+		ignoreErrs:  cfg.IgnoreCheckErrors,
+		dedup:       cfg.Dedup,
+		baseline:    baseline,
+		baselineIdx: newBaselineIndex(baseline),
+		baselineOut: cfg.BaselineOutput,
+		stream:      stream,
 	}, nil
 }
 
-// Write renders the provided [engine.Report]. The returned exit code
-// is calculated by evaluating the report with the [config.ReportConfig]
-// passed to [NewWriter]. If the returned error is not nil, the exit code
-// will be zero and should be ignored.
-func (writer Writer) Write(er engine.Report) (ExitCode, error) {
-	vulns, err := writer.parseReport(er)
+// Write renders the provided [engine.Report]. The returned [Result]
+// is calculated by evaluating the report with the
+// [config.ReportConfig] passed to [NewWriter]. If the returned error
+// is not nil, the result will be the zero value and should be
+// ignored, unless the error happened while printing the report. If a
+// webhook is configured, the reported findings are also sent to it.
+// If a severity floor is configured, the findings below it are
+// dropped from all the outputs, but they are considered to calculate
+// the result. If deduplication is enabled, duplicated findings are
+// removed before calculating the result. See [dedupVulns].
+// Delivery failures are logged, but they do not make Write fail. If a
+// baseline output file is configured, the baseline of the scan is
+// written to it. The checks already rendered by [Writer.Stream] are
+// not rendered again, but they are considered to calculate the
+// result.
+func (writer Writer) Write(er engine.Report) (Result, error) {
+	rawVulns, err := writer.parseReport(er)
 	if err != nil {
-		return 0, fmt.Errorf("parse report: %w", err)
+		return Result{}, fmt.Errorf("parse report: %w", err)
+	}
+
+	vulns := rawVulns
+	if writer.dedup {
+		var ndups int
+		vulns, ndups = dedupVulns(rawVulns)
+		metrics.Collect("duplicated_vulnerability_count", ndups)
 	}
 
 	summ, err := mkSummary(vulns)
 	if err != nil {
-		return 0, fmt.Errorf("calculate summary: %w", err)
+		return Result{}, fmt.Errorf("calculate summary: %w", err)
 	}
 
 	metrics.Collect("excluded_vulnerability_count", summ.excluded)
 	metrics.Collect("vulnerability_count", summ.count)
+	metrics.Collect("baselined_vulnerability_count", summ.baselined)
+
+	fvulns, dvulns := writer.applyFloor(writer.filterVulns(vulns))
+	summ.dropped = len(dvulns)
+	metrics.Collect("dropped_vulnerability_count", summ.dropped)
 
-	fvulns := writer.filterVulns(vulns)
 	status := mkStatus(er)
 	exitCode := writer.calculateExitCode(summ, status)
+	checkErrs := mkCheckErrors(status)
+
+	res := Result{
+		Passed:      exitCode == 0,
+		Gate:        writer.minSeverity,
+		Findings:    mkFindings(newVulns(fvulns)),
+		Count:       summ.count,
+		Excluded:    summ.excluded,
+		Baselined:   summ.baselined,
+		Dropped:     mkFindings(dvulns),
+		CheckErrors: checkErrs,
+		ExitCode:    exitCode,
+	}
+
+	if err = writer.print(fvulns, summ, status); err != nil {
+		return res, fmt.Errorf("print report: %w", err)
+	}
+
+	if writer.baselineOut != "" {
+		b := NewBaseline(mkFindings(writer.baselineVulns(rawVulns)), writer.baseline)
+		if err := b.WriteFile(writer.baselineOut); err != nil {
+			return res, fmt.Errorf("write baseline: %w", err)
+		}
+	}
+
+	if writer.webhook != nil {
+		if err := sendWebhook(*writer.webhook, fvulns, checkErrs); err != nil {
+			slog.Error("could not send findings to webhook", "url", writer.webhook.URL, "err", err)
+		}
+	}
+
+	return res, nil
+}
+
+// print renders the provided results. If streaming is supported, the
+// checks already rendered by [Writer.Stream] are skipped.
+func (writer Writer) print(vulns []vulnerability, summ summary, status []checkStatus) error {
+	if writer.stream == nil {
+		return writer.prn.Print(writer.w, vulns, summ, status)
+	}
+
+	writer.stream.mu.Lock()
+	defer writer.stream.mu.Unlock()
 
-	if err = writer.prn.Print(writer.w, fvulns, summ, status); err != nil {
-		return exitCode, fmt.Errorf("print report: %w", err)
+	var pvulns []vulnerability
+	for _, v := range vulns {
+		if !writer.stream.checks[v.CheckData.CheckID] {
+			pvulns = append(pvulns, v)
+		}
 	}
 
-	return exitCode, nil
+	var pstatus []checkStatus
+	for _, cs := range status {
+		if !writer.stream.checks[cs.CheckID] {
+			pstatus = append(pstatus, cs)
+		}
+	}
+
+	if err := writer.prn.Print(writer.w, pvulns, summ, pstatus); err != nil {
+		return err
+	}
+
+	for _, cs := range pstatus {
+		writer.stream.checks[cs.CheckID] = true
+	}
+	return nil
+}
+
+// Stream renders the report of a single check before the scan
+// finishes, so the output can be consumed incrementally. It only has
+// effect with the output formats that support streaming, which are
+// "jsonl" and "jsonl-reports", and when deduplication is disabled.
+// Only the checks that finished successfully are rendered, because
+// the failed ones may be retried. The rest of the checks are rendered
+// by [Writer.Write]. It is safe to call Stream concurrently with
+// [Writer.Write].
+func (writer Writer) Stream(r engine.CheckReport) error {
+	if writer.stream == nil || r.Status != "FINISHED" {
+		return nil
+	}
+
+	er := engine.Report{r.CheckID: r}
+	vulns, err := writer.parseReport(er)
+	if err != nil {
+		return fmt.Errorf("parse report: %w", err)
+	}
+	fvulns, _ := writer.applyFloor(writer.filterVulns(vulns))
+
+	writer.stream.mu.Lock()
+	defer writer.stream.mu.Unlock()
+
+	if writer.stream.checks[r.CheckID] {
+		return nil
+	}
+
+	prn := writer.prn.(streamPrinter)
+	if err := prn.PrintCheck(writer.w, fvulns, mkStatus(er)[0]); err != nil {
+		return fmt.Errorf("print check: %w", err)
+	}
+	writer.stream.checks[r.CheckID] = true
+	return nil
 }
 
 // Close closes the [Writer].
@@ -103,12 +275,12 @@ func (writer Writer) Close() error {
 // parseReport converts the provided [engine.Report] into a list of
 // vulnerabilities. It calculates the severity of each vulnerability
 // based on its score and determines if the vulnerability is excluded
-// according to the [Writer] configuration.
+// or baselined according to the [Writer] configuration.
 func (writer Writer) parseReport(er engine.Report) ([]vulnerability, error) {
 	var vulns []vulnerability
 	for _, r := range er {
 		for _, vuln := range r.ResultData.Vulnerabilities {
-			severity := scoreToSeverity(vuln.Score)
+			severity := config.ScoreToSeverity(vuln.Score)
 			excluded, err := writer.isExcluded(vuln, r.Target)
 			if err != nil {
 				return nil, fmt.Errorf("vulnerability exlusion: %w", err)
@@ -117,14 +289,53 @@ func (writer Writer) parseReport(er engine.Report) ([]vulnerability, error) {
 				CheckData:     r.CheckData,
 				Vulnerability: vuln,
 				Severity:      severity,
+				TargetLabels:  r.Labels,
 				excluded:      excluded,
+				fingerprint: Fingerprint(Finding{
+					Vulnerability: vuln,
+					CheckData:     r.CheckData,
+				}),
 			}
+			v.Baselined = writer.baselineIdx.contains(v.fingerprint)
 			vulns = append(vulns, v)
 		}
 	}
 	return vulns, nil
 }
 
+// exclusion is an exclusion rule ready to be applied.
+type exclusion struct {
+	config.Exclusion
+
+	// cveRegexp matches the CVE identifier of the rule. It is nil
+	// if the rule does not specify a CVE.
+	cveRegexp *regexp.Regexp
+}
+
+// activeExclusions returns the exclusion rules that have not expired
+// at the provided time. Expired rules are logged, so they can be
+// reviewed, and are not applied anymore.
+func activeExclusions(excls []config.Exclusion, now time.Time) []exclusion {
+	var (
+		active  []exclusion
+		expired int
+	)
+	for _, excl := range excls {
+		if excl.Expired(now) {
+			slog.Warn("exclusion expired", "description", excl.Description, "expires", excl.Expires)
+			expired++
+			continue
+		}
+		e := exclusion{Exclusion: excl}
+		if excl.CVE != "" {
+			e.cveRegexp = regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(excl.CVE) + `\b`)
+		}
+		active = append(active, e)
+	}
+	metrics.Collect("expired_exclusion_count", expired)
+	return active
+}
+
 // isExcluded returns whether the provided [report.Vulnerability] is
 // excluded based on the [Writer] configuration and the affected target.
 func (writer Writer) isExcluded(v report.Vulnerability, target string) (bool, error) {
@@ -133,6 +344,10 @@ func (writer Writer) isExcluded(v report.Vulnerability, target string) (bool, er
 			continue
 		}
 
+		if excl.cveRegexp != nil && !hasCVE(v, excl.cveRegexp) {
+			continue
+		}
+
 		if excl.Summary != "" {
 			matched, err := regexp.MatchString(excl.Summary, v.Summary)
 			if err != nil {
@@ -171,14 +386,32 @@ func (writer Writer) isExcluded(v report.Vulnerability, target string) (bool, er
 	return false, nil
 }
 
+// hasCVE reports whether the CVE identifier matched by the provided
+// regular expression is mentioned in the summary, the labels or the
+// references of the vulnerability.
+func hasCVE(v report.Vulnerability, re *regexp.Regexp) bool {
+	if re.MatchString(v.Summary) {
+		return true
+	}
+	for _, s := range v.Labels {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	for _, s := range v.References {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
 // filterVulns takes a list of vulnerabilities and filters out those
 // vulnerabilities that should be excluded based on the [Writer]
-// configuration.
+// configuration. The returned vulnerabilities are sorted. See
+// [compareVulns].
 func (writer Writer) filterVulns(vulns []vulnerability) []vulnerability {
-	// Sort the results by severity in reverse order.
-	slices.SortFunc(vulns, func(a, b vulnerability) int {
-		return cmp.Compare(b.Severity, a.Severity)
-	})
+	slices.SortFunc(vulns, compareVulns)
 
 	fvulns := make([]vulnerability, 0)
 	for _, v := range vulns {
@@ -193,15 +426,69 @@ func (writer Writer) filterVulns(vulns []vulnerability) []vulnerability {
 	return fvulns
 }
 
+// applyFloor splits the provided vulnerabilities into the ones with
+// a severity higher or equal than the severity floor of the [Writer]
+// and the ones below it. The order of the vulnerabilities is
+// preserved.
+func (writer Writer) applyFloor(vulns []vulnerability) (kept, dropped []vulnerability) {
+	if writer.floor == nil {
+		return vulns, nil
+	}
+
+	kept = make([]vulnerability, 0)
+	for _, v := range vulns {
+		if v.Severity < *writer.floor {
+			dropped = append(dropped, v)
+			continue
+		}
+		kept = append(kept, v)
+	}
+	return kept, dropped
+}
+
+// compareVulns compares two vulnerabilities. It is used to sort
+// vulnerabilities by severity in reverse order. Vulnerabilities with
+// the same severity are sorted by target, checktype and fingerprint,
+// so the order of the reported vulnerabilities is deterministic.
+func compareVulns(a, b vulnerability) int {
+	if c := cmp.Compare(b.Severity, a.Severity); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.CheckData.Target, b.CheckData.Target); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.CheckData.ChecktypeName, b.CheckData.ChecktypeName); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.fingerprint, b.fingerprint)
+}
+
+// baselineVulns returns the vulnerabilities that must be included in
+// the baseline of the scan. That is, all the vulnerabilities that are
+// not excluded, regardless of their severity.
+func (writer Writer) baselineVulns(vulns []vulnerability) []vulnerability {
+	var bvulns []vulnerability
+	for _, v := range vulns {
+		if !v.excluded {
+			bvulns = append(bvulns, v)
+		}
+	}
+	return bvulns
+}
+
 // calculateExitCode returns an error code depending on the vulnerabilities found,
 // as long as the severity of the vulnerabilities is higher or equal than the
 // min severity configured in the writer. For that it makes use of the summary.
+// Checks that did not finish successfully take precedence over the
+// vulnerabilities, unless the writer is configured to ignore them.
 //
 // See [ExitCode] for more information about exit codes.
 func (writer Writer) calculateExitCode(summ summary, status []checkStatus) ExitCode {
-	for _, cs := range status {
-		if cs.Status != "FINISHED" {
-			return ExitCodeCheckError
+	if !writer.ignoreErrs {
+		for _, cs := range status {
+			if cs.errored() {
+				return ExitCodeCheckError
+			}
 		}
 	}
 
@@ -217,9 +504,77 @@ func (writer Writer) calculateExitCode(summ summary, status []checkStatus) ExitC
 // vulnerability represents a vulnerability found by a check.
 type vulnerability struct {
 	report.Vulnerability
-	CheckData report.CheckData `json:"check_data"`
-	Severity  config.Severity  `json:"severity"`
-	excluded  bool
+	CheckData    report.CheckData  `json:"check_data"`
+	Severity     config.Severity   `json:"severity"`
+	TargetLabels map[string]string `json:"target_labels,omitempty"`
+	Baselined    bool              `json:"baselined,omitempty"`
+
+	// Checktypes are the checktypes that reported the
+	// vulnerability. It is only set if duplicated
+	// vulnerabilities are removed. See [dedupVulns].
+	Checktypes []string `json:"checktypes,omitempty"`
+
+	excluded bool
+
+	// fingerprint is the fingerprint of the vulnerability. It is
+	// computed once by [Writer.parseReport]. See [Fingerprint].
+	fingerprint string
+}
+
+// dedupVulns removes the duplicated vulnerabilities. Vulnerabilities
+// are duplicated if they have the same target, summary and affected
+// resource, even if they are reported by different checks. Of every
+// set of duplicates, the instance that is not excluded with the
+// highest score is kept, and the checktypes of all the instances are
+// recorded. The order of the vulnerabilities is preserved. It also
+// returns the number of removed duplicates.
+func dedupVulns(vulns []vulnerability) (dvulns []vulnerability, ndups int) {
+	type vulnKey struct {
+		target   string
+		summary  string
+		resource string
+	}
+
+	idx := make(map[vulnKey]int)
+	for _, v := range vulns {
+		k := vulnKey{
+			target:   v.CheckData.Target,
+			summary:  v.Summary,
+			resource: v.AffectedResource,
+		}
+
+		i, ok := idx[k]
+		if !ok {
+			idx[k] = len(dvulns)
+			v.Checktypes = []string{v.CheckData.ChecktypeName}
+			dvulns = append(dvulns, v)
+			continue
+		}
+
+		ndups++
+
+		cts := dvulns[i].Checktypes
+		if !slices.Contains(cts, v.CheckData.ChecktypeName) {
+			cts = append(cts, v.CheckData.ChecktypeName)
+			slices.Sort(cts)
+		}
+
+		if preferVuln(v, dvulns[i]) {
+			dvulns[i] = v
+		}
+		dvulns[i].Checktypes = cts
+	}
+	return dvulns, ndups
+}
+
+// preferVuln reports whether the vulnerability a must be kept instead
+// of its duplicate b. Vulnerabilities that are not excluded are
+// preferred, then the ones with the highest score.
+func preferVuln(a, b vulnerability) bool {
+	if a.excluded != b.excluded {
+		return !a.excluded
+	}
+	return a.Score > b.Score
 }
 
 // A printer renders a Vulcan report in a specific format.
@@ -227,35 +582,26 @@ type printer interface {
 	Print(w io.Writer, vulns []vulnerability, summ summary, status []checkStatus) error
 }
 
-// scoreToSeverity converts a CVSS score into a [config.Severity].
-// To calculate the severity we are using the [severity ratings]
-// provided by the NVD.
-//
-// [severity ratings]: https://nvd.nist.gov/vuln-metrics/cvss
-func scoreToSeverity(score float32) config.Severity {
-	switch {
-	case score >= 9.0:
-		return config.SeverityCritical
-	case score >= 7.0:
-		return config.SeverityHigh
-	case score >= 4.0:
-		return config.SeverityMedium
-	case score >= 0.1:
-		return config.SeverityLow
-	default:
-		return config.SeverityInfo
-	}
+// A streamPrinter is a [printer] that is also able to render the
+// results of a single check, so they can be rendered as soon as the
+// check finishes.
+type streamPrinter interface {
+	printer
+	PrintCheck(w io.Writer, vulns []vulnerability, cs checkStatus) error
 }
 
 // summary represents the statistics of the results.
 type summary struct {
-	count    map[config.Severity]int
-	excluded int
+	count     map[config.Severity]int
+	excluded  int
+	baselined int
+	dropped   int
 }
 
 // mkSummary counts the number vulnerabilities per severity and the
-// number of excluded vulnerabilities. The excluded vulnerabilities are
-// not considered in the count per severity.
+// number of excluded and baselined vulnerabilities. The excluded and
+// baselined vulnerabilities are not considered in the count per
+// severity.
 func mkSummary(vulns []vulnerability) (summary, error) {
 	if len(vulns) == 0 {
 		return summary{}, nil
@@ -268,9 +614,12 @@ func mkSummary(vulns []vulnerability) (summary, error) {
 		if !vuln.Severity.IsValid() {
 			return summary{}, fmt.Errorf("invalid severity: %v", vuln.Severity)
 		}
-		if vuln.excluded {
+		switch {
+		case vuln.excluded:
 			summ.excluded++
-		} else {
+		case vuln.Baselined:
+			summ.baselined++
+		default:
 			summ.count[vuln.Severity]++
 		}
 	}
@@ -280,26 +629,171 @@ func mkSummary(vulns []vulnerability) (summary, error) {
 // checkStatus represents the status of a check after the scan has
 // finished.
 type checkStatus struct {
+	CheckID   string
 	Checktype string
 	Target    string
 	Status    string
 }
 
+// errored reports whether the check did not finish successfully.
+// Skipped checks are not considered errored.
+func (cs checkStatus) errored() bool {
+	return cs.Status != "FINISHED" && cs.Status != engine.StatusSkipped
+}
+
 // mkStatus returns the status of every check after the scan has
-// finished.
+// finished sorted by checktype, target and status.
 func mkStatus(er engine.Report) []checkStatus {
 	var status []checkStatus
 	for _, r := range er {
 		cs := checkStatus{
+			CheckID:   r.CheckID,
 			Checktype: r.ChecktypeName,
 			Target:    r.Target,
 			Status:    r.Status,
 		}
 		status = append(status, cs)
 	}
+	slices.SortFunc(status, compareStatus)
 	return status
 }
 
+// compareStatus compares two check statuses by checktype, target,
+// status and check ID.
+func compareStatus(a, b checkStatus) int {
+	if c := cmp.Compare(a.Checktype, b.Checktype); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Target, b.Target); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Status, b.Status); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.CheckID, b.CheckID)
+}
+
+// Result is the outcome of evaluating a report against the minimum
+// severity configured in the [Writer].
+type Result struct {
+	// Passed reports whether no findings with a severity higher
+	// or equal than Gate were found and all the checks finished
+	// successfully. Checks that did not finish successfully are
+	// not considered if the [Writer] is configured to ignore
+	// them.
+	Passed bool
+
+	// Gate is the minimum severity required to consider a finding.
+	Gate config.Severity
+
+	// Findings are the findings that made the evaluation fail.
+	// That is, the findings that are not excluded nor baselined
+	// and have a severity higher or equal than Gate. Findings
+	// below the severity floor are not included. They are sorted
+	// by severity in reverse order, target, checktype and
+	// fingerprint.
+	Findings []Finding
+
+	// Count is the number of findings per severity. Excluded and
+	// baselined findings are not considered.
+	Count map[config.Severity]int
+
+	// Excluded is the number of excluded findings.
+	Excluded int
+
+	// Baselined is the number of findings that are in the
+	// baseline.
+	Baselined int
+
+	// Dropped are the reportable findings that were dropped
+	// because their severity is below the severity floor. They
+	// are not included in Findings nor in the printed report, but
+	// they are included in Count. They are sorted like Findings.
+	Dropped []Finding
+
+	// CheckErrors are the checks that did not finish
+	// successfully. They are sorted by checktype and target.
+	CheckErrors []CheckError
+
+	// ExitCode is the exit code corresponding to the result.
+	ExitCode ExitCode
+}
+
+// Finding is a vulnerability found by a check.
+type Finding struct {
+	report.Vulnerability
+
+	// CheckData is the data of the check that found the
+	// vulnerability.
+	CheckData report.CheckData
+
+	// Severity is the severity of the vulnerability.
+	Severity config.Severity
+
+	// TargetLabels are the labels of the affected target.
+	TargetLabels map[string]string
+}
+
+// CheckError is a check that did not finish successfully. For
+// instance, because it crashed or it could not reach the target.
+type CheckError struct {
+	// Checktype is the name of the checktype.
+	Checktype string `json:"checktype"`
+
+	// Target is the target of the check.
+	Target string `json:"target"`
+
+	// Status is the final status of the check.
+	Status string `json:"status"`
+}
+
+// mkCheckErrors returns the checks that did not finish successfully
+// sorted by checktype and target.
+func mkCheckErrors(status []checkStatus) []CheckError {
+	status = slices.Clone(status)
+	slices.SortFunc(status, compareStatus)
+
+	var errs []CheckError
+	for _, cs := range status {
+		if !cs.errored() {
+			continue
+		}
+		errs = append(errs, CheckError{
+			Checktype: cs.Checktype,
+			Target:    cs.Target,
+			Status:    cs.Status,
+		})
+	}
+	return errs
+}
+
+// newVulns returns the provided vulnerabilities that are not in the
+// baseline.
+func newVulns(vulns []vulnerability) []vulnerability {
+	var nvulns []vulnerability
+	for _, v := range vulns {
+		if !v.Baselined {
+			nvulns = append(nvulns, v)
+		}
+	}
+	return nvulns
+}
+
+// mkFindings converts the provided vulnerabilities into findings.
+func mkFindings(vulns []vulnerability) []Finding {
+	var findings []Finding
+	for _, v := range vulns {
+		f := Finding{
+			Vulnerability: v.Vulnerability,
+			CheckData:     v.CheckData,
+			Severity:      v.Severity,
+			TargetLabels:  v.TargetLabels,
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
 // ExitCode represents an exit code depending on the vulnerabilities found.
 type ExitCode int
 
@@ -3,10 +3,14 @@
 package report
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	vreport "github.com/adevinta/vulcan-report"
 	"github.com/google/go-cmp/cmp"
@@ -209,58 +213,80 @@ func TestWriter_calculateExitCode(t *testing.T) {
 			},
 			want: ExitCodeCheckError,
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			w, err := NewWriter(tt.rConfig)
-			if err != nil {
-				t.Fatalf("unable to create a report writer: %v", err)
-			}
-			got := w.calculateExitCode(tt.summ, tt.status)
-			if got != tt.want {
-				t.Errorf("unexpected exit code: got: %v, want: %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestScoreToSeverity(t *testing.T) {
-	tests := []struct {
-		name  string
-		score float32
-		want  config.Severity
-	}{
-		{
-			name:  "critical",
-			score: 9,
-			want:  config.SeverityCritical,
-		},
 		{
-			name:  "high",
-			score: 7,
-			want:  config.SeverityHigh,
-		},
-		{
-			name:  "medium",
-			score: 4,
-			want:  config.SeverityMedium,
+			name: "skipped check",
+			summ: summary{
+				count: map[config.Severity]int{
+					config.SeverityCritical: 0,
+					config.SeverityHigh:     0,
+					config.SeverityMedium:   1,
+					config.SeverityLow:      0,
+					config.SeverityInfo:     0,
+				},
+			},
+			status: []checkStatus{
+				{
+					Checktype: "Checktype1",
+					Target:    "Target1",
+					Status:    "FINISHED",
+				},
+				{
+					Checktype: "Checktype2",
+					Target:    "Target1",
+					Status:    "SKIPPED",
+				},
+			},
+			rConfig: config.ReportConfig{
+				Severity: config.SeverityHigh,
+			},
+			want: 0,
 		},
 		{
-			name:  "low",
-			score: 0.1,
-			want:  config.SeverityLow,
+			name: "ignored check errors",
+			summ: summary{
+				count: map[config.Severity]int{
+					config.SeverityHigh: 1,
+				},
+			},
+			status: []checkStatus{
+				{
+					Checktype: "Checktype1",
+					Target:    "Target1",
+					Status:    "FAILED",
+				},
+			},
+			rConfig: config.ReportConfig{
+				Severity:          config.SeverityHigh,
+				IgnoreCheckErrors: true,
+			},
+			want: ExitCodeHigh,
 		},
 		{
-			name:  "info",
-			score: 0,
-			want:  config.SeverityInfo,
+			name: "ignored check errors without findings",
+			summ: summary{},
+			status: []checkStatus{
+				{
+					Checktype: "Checktype1",
+					Target:    "Target1",
+					Status:    "FAILED",
+				},
+			},
+			rConfig: config.ReportConfig{
+				Severity:          config.SeverityHigh,
+				IgnoreCheckErrors: true,
+			},
+			want: 0,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := scoreToSeverity(tt.score)
+			w, err := NewWriter(tt.rConfig)
+			if err != nil {
+				t.Fatalf("unable to create a report writer: %v", err)
+			}
+			got := w.calculateExitCode(tt.summ, tt.status)
 			if got != tt.want {
-				t.Errorf("unexpected severity: got: %v, want: %v", got, tt.want)
+				t.Errorf("unexpected exit code: got: %v, want: %v", got, tt.want)
 			}
 		})
 	}
@@ -276,28 +302,32 @@ func TestWriter_parseReport(t *testing.T) {
 	}{
 		{
 			name: "all vulnerabilities included",
-			report: map[string]vreport.Report{
+			report: engine.Report{
 				"CheckID1": {
-					CheckData: vreport.CheckData{
-						CheckID: "CheckID1",
-					},
-					ResultData: vreport.ResultData{
-						Vulnerabilities: []vreport.Vulnerability{
-							{
-								Summary: "Vulnerability Summary 1",
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							CheckID: "CheckID1",
+						},
+						ResultData: vreport.ResultData{
+							Vulnerabilities: []vreport.Vulnerability{
+								{
+									Summary: "Vulnerability Summary 1",
+								},
 							},
 						},
 					},
 				},
 				"CheckID2": {
-					CheckData: vreport.CheckData{
-						CheckID: "CheckID2",
-					},
-					ResultData: vreport.ResultData{
-						Vulnerabilities: []vreport.Vulnerability{
-							{
-								Summary: "Vulnerability Summary 2",
-								Score:   6.7,
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							CheckID: "CheckID2",
+						},
+						ResultData: vreport.ResultData{
+							Vulnerabilities: []vreport.Vulnerability{
+								{
+									Summary: "Vulnerability Summary 2",
+									Score:   6.7,
+								},
 							},
 						},
 					},
@@ -332,29 +362,74 @@ func TestWriter_parseReport(t *testing.T) {
 			wantNilErr: true,
 		},
 		{
-			name: "some vulnerabilities excluded",
-			report: map[string]vreport.Report{
+			name: "target labels",
+			report: engine.Report{
 				"CheckID1": {
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							CheckID: "CheckID1",
+						},
+						ResultData: vreport.ResultData{
+							Vulnerabilities: []vreport.Vulnerability{
+								{
+									Summary: "Vulnerability Summary 1",
+								},
+							},
+						},
+					},
+					Labels: map[string]string{
+						"owner": "team-a",
+					},
+				},
+			},
+			rConfig: config.ReportConfig{
+				Exclusions: []config.Exclusion{},
+			},
+			want: []vulnerability{
+				{
 					CheckData: vreport.CheckData{
 						CheckID: "CheckID1",
 					},
-					ResultData: vreport.ResultData{
-						Vulnerabilities: []vreport.Vulnerability{
-							{
-								Summary: "Vulnerability Summary 1",
+					Vulnerability: vreport.Vulnerability{
+						Summary: "Vulnerability Summary 1",
+					},
+					Severity: config.SeverityInfo,
+					TargetLabels: map[string]string{
+						"owner": "team-a",
+					},
+					excluded: false,
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "some vulnerabilities excluded",
+			report: engine.Report{
+				"CheckID1": {
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							CheckID: "CheckID1",
+						},
+						ResultData: vreport.ResultData{
+							Vulnerabilities: []vreport.Vulnerability{
+								{
+									Summary: "Vulnerability Summary 1",
+								},
 							},
 						},
 					},
 				},
 				"CheckID2": {
-					CheckData: vreport.CheckData{
-						CheckID: "CheckID2",
-					},
-					ResultData: vreport.ResultData{
-						Vulnerabilities: []vreport.Vulnerability{
-							{
-								Summary: "Vulnerability Summary 2",
-								Score:   6.7,
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							CheckID: "CheckID2",
+						},
+						ResultData: vreport.ResultData{
+							Vulnerabilities: []vreport.Vulnerability{
+								{
+									Summary: "Vulnerability Summary 2",
+									Score:   6.7,
+								},
 							},
 						},
 					},
@@ -401,8 +476,15 @@ func TestWriter_parseReport(t *testing.T) {
 			if (err == nil) != tt.wantNilErr {
 				t.Errorf("unexpected error value: %v", err)
 			}
+			for _, v := range got {
+				want := Fingerprint(Finding{Vulnerability: v.Vulnerability, CheckData: v.CheckData})
+				if v.fingerprint != want {
+					t.Errorf("unexpected fingerprint: got: %v, want: %v", v.fingerprint, want)
+				}
+			}
 			diffOpts := []cmp.Option{
 				cmp.AllowUnexported(vulnerability{}),
+				cmpopts.IgnoreFields(vulnerability{}, "fingerprint"),
 				cmpopts.SortSlices(vulnLess),
 			}
 			if diff := cmp.Diff(tt.want, got, diffOpts...); diff != "" {
@@ -631,6 +713,90 @@ func TestWriter_isExcluded(t *testing.T) {
 			want:       false,
 			wantNilErr: true,
 		},
+		{
+			name: "exclude by CVE in summary",
+			vulnerability: vreport.Vulnerability{
+				Summary: "cve-2023-44487 in golang.org/x/net",
+			},
+			target: ".",
+			rConfig: config.ReportConfig{
+				Exclusions: []config.Exclusion{
+					{
+						CVE: "CVE-2023-44487",
+					},
+				},
+			},
+			want:       true,
+			wantNilErr: true,
+		},
+		{
+			name: "exclude by CVE in references",
+			vulnerability: vreport.Vulnerability{
+				Summary:    "HTTP/2 rapid reset",
+				References: []string{"https://nvd.nist.gov/vuln/detail/CVE-2023-44487"},
+			},
+			target: ".",
+			rConfig: config.ReportConfig{
+				Exclusions: []config.Exclusion{
+					{
+						CVE: "CVE-2023-44487",
+					},
+				},
+			},
+			want:       true,
+			wantNilErr: true,
+		},
+		{
+			name: "not exclude by CVE prefix",
+			vulnerability: vreport.Vulnerability{
+				Summary: "CVE-2023-444870 in golang.org/x/net",
+				Labels:  []string{"CVE-2023-444870"},
+			},
+			target: ".",
+			rConfig: config.ReportConfig{
+				Exclusions: []config.Exclusion{
+					{
+						CVE: "CVE-2023-44487",
+					},
+				},
+			},
+			want:       false,
+			wantNilErr: true,
+		},
+		{
+			name: "not expired exclusion",
+			vulnerability: vreport.Vulnerability{
+				Summary: "Vulnerability Summary 1",
+			},
+			target: ".",
+			rConfig: config.ReportConfig{
+				Exclusions: []config.Exclusion{
+					{
+						Summary: "Summary 1",
+						Expires: time.Now().Add(time.Hour),
+					},
+				},
+			},
+			want:       true,
+			wantNilErr: true,
+		},
+		{
+			name: "expired exclusion",
+			vulnerability: vreport.Vulnerability{
+				Summary: "Vulnerability Summary 1",
+			},
+			target: ".",
+			rConfig: config.ReportConfig{
+				Exclusions: []config.Exclusion{
+					{
+						Summary: "Summary 1",
+						Expires: time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			want:       false,
+			wantNilErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -791,18 +957,22 @@ func TestMkStatus(t *testing.T) {
 		{
 			name: "multiple checks",
 			er: engine.Report{
-				"CheckID1": vreport.Report{
-					CheckData: vreport.CheckData{
-						ChecktypeName: "Checktype1",
-						Target:        "Target1",
-						Status:        "Status1",
+				"CheckID1": {
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							ChecktypeName: "Checktype1",
+							Target:        "Target1",
+							Status:        "Status1",
+						},
 					},
 				},
-				"CheckID2": vreport.Report{
-					CheckData: vreport.CheckData{
-						ChecktypeName: "Checktype2",
-						Target:        "Target2",
-						Status:        "Status2",
+				"CheckID2": {
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							ChecktypeName: "Checktype2",
+							Target:        "Target2",
+							Status:        "Status2",
+						},
 					},
 				},
 			},
@@ -822,18 +992,22 @@ func TestMkStatus(t *testing.T) {
 		{
 			name: "duplicated check",
 			er: engine.Report{
-				"CheckID1": vreport.Report{
-					CheckData: vreport.CheckData{
-						ChecktypeName: "Checktype1",
-						Target:        "Target1",
-						Status:        "Status1",
+				"CheckID1": {
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							ChecktypeName: "Checktype1",
+							Target:        "Target1",
+							Status:        "Status1",
+						},
 					},
 				},
-				"CheckID2": vreport.Report{
-					CheckData: vreport.CheckData{
-						ChecktypeName: "Checktype1",
-						Target:        "Target1",
-						Status:        "Status1",
+				"CheckID2": {
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							ChecktypeName: "Checktype1",
+							Target:        "Target1",
+							Status:        "Status1",
+						},
 					},
 				},
 			},
@@ -859,13 +1033,56 @@ func TestMkStatus(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := mkStatus(tt.er)
-			if diff := cmp.Diff(tt.want, got, cmpopts.SortSlices(statusLess)); diff != "" {
+			if diff := cmp.Diff(tt.want, got); diff != "" {
 				t.Errorf("status mismatch (-want +got):\n%v", diff)
 			}
 		})
 	}
 }
 
+func TestMkCheckErrors(t *testing.T) {
+	status := []checkStatus{
+		{
+			Checktype: "Checktype2",
+			Target:    "Target1",
+			Status:    "TIMEOUT",
+		},
+		{
+			Checktype: "Checktype1",
+			Target:    "Target2",
+			Status:    "FINISHED",
+		},
+		{
+			Checktype: "Checktype1",
+			Target:    "Target1",
+			Status:    "FAILED",
+		},
+		{
+			Checktype: "Checktype3",
+			Target:    "Target1",
+			Status:    "SKIPPED",
+		},
+	}
+
+	want := []CheckError{
+		{
+			Checktype: "Checktype1",
+			Target:    "Target1",
+			Status:    "FAILED",
+		},
+		{
+			Checktype: "Checktype2",
+			Target:    "Target1",
+			Status:    "TIMEOUT",
+		},
+	}
+
+	got := mkCheckErrors(status)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("check errors mismatch (-want +got):\n%v", diff)
+	}
+}
+
 func TestWriter_filterVulns(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -1103,133 +1320,136 @@ func TestNewWriter_OutputFile(t *testing.T) {
 		report       engine.Report
 		rConfig      config.ReportConfig
 		wantExitCode ExitCode
+		wantPassed   bool
 		wantNilErr   bool
 	}{
 		{
 			name: "Standard Output JSON Report",
-			report: map[string]vreport.Report{
+			report: engine.Report{
 				"CheckID1": {
-					CheckData: vreport.CheckData{
-						CheckID:       "CheckID1",
-						ChecktypeName: "Checktype1",
-						Target:        "Target1",
-						Status:        "FINISHED",
-					},
-					ResultData: vreport.ResultData{
-						Vulnerabilities: []vreport.Vulnerability{
-							{
-								Summary: "Vulnerability Summary 1",
-								Description: "Lorem ipsum dolor sit amet, " +
-									"consectetur adipiscing elit. Nam malesuada " +
-									"pretium ligula, ac egestas leo egestas nec. " +
-									"Morbi id placerat ipsum. Donec semper enim urna, " +
-									"et bibendum ex dictum in. Quisque venenatis " +
-									"in sem in lacinia. Fusce lacus odio, molestie " +
-									"vitae mi nec, elementum pellentesque augue. " +
-									"Aenean imperdiet odio eu sodales molestie. " +
-									"Fusce ut elementum leo. Nam sodales molestie " +
-									"lorem in rutrum. Pellentesque nec sapien elit. " +
-									"Sed tincidunt ut augue sit amet cursus. " +
-									"In convallis magna sit amet tempus pellentesque. " +
-									"Nam commodo porttitor ante sed volutpat. " +
-									"Ut vulputate leo quis ultricies sodales.",
-								AffectedResource: "Affected Resource 1",
-								ImpactDetails:    "Impact detail 1",
-								Recommendations: []string{
-									"Recommendation 1",
-									"Recommendation 2",
-									"Recommendation 3",
-								},
-								Details: "Vulnerability Detail 1",
-								References: []string{
-									"Reference 1",
-									"Reference 2",
-									"Reference 3",
-								},
-								Resources: []vreport.ResourcesGroup{
-									{
-										Name: "Resource 1",
-										Header: []string{
-											"Header 1",
-											"Header 2",
-											"Header 3",
-											"Header 4",
-										},
-										Rows: []map[string]string{
-											{
-												"Header 1": "row 11",
-												"Header 2": "row 12",
-												"Header 3": "row 13",
-												"Header 4": "row 14",
-											},
-											{
-												"Header 1": "row 21",
-												"Header 2": "row 22",
-												"Header 3": "row 23",
-												"Header 4": "row 24",
-											},
-											{
-												"Header 1": "row 31",
-												"Header 2": "row 32",
-												"Header 3": "row 33",
-												"Header 4": "row 34",
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							CheckID:       "CheckID1",
+							ChecktypeName: "Checktype1",
+							Target:        "Target1",
+							Status:        "FINISHED",
+						},
+						ResultData: vreport.ResultData{
+							Vulnerabilities: []vreport.Vulnerability{
+								{
+									Summary: "Vulnerability Summary 1",
+									Description: "Lorem ipsum dolor sit amet, " +
+										"consectetur adipiscing elit. Nam malesuada " +
+										"pretium ligula, ac egestas leo egestas nec. " +
+										"Morbi id placerat ipsum. Donec semper enim urna, " +
+										"et bibendum ex dictum in. Quisque venenatis " +
+										"in sem in lacinia. Fusce lacus odio, molestie " +
+										"vitae mi nec, elementum pellentesque augue. " +
+										"Aenean imperdiet odio eu sodales molestie. " +
+										"Fusce ut elementum leo. Nam sodales molestie " +
+										"lorem in rutrum. Pellentesque nec sapien elit. " +
+										"Sed tincidunt ut augue sit amet cursus. " +
+										"In convallis magna sit amet tempus pellentesque. " +
+										"Nam commodo porttitor ante sed volutpat. " +
+										"Ut vulputate leo quis ultricies sodales.",
+									AffectedResource: "Affected Resource 1",
+									ImpactDetails:    "Impact detail 1",
+									Recommendations: []string{
+										"Recommendation 1",
+										"Recommendation 2",
+										"Recommendation 3",
+									},
+									Details: "Vulnerability Detail 1",
+									References: []string{
+										"Reference 1",
+										"Reference 2",
+										"Reference 3",
+									},
+									Resources: []vreport.ResourcesGroup{
+										{
+											Name: "Resource 1",
+											Header: []string{
+												"Header 1",
+												"Header 2",
+												"Header 3",
+												"Header 4",
 											},
-											{
-												"Header 1": "row 41",
-												"Header 2": "row 42",
-												"Header 3": "row 43",
-												"Header 4": "row 44",
+											Rows: []map[string]string{
+												{
+													"Header 1": "row 11",
+													"Header 2": "row 12",
+													"Header 3": "row 13",
+													"Header 4": "row 14",
+												},
+												{
+													"Header 1": "row 21",
+													"Header 2": "row 22",
+													"Header 3": "row 23",
+													"Header 4": "row 24",
+												},
+												{
+													"Header 1": "row 31",
+													"Header 2": "row 32",
+													"Header 3": "row 33",
+													"Header 4": "row 34",
+												},
+												{
+													"Header 1": "row 41",
+													"Header 2": "row 42",
+													"Header 3": "row 43",
+													"Header 4": "row 44",
+												},
 											},
 										},
-									},
-									{
-										Name: "Resource 2",
-										Header: []string{
-											"Header 1",
-											"Header 2",
-										},
-										Rows: []map[string]string{
-											{
-												"Header 1": "row 11",
-												"Header 2": "row 12",
+										{
+											Name: "Resource 2",
+											Header: []string{
+												"Header 1",
+												"Header 2",
 											},
-											{
-												"Header 1": "row 21",
-												"Header 2": "row 22",
+											Rows: []map[string]string{
+												{
+													"Header 1": "row 11",
+													"Header 2": "row 12",
+												},
+												{
+													"Header 1": "row 21",
+													"Header 2": "row 22",
+												},
 											},
 										},
-									},
-									{
-										Name: "Resource 3",
-										Header: []string{
-											"Header 1",
-											"Header 2",
-										},
-										Rows: []map[string]string{
-											{
-												"Header 1": "row 11",
-												"Header 2": "row 12",
+										{
+											Name: "Resource 3",
+											Header: []string{
+												"Header 1",
+												"Header 2",
 											},
-											{
-												"Header 1": "row 21",
-												"Header 2": "row 22",
+											Rows: []map[string]string{
+												{
+													"Header 1": "row 11",
+													"Header 2": "row 12",
+												},
+												{
+													"Header 1": "row 21",
+													"Header 2": "row 22",
+												},
 											},
 										},
-									},
-									{
-										Name: "Resource 4",
-										Header: []string{
-											"Header 1",
-											"Header 2",
-										},
-										Rows: []map[string]string{
-											{
-												"Header 1": "row 11",
-												"Header 2": "row 12",
+										{
+											Name: "Resource 4",
+											Header: []string{
+												"Header 1",
+												"Header 2",
 											},
-											{
-												"Header 1": "row 21",
-												"Header 2": "row 22",
+											Rows: []map[string]string{
+												{
+													"Header 1": "row 11",
+													"Header 2": "row 12",
+												},
+												{
+													"Header 1": "row 21",
+													"Header 2": "row 22",
+												},
 											},
 										},
 									},
@@ -1245,6 +1465,38 @@ func TestNewWriter_OutputFile(t *testing.T) {
 				Format:     config.OutputFormatJSON,
 			},
 			wantExitCode: ExitCodeInfo,
+			wantPassed:   false,
+			wantNilErr:   true,
+		},
+		{
+			name: "Passed JSON Report",
+			report: engine.Report{
+				"CheckID1": {
+					Report: vreport.Report{
+						CheckData: vreport.CheckData{
+							CheckID:       "CheckID1",
+							ChecktypeName: "Checktype1",
+							Target:        "Target1",
+							Status:        "FINISHED",
+						},
+						ResultData: vreport.ResultData{
+							Vulnerabilities: []vreport.Vulnerability{
+								{
+									Summary: "Vulnerability Summary 1",
+									Score:   3.9,
+								},
+							},
+						},
+					},
+				},
+			},
+			rConfig: config.ReportConfig{
+				Severity:   config.SeverityHigh,
+				OutputFile: "test.json",
+				Format:     config.OutputFormatJSON,
+			},
+			wantExitCode: 0,
+			wantPassed:   true,
 			wantNilErr:   true,
 		},
 	}
@@ -1262,12 +1514,15 @@ func TestNewWriter_OutputFile(t *testing.T) {
 				t.Fatalf("unable to create a report writer: %v", err)
 			}
 			defer writer.Close()
-			gotExitCode, err := writer.Write(tt.report)
+			got, err := writer.Write(tt.report)
 			if (err == nil) != tt.wantNilErr {
 				t.Errorf("unexpected error value: %v", err)
 			}
-			if gotExitCode != tt.wantExitCode {
-				t.Errorf("unexpected error value: got: %d, want: %d", gotExitCode, tt.wantExitCode)
+			if got.ExitCode != tt.wantExitCode {
+				t.Errorf("unexpected exit code: got: %d, want: %d", got.ExitCode, tt.wantExitCode)
+			}
+			if got.Passed != tt.wantPassed {
+				t.Errorf("unexpected passed value: got: %v, want: %v", got.Passed, tt.wantPassed)
 			}
 
 			if _, err = os.Stat(tt.rConfig.OutputFile); err != nil {
@@ -1277,6 +1532,76 @@ func TestNewWriter_OutputFile(t *testing.T) {
 	}
 }
 
+func TestWriter_Write_stableOrder(t *testing.T) {
+	mkCheckReport := func(checkID, checktype, target string, summaries ...string) engine.CheckReport {
+		var vulns []vreport.Vulnerability
+		for _, s := range summaries {
+			vulns = append(vulns, vreport.Vulnerability{Summary: s, Score: 7.0})
+		}
+		return engine.CheckReport{
+			Report: vreport.Report{
+				CheckData: vreport.CheckData{
+					CheckID:       checkID,
+					ChecktypeName: checktype,
+					Target:        target,
+					Status:        "FINISHED",
+				},
+				ResultData: vreport.ResultData{
+					Vulnerabilities: vulns,
+				},
+			},
+		}
+	}
+
+	er := engine.Report{
+		"CheckID1": mkCheckReport("CheckID1", "Checktype2", "Target1", "Summary 1", "Summary 2", "Summary 3"),
+		"CheckID2": mkCheckReport("CheckID2", "Checktype1", "Target1", "Summary 4", "Summary 5"),
+		"CheckID3": mkCheckReport("CheckID3", "Checktype1", "Target2", "Summary 6", "Summary 7"),
+		"CheckID4": mkCheckReport("CheckID4", "Checktype2", "Target2", "Summary 8"),
+	}
+
+	var want []byte
+	for i := 0; i < 10; i++ {
+		output := filepath.Join(t.TempDir(), "output.json")
+		writer, err := NewWriter(config.ReportConfig{
+			Severity:   config.SeverityInfo,
+			Format:     config.OutputFormatJSON,
+			OutputFile: output,
+		})
+		if err != nil {
+			t.Fatalf("unable to create a report writer: %v", err)
+		}
+		res, err := writer.Write(er)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("close writer: %v", err)
+		}
+
+		for j := 1; j < len(res.Findings); j++ {
+			a, b := res.Findings[j-1], res.Findings[j]
+			if a.CheckData.Target > b.CheckData.Target ||
+				a.CheckData.Target == b.CheckData.Target && a.CheckData.ChecktypeName > b.CheckData.ChecktypeName {
+				t.Errorf("findings are not sorted: %v/%v before %v/%v",
+					a.CheckData.Target, a.CheckData.ChecktypeName, b.CheckData.Target, b.CheckData.ChecktypeName)
+			}
+		}
+
+		got, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("read output: %v", err)
+		}
+		if want == nil {
+			want = got
+			continue
+		}
+		if diff := cmp.Diff(string(want), string(got)); diff != "" {
+			t.Fatalf("output mismatch in run %v (-want +got):\n%v", i, diff)
+		}
+	}
+}
+
 func vulnLess(a, b vulnerability) bool {
 	h := func(v vulnerability) string {
 		return fmt.Sprintf("%#v", v)
@@ -1284,9 +1609,369 @@ func vulnLess(a, b vulnerability) bool {
 	return h(a) < h(b)
 }
 
-func statusLess(a, b checkStatus) bool {
-	h := func(v checkStatus) string {
-		return fmt.Sprintf("%#v", v)
+func TestWriter_Write_floor(t *testing.T) {
+	er := engine.Report{
+		"CheckID1": engine.CheckReport{
+			Report: vreport.Report{
+				CheckData: vreport.CheckData{
+					CheckID:       "CheckID1",
+					ChecktypeName: "Checktype1",
+					Target:        "Target1",
+					Status:        "FINISHED",
+				},
+				ResultData: vreport.ResultData{
+					Vulnerabilities: []vreport.Vulnerability{
+						{Summary: "Critical", Score: 9.0},
+						{Summary: "Medium", Score: 5.0},
+						{Summary: "Low", Score: 3.0},
+						{Summary: "Info", Score: 0},
+					},
+				},
+			},
+		},
+	}
+
+	floor := config.SeverityMedium
+	output := filepath.Join(t.TempDir(), "output.json")
+	writer, err := NewWriter(config.ReportConfig{
+		Severity:   config.SeverityLow,
+		Floor:      &floor,
+		Format:     config.OutputFormatJSON,
+		OutputFile: output,
+	})
+	if err != nil {
+		t.Fatalf("unable to create a report writer: %v", err)
+	}
+	defer writer.Close()
+
+	res, err := writer.Write(er)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.ExitCode != ExitCodeCritical {
+		t.Errorf("unexpected exit code: got: %v, want: %v", res.ExitCode, ExitCodeCritical)
+	}
+
+	wantCount := map[config.Severity]int{
+		config.SeverityCritical: 1,
+		config.SeverityMedium:   1,
+		config.SeverityLow:      1,
+		config.SeverityInfo:     1,
+	}
+	if diff := cmp.Diff(wantCount, res.Count); diff != "" {
+		t.Errorf("count mismatch (-want +got):\n%v", diff)
+	}
+
+	summaries := func(findings []Finding) []string {
+		var ss []string
+		for _, f := range findings {
+			ss = append(ss, f.Summary)
+		}
+		return ss
+	}
+	if diff := cmp.Diff([]string{"Critical", "Medium"}, summaries(res.Findings)); diff != "" {
+		t.Errorf("findings mismatch (-want +got):\n%v", diff)
+	}
+	if diff := cmp.Diff([]string{"Low"}, summaries(res.Dropped)); diff != "" {
+		t.Errorf("dropped findings mismatch (-want +got):\n%v", diff)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	var printed []vulnerability
+	if err := json.Unmarshal(data, &printed); err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	var got []string
+	for _, v := range printed {
+		got = append(got, v.Summary)
+	}
+	if diff := cmp.Diff([]string{"Critical", "Medium"}, got); diff != "" {
+		t.Errorf("printed findings mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestDedupVulns(t *testing.T) {
+	mkVuln := func(checktype, target, summary, resource string, score float32, excluded bool) vulnerability {
+		return vulnerability{
+			Vulnerability: vreport.Vulnerability{
+				Summary:          summary,
+				AffectedResource: resource,
+				Score:            score,
+			},
+			CheckData: vreport.CheckData{
+				ChecktypeName: checktype,
+				Target:        target,
+			},
+			Severity: config.ScoreToSeverity(score),
+			excluded: excluded,
+		}
+	}
+
+	withChecktypes := func(v vulnerability, cts ...string) vulnerability {
+		v.Checktypes = cts
+		return v
+	}
+
+	tests := []struct {
+		name      string
+		vulns     []vulnerability
+		want      []vulnerability
+		wantNDups int
+	}{
+		{
+			name:      "no vulnerabilities",
+			vulns:     nil,
+			want:      nil,
+			wantNDups: 0,
+		},
+		{
+			name: "no duplicates",
+			vulns: []vulnerability{
+				mkVuln("ct1", "example.com", "CVE-1", "pkg", 5, false),
+				mkVuln("ct1", "example.com", "CVE-1", "other", 5, false),
+				mkVuln("ct1", "example.org", "CVE-1", "pkg", 5, false),
+			},
+			want: []vulnerability{
+				withChecktypes(mkVuln("ct1", "example.com", "CVE-1", "pkg", 5, false), "ct1"),
+				withChecktypes(mkVuln("ct1", "example.com", "CVE-1", "other", 5, false), "ct1"),
+				withChecktypes(mkVuln("ct1", "example.org", "CVE-1", "pkg", 5, false), "ct1"),
+			},
+			wantNDups: 0,
+		},
+		{
+			name: "highest score wins",
+			vulns: []vulnerability{
+				mkVuln("ct2", "example.com", "CVE-1", "pkg", 5, false),
+				mkVuln("ct1", "example.com", "CVE-1", "pkg", 9, false),
+				mkVuln("ct3", "example.com", "CVE-1", "pkg", 7, false),
+				mkVuln("ct1", "example.com", "CVE-2", "pkg", 3, false),
+			},
+			want: []vulnerability{
+				withChecktypes(mkVuln("ct1", "example.com", "CVE-1", "pkg", 9, false), "ct1", "ct2", "ct3"),
+				withChecktypes(mkVuln("ct1", "example.com", "CVE-2", "pkg", 3, false), "ct1"),
+			},
+			wantNDups: 2,
+		},
+		{
+			name: "not excluded wins",
+			vulns: []vulnerability{
+				mkVuln("ct1", "example.com", "CVE-1", "pkg", 9, true),
+				mkVuln("ct2", "example.com", "CVE-1", "pkg", 5, false),
+			},
+			want: []vulnerability{
+				withChecktypes(mkVuln("ct2", "example.com", "CVE-1", "pkg", 5, false), "ct1", "ct2"),
+			},
+			wantNDups: 1,
+		},
+		{
+			name: "same checktype",
+			vulns: []vulnerability{
+				mkVuln("ct1", "example.com", "CVE-1", "pkg", 5, false),
+				mkVuln("ct1", "example.com", "CVE-1", "pkg", 5, false),
+			},
+			want: []vulnerability{
+				withChecktypes(mkVuln("ct1", "example.com", "CVE-1", "pkg", 5, false), "ct1"),
+			},
+			wantNDups: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ndups := dedupVulns(tt.vulns)
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(vulnerability{})); diff != "" {
+				t.Errorf("vulnerabilities mismatch (-want +got):\n%v", diff)
+			}
+			if ndups != tt.wantNDups {
+				t.Errorf("unexpected number of duplicates: got: %v, want: %v", ndups, tt.wantNDups)
+			}
+		})
+	}
+}
+
+func TestWriter_Write_dedup(t *testing.T) {
+	er := engine.Report{
+		"CheckID1": engine.CheckReport{
+			Report: vreport.Report{
+				CheckData: vreport.CheckData{
+					CheckID:       "CheckID1",
+					ChecktypeName: "Checktype1",
+					Target:        "Target1",
+					Status:        "FINISHED",
+				},
+				ResultData: vreport.ResultData{
+					Vulnerabilities: []vreport.Vulnerability{
+						{Summary: "CVE-1", AffectedResource: "pkg", Score: 5.0},
+					},
+				},
+			},
+		},
+		"CheckID2": engine.CheckReport{
+			Report: vreport.Report{
+				CheckData: vreport.CheckData{
+					CheckID:       "CheckID2",
+					ChecktypeName: "Checktype2",
+					Target:        "Target1",
+					Status:        "FINISHED",
+				},
+				ResultData: vreport.ResultData{
+					Vulnerabilities: []vreport.Vulnerability{
+						{Summary: "CVE-1", AffectedResource: "pkg", Score: 9.0},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		dedup          bool
+		wantCount      map[config.Severity]int
+		wantChecktypes [][]string
+	}{
+		{
+			name:  "raw",
+			dedup: false,
+			wantCount: map[config.Severity]int{
+				config.SeverityCritical: 1,
+				config.SeverityMedium:   1,
+			},
+			wantChecktypes: [][]string{nil, nil},
+		},
+		{
+			name:  "deduplicated",
+			dedup: true,
+			wantCount: map[config.Severity]int{
+				config.SeverityCritical: 1,
+			},
+			wantChecktypes: [][]string{{"Checktype1", "Checktype2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "output.json")
+			writer, err := NewWriter(config.ReportConfig{
+				Severity:   config.SeverityInfo,
+				Format:     config.OutputFormatJSON,
+				OutputFile: output,
+				Dedup:      tt.dedup,
+			})
+			if err != nil {
+				t.Fatalf("unable to create a report writer: %v", err)
+			}
+			defer writer.Close()
+
+			res, err := writer.Write(er)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantCount, res.Count); diff != "" {
+				t.Errorf("count mismatch (-want +got):\n%v", diff)
+			}
+
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatalf("read output: %v", err)
+			}
+			var printed []vulnerability
+			if err := json.Unmarshal(data, &printed); err != nil {
+				t.Fatalf("decode output: %v", err)
+			}
+			var got [][]string
+			for _, v := range printed {
+				got = append(got, v.Checktypes)
+			}
+			if diff := cmp.Diff(tt.wantChecktypes, got); diff != "" {
+				t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestWriter_Stream(t *testing.T) {
+	er := engine.Report{
+		"CheckID1": engine.CheckReport{
+			Report: vreport.Report{
+				CheckData: vreport.CheckData{
+					CheckID:       "CheckID1",
+					ChecktypeName: "Checktype1",
+					Target:        "Target1",
+					Status:        "FINISHED",
+				},
+				ResultData: vreport.ResultData{
+					Vulnerabilities: []vreport.Vulnerability{
+						{Summary: "Critical", Score: 9.0},
+					},
+				},
+			},
+		},
+		"CheckID2": engine.CheckReport{
+			Report: vreport.Report{
+				CheckData: vreport.CheckData{
+					CheckID:       "CheckID2",
+					ChecktypeName: "Checktype2",
+					Target:        "Target1",
+					Status:        "FAILED",
+				},
+			},
+		},
+	}
+
+	output := filepath.Join(t.TempDir(), "output.jsonl")
+	writer, err := NewWriter(config.ReportConfig{
+		Severity:   config.SeverityInfo,
+		Format:     config.OutputFormatJSONLReports,
+		OutputFile: output,
+	})
+	if err != nil {
+		t.Fatalf("unable to create a report writer: %v", err)
+	}
+	defer writer.Close()
+
+	readLines := func() []string {
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("read output: %v", err)
+		}
+		var ids []string
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			var cl checkLine
+			if err := json.Unmarshal([]byte(line), &cl); err != nil {
+				t.Fatalf("decode line %q: %v", line, err)
+			}
+			ids = append(ids, cl.CheckID)
+		}
+		return ids
+	}
+
+	// Failed checks are not streamed, because they may be
+	// retried.
+	for _, checkID := range []string{"CheckID1", "CheckID2", "CheckID1"} {
+		if err := writer.Stream(er[checkID]); err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+	}
+	if diff := cmp.Diff([]string{"CheckID1"}, readLines()); diff != "" {
+		t.Errorf("streamed checks mismatch (-want +got):\n%v", diff)
+	}
+
+	res, err := writer.Write(er)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ExitCode != ExitCodeCheckError {
+		t.Errorf("unexpected exit code: got: %v, want: %v", res.ExitCode, ExitCodeCheckError)
+	}
+	if diff := cmp.Diff([]string{"CheckID1", "CheckID2"}, readLines()); diff != "" {
+		t.Errorf("written checks mismatch (-want +got):\n%v", diff)
 	}
-	return h(a) < h(b)
 }
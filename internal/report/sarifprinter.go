@@ -0,0 +1,252 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// SARIF version and schema of the generated reports.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// sarifPrinter represents a SARIF report printer. The generated
+// reports can be uploaded to GitHub code scanning.
+type sarifPrinter struct{}
+
+// sarifLog is the root object of a SARIF report.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun describes a single run of an analysis tool.
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+// sarifTool describes the analysis tool.
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+// sarifDriver describes the component of the tool that contains the
+// rules.
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+// sarifRule describes a kind of finding.
+type sarifRule struct {
+	ID               string              `json:"id"`
+	ShortDescription sarifMessage        `json:"shortDescription"`
+	FullDescription  *sarifMessage       `json:"fullDescription,omitempty"`
+	Help             *sarifMessage       `json:"help,omitempty"`
+	HelpURI          string              `json:"helpUri,omitempty"`
+	Properties       sarifRuleProperties `json:"properties"`
+}
+
+// sarifRuleProperties are the properties of a rule. GitHub code
+// scanning uses the security severity to rank the alerts.
+type sarifRuleProperties struct {
+	Checktype        string   `json:"checktype"`
+	Tags             []string `json:"tags"`
+	SecuritySeverity string   `json:"security-severity"`
+}
+
+// sarifMessage is a plain text message.
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifInvocation describes the execution of the tool.
+type sarifInvocation struct {
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+}
+
+// sarifResult is a finding.
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+// sarifLocation is the location of a finding.
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+// sarifPhysicalLocation is the physical location of a finding.
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+// sarifArtifactLocation is the location of an artifact.
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Print renders the scan results in SARIF format. Every finding is
+// mapped to a result whose rule is identified by the checktype and
+// the summary of the finding. The target of the check is used as the
+// location of the result.
+func (prn sarifPrinter) Print(w io.Writer, vulns []vulnerability, _ summary, status []checkStatus) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "Lava",
+				InformationURI: "https://github.com/adevinta/lava",
+				Rules:          []sarifRule{},
+			},
+		},
+		Invocations: []sarifInvocation{{ExecutionSuccessful: true}},
+		Results:     []sarifResult{},
+	}
+
+	for _, cs := range status {
+		if cs.errored() {
+			run.Invocations[0].ExecutionSuccessful = false
+			break
+		}
+	}
+
+	var (
+		rules  = make(map[string]int)
+		scores []float32
+	)
+	for _, v := range vulns {
+		id := sarifRuleID(v)
+		idx, ok := rules[id]
+		if !ok {
+			idx = len(run.Tool.Driver.Rules)
+			rules[id] = idx
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, mkSarifRule(id, v))
+			scores = append(scores, v.Score)
+		}
+
+		// The rule keeps the highest score of its findings.
+		scores[idx] = max(scores[idx], v.Score)
+
+		run.Results = append(run.Results, mkSarifResult(id, idx, v))
+	}
+	for i, score := range scores {
+		run.Tool.Driver.Rules[i].Properties.SecuritySeverity = fmt.Sprintf("%.1f", score)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(log); err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	return nil
+}
+
+// sarifRuleID returns the ID of the rule of the provided
+// vulnerability. It is composed of the checktype name and a hash of
+// the summary of the vulnerability.
+func sarifRuleID(v vulnerability) string {
+	sum := sha256.Sum256([]byte(v.Summary))
+	return v.CheckData.ChecktypeName + "/" + hex.EncodeToString(sum[:6])
+}
+
+// mkSarifRule returns the rule of the provided vulnerability.
+func mkSarifRule(id string, v vulnerability) sarifRule {
+	rule := sarifRule{
+		ID:               id,
+		ShortDescription: sarifMessage{Text: v.Summary},
+		Properties: sarifRuleProperties{
+			Checktype: v.CheckData.ChecktypeName,
+			Tags:      []string{"security"},
+		},
+	}
+	if v.Description != "" {
+		rule.FullDescription = &sarifMessage{Text: v.Description}
+	}
+	if len(v.Recommendations) > 0 {
+		rule.Help = &sarifMessage{Text: strings.Join(v.Recommendations, "\n")}
+	}
+	for _, ref := range v.References {
+		if u, err := url.Parse(ref); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			rule.HelpURI = ref
+			break
+		}
+	}
+	return rule
+}
+
+// mkSarifResult returns the result of the provided vulnerability.
+func mkSarifResult(ruleID string, ruleIndex int, v vulnerability) sarifResult {
+	msg := v.Summary
+	if res := firstNonEmpty(v.AffectedResourceString, v.AffectedResource); res != "" {
+		msg += ": " + res
+	}
+
+	result := sarifResult{
+		RuleID:    ruleID,
+		RuleIndex: ruleIndex,
+		Level:     sarifLevel(v.Severity),
+		Message:   sarifMessage{Text: msg},
+		Locations: []sarifLocation{
+			{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: v.CheckData.Target},
+				},
+			},
+		},
+	}
+	if v.Fingerprint != "" {
+		result.PartialFingerprints = map[string]string{"lavaFingerprint/v1": v.Fingerprint}
+	}
+	if len(v.TargetLabels) > 0 {
+		result.Properties = map[string]any{"target_labels": v.TargetLabels}
+	}
+	return result
+}
+
+// sarifLevel maps the provided severity to a SARIF level.
+func sarifLevel(sev config.Severity) string {
+	switch {
+	case sev >= config.SeverityHigh:
+		return "error"
+	case sev == config.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// firstNonEmpty returns the first of its arguments that is not
+// empty. If all the arguments are empty, it returns an empty string.
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,364 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestSarifPrinter_Print(t *testing.T) {
+	vulns := []vulnerability{
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary:          "Outdated Drupal",
+				Description:      "The Drupal version is outdated.",
+				Score:            8.9,
+				AffectedResource: "/CHANGELOG.txt",
+				Recommendations:  []string{"Upgrade Drupal."},
+				References:       []string{"Drupal docs", "https://www.drupal.org/"},
+				Fingerprint:      "fingerprint1",
+			},
+			CheckData: vreport.CheckData{
+				ChecktypeName: "vulcan-drupal",
+				Target:        "https://example.com",
+			},
+			Severity: config.SeverityHigh,
+		},
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary: "Outdated Drupal",
+				Score:   9.8,
+			},
+			CheckData: vreport.CheckData{
+				ChecktypeName: "vulcan-drupal",
+				Target:        "https://example.org",
+			},
+			Severity: config.SeverityCritical,
+		},
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary: "Secret in repository",
+				Score:   5.0,
+			},
+			CheckData: vreport.CheckData{
+				ChecktypeName: "vulcan-gitleaks",
+				Target:        ".",
+			},
+			Severity: config.SeverityMedium,
+		},
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary: "Open port",
+				Score:   0,
+			},
+			CheckData: vreport.CheckData{
+				ChecktypeName: "vulcan-nessus",
+				Target:        "example.com",
+			},
+			Severity: config.SeverityInfo,
+		},
+	}
+
+	tests := []struct {
+		name           string
+		vulns          []vulnerability
+		status         []checkStatus
+		wantLevels     []string
+		wantRules      int
+		wantSeverities []string
+		wantSuccessful bool
+	}{
+		{
+			name:           "findings",
+			vulns:          vulns,
+			status:         []checkStatus{{Checktype: "vulcan-drupal", Target: "https://example.com", Status: "FINISHED"}},
+			wantLevels:     []string{"error", "error", "warning", "note"},
+			wantRules:      3,
+			wantSeverities: []string{"9.8", "5.0", "0.0"},
+			wantSuccessful: true,
+		},
+		{
+			name:           "no findings",
+			vulns:          nil,
+			status:         []checkStatus{{Checktype: "vulcan-drupal", Target: "https://example.com", Status: "FAILED"}},
+			wantLevels:     nil,
+			wantRules:      0,
+			wantSeverities: nil,
+			wantSuccessful: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (sarifPrinter{}).Print(&buf, tt.vulns, summary{}, tt.status); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			validateSarif(t, buf.Bytes())
+
+			var got sarifLog
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("unmarshal report: %v", err)
+			}
+
+			run := got.Runs[0]
+
+			var levels []string
+			for _, r := range run.Results {
+				levels = append(levels, r.Level)
+			}
+			if diff := cmp.Diff(tt.wantLevels, levels); diff != "" {
+				t.Errorf("levels mismatch (-want +got):\n%v", diff)
+			}
+
+			if len(run.Tool.Driver.Rules) != tt.wantRules {
+				t.Errorf("unexpected number of rules: got: %v, want: %v", len(run.Tool.Driver.Rules), tt.wantRules)
+			}
+
+			var sevs []string
+			for _, r := range run.Tool.Driver.Rules {
+				sevs = append(sevs, r.Properties.SecuritySeverity)
+			}
+			if diff := cmp.Diff(tt.wantSeverities, sevs); diff != "" {
+				t.Errorf("security severities mismatch (-want +got):\n%v", diff)
+			}
+
+			if run.Invocations[0].ExecutionSuccessful != tt.wantSuccessful {
+				t.Errorf("unexpected execution status: %v", run.Invocations[0].ExecutionSuccessful)
+			}
+		})
+	}
+}
+
+func TestSarifPrinter_Print_result(t *testing.T) {
+	v := vulnerability{
+		Vulnerability: vreport.Vulnerability{
+			Summary:          "Outdated Drupal",
+			Description:      "The Drupal version is outdated.",
+			Score:            8.9,
+			AffectedResource: "/CHANGELOG.txt",
+			Recommendations:  []string{"Upgrade Drupal.", "Remove CHANGELOG.txt."},
+			References:       []string{"Drupal docs", "https://www.drupal.org/"},
+			Fingerprint:      "fingerprint1",
+		},
+		CheckData: vreport.CheckData{
+			ChecktypeName: "vulcan-drupal",
+			Target:        "https://example.com",
+		},
+		Severity: config.SeverityHigh,
+	}
+
+	var buf bytes.Buffer
+	if err := (sarifPrinter{}).Print(&buf, []vulnerability{v}, summary{}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal report: %v", err)
+	}
+
+	ruleID := sarifRuleID(v)
+
+	wantRules := []sarifRule{
+		{
+			ID:               ruleID,
+			ShortDescription: sarifMessage{Text: "Outdated Drupal"},
+			FullDescription:  &sarifMessage{Text: "The Drupal version is outdated."},
+			Help:             &sarifMessage{Text: "Upgrade Drupal.\nRemove CHANGELOG.txt."},
+			HelpURI:          "https://www.drupal.org/",
+			Properties: sarifRuleProperties{
+				Checktype:        "vulcan-drupal",
+				Tags:             []string{"security"},
+				SecuritySeverity: "8.9",
+			},
+		},
+	}
+	if diff := cmp.Diff(wantRules, got.Runs[0].Tool.Driver.Rules); diff != "" {
+		t.Errorf("rules mismatch (-want +got):\n%v", diff)
+	}
+
+	wantResults := []sarifResult{
+		{
+			RuleID:    ruleID,
+			RuleIndex: 0,
+			Level:     "error",
+			Message:   sarifMessage{Text: "Outdated Drupal: /CHANGELOG.txt"},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: "https://example.com"},
+					},
+				},
+			},
+			PartialFingerprints: map[string]string{"lavaFingerprint/v1": "fingerprint1"},
+		},
+	}
+	if diff := cmp.Diff(wantResults, got.Runs[0].Results); diff != "" {
+		t.Errorf("results mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestSarifPrinter_Print_targetLabels(t *testing.T) {
+	tests := []struct {
+		name           string
+		labels         map[string]string
+		wantProperties map[string]any
+	}{
+		{
+			name: "labels",
+			labels: map[string]string{
+				"owner":       "team-a",
+				"environment": "production",
+			},
+			wantProperties: map[string]any{
+				"target_labels": map[string]any{
+					"owner":       "team-a",
+					"environment": "production",
+				},
+			},
+		},
+		{
+			name:           "no labels",
+			labels:         nil,
+			wantProperties: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vulnerability{
+				Vulnerability: vreport.Vulnerability{
+					Summary: "Outdated Drupal",
+					Score:   8.9,
+				},
+				CheckData: vreport.CheckData{
+					ChecktypeName: "vulcan-drupal",
+					Target:        "https://example.com",
+				},
+				Severity:     config.SeverityHigh,
+				TargetLabels: tt.labels,
+			}
+
+			var buf bytes.Buffer
+			if err := (sarifPrinter{}).Print(&buf, []vulnerability{v}, summary{}, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			validateSarif(t, buf.Bytes())
+
+			var got sarifLog
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("unmarshal report: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantProperties, got.Runs[0].Results[0].Properties); diff != "" {
+				t.Errorf("properties mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+// validateSarif checks that the provided document satisfies the
+// constraints of the SARIF 2.1.0 schema that apply to the properties
+// generated by [sarifPrinter].
+func validateSarif(t *testing.T, data []byte) {
+	t.Helper()
+
+	var doc struct {
+		Schema  *string `json:"$schema"`
+		Version *string `json:"version"`
+		Runs    []struct {
+			Tool *struct {
+				Driver *struct {
+					Name  *string `json:"name"`
+					Rules []struct {
+						ID *string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Invocations []struct {
+				ExecutionSuccessful *bool `json:"executionSuccessful"`
+			} `json:"invocations"`
+			Results *[]struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex *int   `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Message   *struct {
+					Text *string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation *struct {
+						ArtifactLocation *struct {
+							URI *string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if doc.Version == nil || *doc.Version != "2.1.0" {
+		t.Fatalf("invalid version: %v", doc.Version)
+	}
+	if doc.Schema == nil || *doc.Schema == "" {
+		t.Errorf("missing $schema")
+	}
+	if doc.Runs == nil {
+		t.Fatalf("missing runs")
+	}
+
+	for _, run := range doc.Runs {
+		if run.Tool == nil || run.Tool.Driver == nil || run.Tool.Driver.Name == nil {
+			t.Fatalf("missing tool driver name")
+		}
+
+		var ids []string
+		for _, r := range run.Tool.Driver.Rules {
+			if r.ID == nil || *r.ID == "" {
+				t.Errorf("missing rule id")
+				continue
+			}
+			ids = append(ids, *r.ID)
+		}
+
+		for _, inv := range run.Invocations {
+			if inv.ExecutionSuccessful == nil {
+				t.Errorf("missing invocation executionSuccessful")
+			}
+		}
+
+		if run.Results == nil {
+			t.Fatalf("missing results")
+		}
+		for _, r := range *run.Results {
+			if r.Message == nil || r.Message.Text == nil {
+				t.Errorf("missing result message text")
+			}
+			if !slices.Contains([]string{"none", "note", "warning", "error"}, r.Level) {
+				t.Errorf("invalid result level: %q", r.Level)
+			}
+			if r.RuleIndex == nil || *r.RuleIndex < 0 || *r.RuleIndex >= len(ids) || ids[*r.RuleIndex] != r.RuleID {
+				t.Errorf("invalid rule reference: %v %v", r.RuleID, r.RuleIndex)
+			}
+			for _, loc := range r.Locations {
+				if loc.PhysicalLocation == nil || loc.PhysicalLocation.ArtifactLocation == nil || loc.PhysicalLocation.ArtifactLocation.URI == nil {
+					t.Errorf("invalid result location")
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+// webhookPayloadVersion is the version of the schema of the payload
+// sent to webhooks. It must be increased every time the schema
+// changes in a backwards incompatible way.
+const webhookPayloadVersion = "1"
+
+// defaultWebhookRetries is the default maximum number of times a
+// failed webhook delivery is retried.
+const defaultWebhookRetries = 3
+
+// webhookRetryInterval is the base time between webhook delivery
+// attempts. The waiting time increases linearly with every attempt.
+// It is a variable, so tests can modify it.
+var webhookRetryInterval = time.Second
+
+// webhookTimeout is the maximum time a webhook delivery attempt can
+// take.
+const webhookTimeout = 30 * time.Second
+
+// webhookPayload is the payload sent to webhooks. Its JSON encoding
+// is considered part of the public interface of Lava.
+type webhookPayload struct {
+	// Version is the version of the payload schema.
+	Version string `json:"version"`
+
+	// Findings are the reported findings. They are encoded like
+	// in the JSON output format.
+	Findings []vulnerability `json:"findings"`
+
+	// CheckErrors are the checks that did not finish
+	// successfully.
+	CheckErrors []CheckError `json:"check_errors,omitempty"`
+}
+
+// sendWebhook sends the provided vulnerabilities and check errors to
+// the configured webhook using an HTTP POST request. Failed
+// deliveries are retried. It returns the error of the last attempt.
+func sendWebhook(cfg config.WebhookConfig, vulns []vulnerability, checkErrs []CheckError) error {
+	payload := webhookPayload{
+		Version:     webhookPayloadVersion,
+		Findings:    vulns,
+		CheckErrors: checkErrs,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode payload: %w", err)
+	}
+
+	retries := cfg.Retries
+	if retries == 0 {
+		retries = defaultWebhookRetries
+	}
+
+	for i := 0; ; i++ {
+		err = postWebhook(cfg, body)
+		if err == nil || i >= retries {
+			return err
+		}
+		time.Sleep(time.Duration(i+1) * webhookRetryInterval)
+	}
+}
+
+// postWebhook sends the provided body to the configured webhook.
+func postWebhook(cfg config.WebhookConfig, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	resp, err := urlutil.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("invalid status code: %v", resp.StatusCode)
+	}
+	return nil
+}
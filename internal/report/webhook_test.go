@@ -0,0 +1,121 @@
+// Copyright 2023 Adevinta
+
+package report
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestSendWebhook(t *testing.T) {
+	oldRetryInterval := webhookRetryInterval
+	webhookRetryInterval = 0
+	t.Cleanup(func() { webhookRetryInterval = oldRetryInterval })
+
+	vulns := []vulnerability{
+		{
+			CheckData: vreport.CheckData{
+				CheckID: "CheckID1",
+			},
+			Vulnerability: vreport.Vulnerability{
+				Summary: "Vulnerability Summary 1",
+			},
+			Severity: config.SeverityHigh,
+		},
+	}
+
+	checkErrs := []CheckError{
+		{
+			Checktype: "Checktype2",
+			Target:    "Target1",
+			Status:    "FAILED",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		failures     int32
+		retries      int
+		wantRequests int32
+		wantNilErr   bool
+	}{
+		{
+			name:         "delivered",
+			failures:     0,
+			wantRequests: 1,
+			wantNilErr:   true,
+		},
+		{
+			name:         "delivered after retries",
+			failures:     2,
+			wantRequests: 3,
+			wantNilErr:   true,
+		},
+		{
+			name:         "retries exhausted",
+			failures:     10,
+			retries:      1,
+			wantRequests: 2,
+			wantNilErr:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LAVA_TEST_TOKEN", "s3cr3t")
+
+			var (
+				requests atomic.Int32
+				got      webhookPayload
+			)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if n := requests.Add(1); n <= tt.failures {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if auth := r.Header.Get("Authorization"); auth != "Bearer s3cr3t" {
+					t.Errorf("unexpected authorization header: %q", auth)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("could not decode payload: %v", err)
+				}
+			}))
+			defer ts.Close()
+
+			cfg := config.WebhookConfig{
+				URL: ts.URL,
+				Headers: map[string]string{
+					"Authorization": "Bearer ${LAVA_TEST_TOKEN}",
+				},
+				Retries: tt.retries,
+			}
+			err := sendWebhook(cfg, vulns, checkErrs)
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if n := requests.Load(); n != tt.wantRequests {
+				t.Errorf("unexpected number of requests: got: %v, want: %v", n, tt.wantRequests)
+			}
+			if !tt.wantNilErr {
+				return
+			}
+
+			want := webhookPayload{
+				Version:     "1",
+				Findings:    vulns,
+				CheckErrors: checkErrs,
+			}
+			if diff := cmp.Diff(want, got, cmp.AllowUnexported(vulnerability{})); diff != "" {
+				t.Errorf("payload mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 Adevinta
+
+package targetsources
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+// ErrMalformedCSV is returned when the format of a CSV target source
+// is not valid.
+var ErrMalformedCSV = errors.New("malformed CSV")
+
+// Default names of the columns of a CSV source.
+const (
+	DefaultCSVIdentifierColumn = "identifier"
+	DefaultCSVAssetTypeColumn  = "type"
+)
+
+// CSV is a [Source] that reads the targets from a CSV file with
+// headers. Every row is converted into a target.
+type CSV struct {
+	// URL points to the CSV file. If the URL omits the scheme,
+	// it is considered a file path.
+	URL string
+
+	// Columns defines the columns that contain the identifier
+	// and the asset type of the targets. Empty fields are set to
+	// DefaultCSVIdentifierColumn and DefaultCSVAssetTypeColumn.
+	Columns config.CSVColumns
+}
+
+// Targets returns the targets found in the CSV file. The rest of the
+// columns are used as target labels. Empty values are ignored. Rows
+// with an empty identifier or an invalid asset type are skipped.
+func (c CSV) Targets() ([]config.Target, error) {
+	data, err := urlutil.Get(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("get CSV: %w", err)
+	}
+
+	identCol := c.Columns.Identifier
+	if identCol == "" {
+		identCol = DefaultCSVIdentifierColumn
+	}
+	atCol := c.Columns.AssetType
+	if atCol == "" {
+		atCol = DefaultCSVAssetTypeColumn
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: read header: %w", ErrMalformedCSV, err)
+	}
+
+	identIdx, atIdx := -1, -1
+	for i, name := range header {
+		switch name {
+		case identCol:
+			identIdx = i
+		case atCol:
+			atIdx = i
+		}
+	}
+	if identIdx < 0 {
+		return nil, fmt.Errorf("%w: missing column: %v", ErrMalformedCSV, identCol)
+	}
+	if atIdx < 0 {
+		return nil, fmt.Errorf("%w: missing column: %v", ErrMalformedCSV, atCol)
+	}
+
+	var targets []config.Target
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedCSV, err)
+		}
+		line, _ := r.FieldPos(0)
+
+		ident := record[identIdx]
+		if ident == "" {
+			slog.Warn("skipping CSV row with empty identifier", "url", c.URL, "line", line)
+			continue
+		}
+
+		at := types.AssetType(record[atIdx])
+		if !at.IsValid() && !assettypes.IsValid(at) {
+			slog.Warn("skipping CSV row with invalid asset type", "url", c.URL, "line", line, "type", at)
+			continue
+		}
+
+		var labels map[string]string
+		for i, v := range record {
+			if i == identIdx || i == atIdx || v == "" {
+				continue
+			}
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[header[i]] = v
+		}
+
+		t := config.Target{
+			Identifier: ident,
+			AssetType:  at,
+			Labels:     labels,
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
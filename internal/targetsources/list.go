@@ -0,0 +1,115 @@
+// Copyright 2023 Adevinta
+
+package targetsources
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+// ErrMalformedList is returned when the format of a list target
+// source is not valid.
+var ErrMalformedList = errors.New("malformed list")
+
+// List is a [Source] that reads the targets from a text file with one
+// target per line. Every line contains either the identifier and the
+// asset type of the target separated by white space, or a JSON object
+// with the fields "identifier", "type" and, optionally, "labels".
+// Blank lines and lines starting with "#" are ignored. For instance,
+//
+//	# Production assets.
+//	example.com DomainName
+//	{"identifier": "192.0.2.1", "type": "IP", "labels": {"owner": "team-a"}}
+type List struct {
+	// URL points to the list file. If the URL omits the scheme,
+	// it is considered a file path.
+	URL string
+}
+
+// listEntry is a target encoded as a JSON object in a list.
+type listEntry struct {
+	Identifier string            `json:"identifier"`
+	AssetType  types.AssetType   `json:"type"`
+	Labels     map[string]string `json:"labels"`
+}
+
+// Targets returns the targets found in the list file. If a line is
+// malformed, it returns an error that contains the line number.
+func (l List) Targets() ([]config.Target, error) {
+	data, err := urlutil.Get(l.URL)
+	if err != nil {
+		return nil, fmt.Errorf("get list: %w", err)
+	}
+
+	var (
+		targets []config.Target
+		lineno  int
+	)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		lineno++
+
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		t, err := parseListLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %v: %w", ErrMalformedList, lineno, err)
+		}
+		targets = append(targets, t)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read list: %w", err)
+	}
+	return targets, nil
+}
+
+// parseListLine parses a non-empty line of a list target source.
+func parseListLine(line string) (config.Target, error) {
+	var entry listEntry
+	if strings.HasPrefix(line, "{") {
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&entry); err != nil {
+			return config.Target{}, fmt.Errorf("decode JSON: %w", err)
+		}
+		if dec.More() {
+			return config.Target{}, errors.New("unexpected data after JSON object")
+		}
+	} else {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return config.Target{}, fmt.Errorf("wrong number of fields: %v", len(fields))
+		}
+		entry = listEntry{
+			Identifier: fields[0],
+			AssetType:  types.AssetType(fields[1]),
+		}
+	}
+
+	if entry.Identifier == "" {
+		return config.Target{}, errors.New("empty identifier")
+	}
+	if !entry.AssetType.IsValid() && !assettypes.IsValid(entry.AssetType) {
+		return config.Target{}, fmt.Errorf("invalid asset type: %v", entry.AssetType)
+	}
+
+	t := config.Target{
+		Identifier: entry.Identifier,
+		AssetType:  entry.AssetType,
+		Labels:     entry.Labels,
+	}
+	return t, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 Adevinta
+
+// Package targetsources retrieves targets from external sources like
+// Terraform state files, CSV files or lists of targets.
+package targetsources
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// ErrUnsupportedSource is returned by [New] when the type of the
+// provided target source is not supported.
+var ErrUnsupportedSource = errors.New("unsupported target source")
+
+// A Source is an external source of targets.
+type Source interface {
+	// Targets returns the targets provided by the source.
+	Targets() ([]config.Target, error)
+}
+
+// New returns the [Source] corresponding to the provided
+// configuration.
+func New(cfg config.TargetSource) (Source, error) {
+	switch cfg.Type {
+	case config.TargetSourceTerraform:
+		return Terraform{URL: cfg.URL, Mappings: cfg.Mappings}, nil
+	case config.TargetSourceCSV:
+		return CSV{URL: cfg.URL, Columns: cfg.Columns}, nil
+	case config.TargetSourceList:
+		return List{URL: cfg.URL}, nil
+	}
+	return nil, fmt.Errorf("%w: %v", ErrUnsupportedSource, cfg.Type)
+}
+
+// Targets returns the targets provided by all the specified sources.
+func Targets(cfgs []config.TargetSource) ([]config.Target, error) {
+	var targets []config.Target
+	for _, cfg := range cfgs {
+		src, err := New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("new target source: %w", err)
+		}
+
+		ts, err := src.Targets()
+		if err != nil {
+			return nil, fmt.Errorf("get targets from %v source %q: %w", cfg.Type, cfg.URL, err)
+		}
+		targets = append(targets, ts...)
+	}
+	return targets, nil
+}
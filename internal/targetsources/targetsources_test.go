@@ -0,0 +1,285 @@
+// Copyright 2023 Adevinta
+
+package targetsources
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestTerraform_Targets(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		mappings   []config.ResourceMapping
+		want       []config.Target
+		wantErr    error
+		wantNilErr bool
+	}{
+		{
+			name: "default mappings",
+			url:  "testdata/terraform.tfstate",
+			want: []config.Target{
+				{
+					Identifier: "192.0.2.1",
+					AssetType:  types.IP,
+					Labels: map[string]string{
+						"terraform_address": "aws_eip.web[0]",
+						"terraform_type":    "aws_eip",
+					},
+				},
+				{
+					Identifier: "www.example.com",
+					AssetType:  types.Hostname,
+					Labels: map[string]string{
+						"terraform_address": "module.dns.aws_route53_record.www",
+						"terraform_type":    "aws_route53_record",
+					},
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "custom mappings",
+			url:  "testdata/terraform.tfstate",
+			mappings: []config.ResourceMapping{
+				{
+					Resource:  "aws_route53_record",
+					Attribute: "fqdn",
+					AssetType: types.WebAddress,
+				},
+			},
+			want: []config.Target{
+				{
+					Identifier: "www.example.com",
+					AssetType:  types.WebAddress,
+					Labels: map[string]string{
+						"terraform_address": "module.dns.aws_route53_record.www",
+						"terraform_type":    "aws_route53_record",
+					},
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name:       "unsupported version",
+			url:        "testdata/unsupported_version.tfstate",
+			want:       nil,
+			wantErr:    ErrMalformedState,
+			wantNilErr: false,
+		},
+		{
+			name:       "file not found",
+			url:        "testdata/not_found.tfstate",
+			want:       nil,
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := Terraform{URL: tt.url, Mappings: tt.mappings}
+			got, err := tf.Targets()
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestCSV_Targets(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		columns    config.CSVColumns
+		want       []config.Target
+		wantErr    error
+		wantNilErr bool
+	}{
+		{
+			name: "default columns",
+			url:  "testdata/targets.csv",
+			want: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Labels: map[string]string{
+						"environment": "production",
+						"owner":       "team-a",
+					},
+				},
+				{
+					Identifier: "192.0.2.1",
+					AssetType:  types.IP,
+					Labels: map[string]string{
+						"owner": "team-b",
+					},
+				},
+				{
+					Identifier: ".",
+					AssetType:  assettypes.Path,
+					Labels: map[string]string{
+						"environment": "development",
+					},
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "custom columns",
+			url:  "testdata/custom_columns.csv",
+			columns: config.CSVColumns{
+				Identifier: "host",
+				AssetType:  "kind",
+			},
+			want: []config.Target{
+				{
+					Identifier: "www.example.com",
+					AssetType:  types.Hostname,
+					Labels: map[string]string{
+						"env": "production",
+					},
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name:       "missing column",
+			url:        "testdata/missing_type_column.csv",
+			want:       nil,
+			wantErr:    ErrMalformedCSV,
+			wantNilErr: false,
+		},
+		{
+			name:       "wrong number of fields",
+			url:        "testdata/malformed.csv",
+			want:       nil,
+			wantErr:    ErrMalformedCSV,
+			wantNilErr: false,
+		},
+		{
+			name:       "file not found",
+			url:        "testdata/not_found.csv",
+			want:       nil,
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CSV{URL: tt.url, Columns: tt.columns}
+			got, err := c.Targets()
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestList_Targets(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		want          []config.Target
+		wantErr       error
+		wantErrRegexp *regexp.Regexp
+		wantNilErr    bool
+	}{
+		{
+			name: "valid list",
+			url:  "testdata/targets.txt",
+			want: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+				},
+				{
+					Identifier: "192.0.2.1",
+					AssetType:  types.IP,
+				},
+				{
+					Identifier: ".",
+					AssetType:  assettypes.Path,
+					Labels: map[string]string{
+						"owner": "team-a",
+					},
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name:          "missing type",
+			url:           "testdata/missing_type.txt",
+			want:          nil,
+			wantErr:       ErrMalformedList,
+			wantErrRegexp: regexp.MustCompile(`line 4: wrong number of fields`),
+			wantNilErr:    false,
+		},
+		{
+			name:          "malformed JSON",
+			url:           "testdata/malformed_json.txt",
+			want:          nil,
+			wantErr:       ErrMalformedList,
+			wantErrRegexp: regexp.MustCompile(`line 2: decode JSON`),
+			wantNilErr:    false,
+		},
+		{
+			name:          "invalid asset type",
+			url:           "testdata/invalid_asset_type.txt",
+			want:          nil,
+			wantErr:       ErrMalformedList,
+			wantErrRegexp: regexp.MustCompile(`line 1: invalid asset type: Domain`),
+			wantNilErr:    false,
+		},
+		{
+			name:       "file not found",
+			url:        "testdata/not_found.txt",
+			want:       nil,
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := List{URL: tt.url}
+			got, err := l.Targets()
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+			if tt.wantErrRegexp != nil && (err == nil || !tt.wantErrRegexp.MatchString(err.Error())) {
+				t.Errorf("unexpected error: got: %v, want: %v", err, tt.wantErrRegexp)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(config.TargetSource{Type: "unknown"}); !errors.Is(err, ErrUnsupportedSource) {
+		t.Errorf("unexpected error: got: %v, want: %v", err, ErrUnsupportedSource)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 Adevinta
+
+package targetsources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+// ErrMalformedState is returned when the format of the Terraform
+// state is not valid.
+var ErrMalformedState = errors.New("malformed Terraform state")
+
+// DefaultTerraformMappings are the mappings used by [Terraform] when
+// no mappings are configured.
+var DefaultTerraformMappings = []config.ResourceMapping{
+	{Resource: "aws_eip", Attribute: "public_ip", AssetType: types.IP},
+	{Resource: "aws_instance", Attribute: "public_ip", AssetType: types.IP},
+	{Resource: "aws_lb", Attribute: "dns_name", AssetType: types.Hostname},
+	{Resource: "aws_route53_record", Attribute: "fqdn", AssetType: types.Hostname},
+	{Resource: "aws_route53_zone", Attribute: "name", AssetType: types.DomainName},
+	{Resource: "google_compute_address", Attribute: "address", AssetType: types.IP},
+	{Resource: "azurerm_public_ip", Attribute: "ip_address", AssetType: types.IP},
+	{Resource: "github_repository", Attribute: "http_clone_url", AssetType: types.GitRepository},
+}
+
+// Terraform is a [Source] that reads the targets from a Terraform
+// state file. Only state files with format version 4 are supported.
+type Terraform struct {
+	// URL points to the Terraform state file. If the URL omits
+	// the scheme, it is considered a file path.
+	URL string
+
+	// Mappings is the list of rules used to convert Terraform
+	// resources into targets. If empty, DefaultTerraformMappings
+	// is used.
+	Mappings []config.ResourceMapping
+}
+
+// tfState represents the subset of a Terraform state file that is
+// relevant to generate targets.
+type tfState struct {
+	Version   int          `json:"version"`
+	Resources []tfResource `json:"resources"`
+}
+
+// tfResource represents a resource of a Terraform state file.
+type tfResource struct {
+	Module    string       `json:"module"`
+	Mode      string       `json:"mode"`
+	Type      string       `json:"type"`
+	Name      string       `json:"name"`
+	Instances []tfInstance `json:"instances"`
+}
+
+// tfInstance represents an instance of a Terraform resource.
+type tfInstance struct {
+	IndexKey   any            `json:"index_key"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// Targets returns the targets found in the Terraform state. Resources
+// without a matching mapping are skipped. The generated targets are
+// labeled with the Terraform address and type of the resource.
+func (tf Terraform) Targets() ([]config.Target, error) {
+	data, err := urlutil.Get(tf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("get state: %w", err)
+	}
+
+	var state tfState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedState, err)
+	}
+	if state.Version != 4 {
+		return nil, fmt.Errorf("%w: unsupported version: %v", ErrMalformedState, state.Version)
+	}
+
+	mappings := tf.Mappings
+	if len(mappings) == 0 {
+		mappings = DefaultTerraformMappings
+	}
+
+	var targets []config.Target
+	for _, rsc := range state.Resources {
+		if rsc.Mode != "managed" {
+			continue
+		}
+
+		for _, m := range mappings {
+			if m.Resource != rsc.Type {
+				continue
+			}
+
+			for _, inst := range rsc.Instances {
+				addr := rsc.address(inst)
+
+				ident, ok := inst.Attributes[m.Attribute].(string)
+				if !ok || ident == "" {
+					slog.Debug("skipping Terraform resource", "address", addr, "attribute", m.Attribute)
+					continue
+				}
+
+				t := config.Target{
+					Identifier: ident,
+					AssetType:  m.AssetType,
+					Labels: map[string]string{
+						"terraform_address": addr,
+						"terraform_type":    rsc.Type,
+					},
+				}
+				targets = append(targets, t)
+			}
+		}
+	}
+	return targets, nil
+}
+
+// address returns the Terraform address of the provided resource
+// instance.
+func (rsc tfResource) address(inst tfInstance) string {
+	addr := rsc.Type + "." + rsc.Name
+	if rsc.Module != "" {
+		addr = rsc.Module + "." + addr
+	}
+	switch k := inst.IndexKey.(type) {
+	case string:
+		addr += fmt.Sprintf("[%q]", k)
+	case float64:
+		addr += fmt.Sprintf("[%v]", k)
+	}
+	return addr
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 Adevinta
+
+package urlutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ErrInvalidCABundle is returned by [NewClient] when the provided CA
+// bundle does not contain any PEM-encoded certificate.
+var ErrInvalidCABundle = errors.New("invalid CA bundle")
+
+// NewClient returns an HTTP client that honors the proxy
+// configuration of the environment, which is defined by the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables and
+// their lowercase versions. The environment is read when the client
+// is created.
+//
+// If caBundle is not empty, it is the path of a file with
+// PEM-encoded certificates that are trusted in addition to the
+// system root CAs.
+func NewClient(caBundle string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxyFunc := httpproxy.FromEnvironment().ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	if caBundle != "" {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCABundle, caBundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2023 Adevinta
+
+package urlutil
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewClient_caBundle(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "response body")
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+
+	caBundle := filepath.Join(dir, "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caBundle, certPEM, 0o644); err != nil {
+		t.Fatalf("write CA bundle: %v", err)
+	}
+
+	invalidCABundle := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidCABundle, []byte("invalid"), 0o644); err != nil {
+		t.Fatalf("write CA bundle: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		caBundle      string
+		wantClientErr error
+		wantNilErr    bool
+	}{
+		{
+			name:       "trusted CA",
+			caBundle:   caBundle,
+			wantNilErr: true,
+		},
+		{
+			name:       "no CA bundle",
+			caBundle:   "",
+			wantNilErr: false,
+		},
+		{
+			name:          "invalid CA bundle",
+			caBundle:      invalidCABundle,
+			wantClientErr: ErrInvalidCABundle,
+		},
+		{
+			name:          "missing CA bundle",
+			caBundle:      filepath.Join(dir, "not_exist.pem"),
+			wantClientErr: os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(tt.caBundle)
+			if tt.wantClientErr != nil {
+				if !errors.Is(err, tt.wantClientErr) {
+					t.Errorf("unexpected error: want: %v, got: %v", tt.wantClientErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = GetWithOptions(context.Background(), ts.URL, Options{Client: client, MaxAttempts: 1})
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: want nil: %v, got: %v", tt.wantNilErr, err)
+			}
+		})
+	}
+}
+
+func TestNewClient_proxy(t *testing.T) {
+	var got []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.String())
+		fmt.Fprint(w, "response body")
+	}))
+	defer proxy.Close()
+
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("NO_PROXY", "lava.invalid")
+
+	client, err := NewClient("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := GetWithOptions(context.Background(), "http://example.com/checktypes.json", Options{Client: client, MaxAttempts: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "response body"; string(data) != want {
+		t.Errorf("unexpected content: want: %q, got: %q", want, data)
+	}
+
+	// Requests to the hosts in NO_PROXY are sent directly.
+	if _, err := GetWithOptions(context.Background(), "http://lava.invalid/checktypes.json", Options{Client: client, MaxAttempts: 1}); err == nil {
+		t.Errorf("expected error sending request to lava.invalid")
+	}
+
+	want := []string{"http://example.com/checktypes.json"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("proxied requests mismatch (-want +got):\n%v", diff)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 Adevinta
+
+package urlutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// s3Config is merged into the configuration of the AWS session used
+// to retrieve S3 objects. It is a variable, so tests can point to a
+// fake S3 server.
+var s3Config = aws.NewConfig()
+
+// getS3 retrieves the S3 object referenced by the provided s3:// URL.
+func getS3(ctx context.Context, parsedURL *url.URL) ([]byte, error) {
+	bucket := parsedURL.Host
+	key := strings.TrimPrefix(parsedURL.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("%w: missing bucket or key: %v", ErrInvalidURL, parsedURL)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *s3Config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("new AWS session: %w", err)
+	}
+
+	out, err := s3.New(sess).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var aerr awserr.Error
+		if errors.As(err, &aerr) {
+			switch aerr.Code() {
+			case s3.ErrCodeNoSuchKey, s3.ErrCodeNoSuchBucket, "NotFound":
+				return nil, fmt.Errorf("get %q: %w: %w", parsedURL, os.ErrNotExist, err)
+			}
+		}
+		return nil, fmt.Errorf("get %q: %w", parsedURL, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	return data, nil
+}
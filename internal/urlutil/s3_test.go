@@ -0,0 +1,117 @@
+// Copyright 2023 Adevinta
+
+package urlutil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/google/go-cmp/cmp"
+)
+
+// testS3Handler is a fake S3 server that serves a single object. It
+// checks that the requests are signed for the expected region.
+type testS3Handler struct {
+	region string
+}
+
+func (h testS3Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Authorization"), "/"+h.region+"/s3/aws4_request") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/bucket/checktypes.json":
+		fmt.Fprint(w, "response body")
+	case "/bucket/forbidden.json":
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+	}
+}
+
+func TestGet_S3(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		want       []byte
+		wantErr    error
+		wantNilErr bool
+	}{
+		{
+			name:       "valid",
+			url:        "s3://bucket/checktypes.json",
+			want:       []byte("response body"),
+			wantNilErr: true,
+		},
+		{
+			name:    "not found",
+			url:     "s3://bucket/not_found.json",
+			want:    nil,
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name:       "forbidden",
+			url:        "s3://bucket/forbidden.json",
+			want:       nil,
+			wantNilErr: false,
+		},
+		{
+			name:    "missing key",
+			url:     "s3://bucket",
+			want:    nil,
+			wantErr: ErrInvalidURL,
+		},
+	}
+
+	ts := httptest.NewServer(testS3Handler{region: "eu-west-1"})
+	defer ts.Close()
+
+	oldS3Config := s3Config
+	defer func() { s3Config = oldS3Config }()
+	s3Config = aws.NewConfig().
+		WithEndpoint(ts.URL).
+		WithS3ForcePathStyle(true).
+		WithCredentials(credentials.NewStaticCredentials("id", "secret", "")).
+		WithMaxRetries(0)
+
+	// The region is resolved from the environment.
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Get(tt.url)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+				}
+			case (err == nil) != tt.wantNilErr:
+				t.Errorf("unexpected error: want nil: %v, got: %v", tt.wantNilErr, err)
+			}
+
+			if tt.wantErr == nil && !tt.wantNilErr && errors.Is(err, os.ErrNotExist) {
+				t.Errorf("unexpected not exist error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("content mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -4,12 +4,18 @@
 package urlutil
 
 import (
+	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
@@ -20,17 +26,88 @@ var (
 	// ErrInvalidURL is returned by [Get] when the provided URL is
 	// not valid.
 	ErrInvalidURL = errors.New("invalid URL")
+
+	// ErrInvalidGzip is returned by [Get] when the response is
+	// gzip-compressed and the compressed stream is not valid. For
+	// instance, because it is truncated.
+	ErrInvalidGzip = errors.New("invalid gzip stream")
 )
 
-// Get retrieves the contents from a given raw URL. It returns error
-// if the URL is not valid or if it is not possible to get the
-// contents.
+// DefaultClient is the HTTP client used by [Get]. It can be replaced
+// to customize the timeouts, the transport, etc.
+var DefaultClient = http.DefaultClient
+
+// DefaultMaxAttempts is the maximum number of attempts of an HTTP
+// request used when [Options.MaxAttempts] is zero.
+const DefaultMaxAttempts = 4
+
+// defaultBackoff is the time to wait before the first retry used when
+// [Options.Backoff] is zero. It is a variable, so tests can modify
+// it.
+var defaultBackoff = time.Second
+
+// maxBackoff is the maximum time to wait between retries.
+const maxBackoff = 30 * time.Second
+
+// Options are the options of [GetWithOptions].
+type Options struct {
+	// Client is the HTTP client used to send the requests. If
+	// nil, [DefaultClient] is used.
+	Client *http.Client
+
+	// MaxAttempts is the maximum number of attempts of an HTTP
+	// request. If zero, [DefaultMaxAttempts] is used. A value of
+	// 1 disables retries.
+	MaxAttempts int
+
+	// Backoff is the time to wait before the first retry. It is
+	// doubled with every retry. If zero, 1 second is used.
+	Backoff time.Duration
+
+	// Header contains the headers added to the HTTP requests. For
+	// instance, the Authorization header. It is ignored by the
+	// schemes other than http and https.
+	Header http.Header
+}
+
+// Get retrieves the contents from a given raw URL using
+// [DefaultClient]. It returns error if the URL is not valid or if it
+// is not possible to get the contents.
 //
-// It supports the following schemes: http, https. If the provided URL
-// does not specify a scheme, it is considered a file path. In the
+// It supports the following schemes: http, https, s3. If the provided
+// URL does not specify a scheme, it is considered a file path. In the
 // case of http and https, the contents are retrieved issuing an HTTP
-// GET request.
+// GET request. Transient failures are retried with the default
+// options. See [GetWithOptions]. In the case of s3, the URL must have
+// the form s3://bucket/key. See [GetWithOptions] for more details.
 func Get(rawURL string) ([]byte, error) {
+	return GetWithOptions(context.Background(), rawURL, Options{})
+}
+
+// GetWithClient is like [Get] but uses the provided HTTP client. If
+// client is nil, [DefaultClient] is used.
+func GetWithClient(client *http.Client, rawURL string) ([]byte, error) {
+	return GetWithOptions(context.Background(), rawURL, Options{Client: client})
+}
+
+// GetWithOptions is like [Get] but accepts a context and the
+// options of the HTTP requests. The context allows to set a deadline
+// for all the attempts.
+//
+// The requests accept gzip-compressed responses, which are
+// transparently decompressed.
+//
+// Connection errors and the responses with status codes 429 and 5xx
+// are retried with exponential backoff. If the response contains a
+// Retry-After header, it is honored. Any other status code different
+// from 200 makes it fail immediately.
+//
+// S3 objects are retrieved using the default credential chain of the
+// AWS SDK. The region is resolved from the environment and the shared
+// config files, following the usual precedence. If the object does
+// not exist, the returned error wraps [os.ErrNotExist]. The options
+// are ignored.
+func GetWithOptions(ctx context.Context, rawURL string, opts Options) ([]byte, error) {
 	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
@@ -38,23 +115,197 @@ func Get(rawURL string) ([]byte, error) {
 
 	switch parsedURL.Scheme {
 	case "http", "https":
-		return getHTTP(parsedURL)
+		return getHTTP(ctx, parsedURL, opts)
+	case "s3":
+		return getS3(ctx, parsedURL)
 	case "":
 		return os.ReadFile(parsedURL.Path)
 	}
 	return nil, fmt.Errorf("%w: %v", ErrInvalidScheme, parsedURL.Scheme)
 }
 
-// getHTTP retrieves the contents of a given HTTP URL.
-func getHTTP(parsedURL *url.URL) ([]byte, error) {
-	resp, err := http.Get(parsedURL.String())
+// getHTTP retrieves the contents of a given HTTP URL using the
+// provided options.
+func getHTTP(ctx context.Context, parsedURL *url.URL, opts Options) ([]byte, error) {
+	client := opts.Client
+	if client == nil {
+		client = DefaultClient
+	}
+
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	backoff := opts.Backoff
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		data, retryAfter, err := tryGetHTTP(ctx, client, parsedURL, opts.Header)
+		if err == nil {
+			return data, nil
+		}
+
+		var rerr retryableError
+		if !errors.As(err, &rerr) || attempt >= maxAttempts {
+			return nil, err
+		}
+
+		wait := backoff
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		slog.Debug("retrying HTTP request", "url", parsedURL, "attempt", attempt, "wait", wait, "err", err)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, fmt.Errorf("get %q: %w", parsedURL, errors.Join(ctx.Err(), err))
+		case <-t.C:
+		}
+
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// maxErrorBodySize is the maximum number of bytes of the response
+// body stored in an [HTTPError].
+const maxErrorBodySize = 4096
+
+// HTTPError is the error returned by [Get] when the server replies
+// with an unexpected status code.
+type HTTPError struct {
+	// URL is the requested URL.
+	URL string
+
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Body is the body of the response. It is truncated to 4KiB.
+	Body []byte
+}
+
+// NewHTTPError returns an [HTTPError] for the provided response. It
+// reads the body of the response, but it does not close it.
+func NewHTTPError(url string, resp *http.Response) HTTPError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	return HTTPError{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+}
+
+// Error implements the error interface.
+func (err HTTPError) Error() string {
+	return fmt.Sprintf("get %q: invalid status code: %v", err.URL, err.StatusCode)
+}
+
+// retryableError is an error that can be solved by retrying the
+// request.
+type retryableError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (err retryableError) Error() string {
+	return err.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err retryableError) Unwrap() error {
+	return err.err
+}
+
+// tryGetHTTP sends a single GET request with the provided headers to
+// the provided URL. If the request fails with a transient error, the
+// returned error is a [retryableError]. In that case, retryAfter is
+// the time to wait before retrying requested by the server, or zero
+// if not specified.
+func tryGetHTTP(ctx context.Context, client *http.Client, parsedURL *url.URL, header http.Header) (data []byte, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsedURL.String(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("new request: %w", err)
+	}
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	// Setting the Accept-Encoding header explicitly disables the
+	// transparent decompression of the transport, so the
+	// compressed responses are handled consistently regardless of
+	// the transport used by the client.
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("get %q: %w", parsedURL, err)
+		err = fmt.Errorf("get %q: %w", parsedURL, err)
+		if ctx.Err() != nil {
+			return nil, 0, err
+		}
+		return nil, 0, retryableError{err}
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("get %q: invalid status code: %v", parsedURL, resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		err := NewHTTPError(parsedURL.String(), resp)
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), retryableError{err}
+	default:
+		return nil, 0, NewHTTPError(parsedURL.String(), resp)
+	}
+
+	data, err = readBody(resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, 0, nil
+}
+
+// readBody reads the body of the provided response. If the body is
+// gzip-compressed, it is decompressed.
+func readBody(resp *http.Response) ([]byte, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read body: %w", err)
+		}
+		return data, nil
+	}
+
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidGzip, err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidGzip, err)
+	}
+	return data, nil
+}
+
+// parseRetryAfter returns the duration specified by the provided
+// Retry-After header value, which can be a number of seconds or an
+// HTTP date. It returns zero if the value is not valid or it is in
+// the past. The returned duration is capped to [maxBackoff].
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	var d time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		d = time.Until(t)
 	}
-	return io.ReadAll(resp.Body)
+	return max(0, min(d, maxBackoff))
 }
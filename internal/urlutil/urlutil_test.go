@@ -3,12 +3,19 @@
 package urlutil
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -61,6 +68,367 @@ func TestGet_HTTP(t *testing.T) {
 	}
 }
 
+func TestGetWithOptions_HTTPError(t *testing.T) {
+	tests := []struct {
+		name        string
+		handlerFunc func(http.ResponseWriter, *http.Request)
+		want        HTTPError
+	}{
+		{
+			name: "forbidden",
+			handlerFunc: func(writer http.ResponseWriter, request *http.Request) {
+				http.Error(writer, "access denied", http.StatusForbidden)
+			},
+			want: HTTPError{
+				StatusCode: http.StatusForbidden,
+				Body:       []byte("access denied\n"),
+			},
+		},
+		{
+			name: "internal server error",
+			handlerFunc: func(writer http.ResponseWriter, request *http.Request) {
+				writer.WriteHeader(http.StatusInternalServerError)
+			},
+			want: HTTPError{
+				StatusCode: http.StatusInternalServerError,
+				Body:       []byte{},
+			},
+		},
+		{
+			name: "truncated body",
+			handlerFunc: func(writer http.ResponseWriter, request *http.Request) {
+				writer.WriteHeader(http.StatusBadGateway)
+				writer.Write(bytes.Repeat([]byte("a"), 2*maxErrorBodySize)) //nolint:errcheck
+			},
+			want: HTTPError{
+				StatusCode: http.StatusBadGateway,
+				Body:       bytes.Repeat([]byte("a"), maxErrorBodySize),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(tt.handlerFunc))
+			defer ts.Close()
+
+			_, err := GetWithOptions(context.Background(), ts.URL, Options{MaxAttempts: 1})
+
+			var got HTTPError
+			if !errors.As(err, &got) {
+				t.Fatalf("error is not an HTTPError: %v", err)
+			}
+
+			tt.want.URL = ts.URL
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("HTTP error mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+// roundTripperFunc is an [http.RoundTripper] implemented by a
+// function.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls fn(req).
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestGet_gzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte("response body")); err != nil {
+		t.Fatalf("write gzip: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close gzip writer: %v", err)
+	}
+	compressed := buf.Bytes()
+
+	tests := []struct {
+		name        string
+		handlerFunc func(http.ResponseWriter, *http.Request)
+		want        []byte
+		wantErr     error
+		wantNilErr  bool
+	}{
+		{
+			name: "compressed",
+			handlerFunc: func(writer http.ResponseWriter, request *http.Request) {
+				if request.Header.Get("Accept-Encoding") != "gzip" {
+					http.Error(writer, "gzip not accepted", http.StatusBadRequest)
+					return
+				}
+				writer.Header().Set("Content-Encoding", "gzip")
+				writer.Write(compressed) //nolint:errcheck
+			},
+			want:       []byte("response body"),
+			wantNilErr: true,
+		},
+		{
+			name: "plain",
+			handlerFunc: func(writer http.ResponseWriter, request *http.Request) {
+				fmt.Fprint(writer, "response body")
+			},
+			want:       []byte("response body"),
+			wantNilErr: true,
+		},
+		{
+			name: "truncated",
+			handlerFunc: func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Encoding", "gzip")
+				writer.Write(compressed[:len(compressed)-8]) //nolint:errcheck
+			},
+			want:    nil,
+			wantErr: ErrInvalidGzip,
+		},
+		{
+			name: "not gzip",
+			handlerFunc: func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Encoding", "gzip")
+				fmt.Fprint(writer, "response body")
+			},
+			want:    nil,
+			wantErr: ErrInvalidGzip,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(tt.handlerFunc))
+			defer ts.Close()
+
+			got, err := Get(ts.URL)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+				}
+			case (err == nil) != tt.wantNilErr:
+				t.Errorf("unexpected error: want nil: %v, got: %v", tt.wantNilErr, err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("content mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestGetWithClient(t *testing.T) {
+	var got []string
+	client := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			got = append(got, req.URL.String())
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("response body")),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	data, err := GetWithClient(client, "https://example.com/checktypes.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "response body"; string(data) != want {
+		t.Errorf("unexpected content: want: %q, got: %q", want, data)
+	}
+
+	want := []string{"https://example.com/checktypes.json"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("requests mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestGetWithClient_nil(t *testing.T) {
+	oldDefaultClient := DefaultClient
+	defer func() { DefaultClient = oldDefaultClient }()
+
+	var got []string
+	DefaultClient = &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			got = append(got, req.URL.String())
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("response body")),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	if _, err := GetWithClient(nil, "https://example.com/checktypes.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"https://example.com/checktypes.json"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("requests mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestGet_DefaultClient(t *testing.T) {
+	oldDefaultClient := DefaultClient
+	defer func() { DefaultClient = oldDefaultClient }()
+
+	oldDefaultBackoff := defaultBackoff
+	defer func() { defaultBackoff = oldDefaultBackoff }()
+	defaultBackoff = time.Millisecond
+
+	wantErr := errors.New("transport error")
+	DefaultClient = &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, wantErr
+		}),
+	}
+
+	if _, err := Get("https://example.com/checktypes.json"); !errors.Is(err, wantErr) {
+		t.Errorf("unexpected error: want: %v, got: %v", wantErr, err)
+	}
+}
+
+func TestGetWithOptions_retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		maxAttempts  int
+		want         []byte
+		wantNilErr   bool
+		wantAttempts int
+	}{
+		{
+			name:         "no retries",
+			statuses:     []int{http.StatusOK},
+			want:         []byte("response body"),
+			wantNilErr:   true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "server errors",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			want:         []byte("response body"),
+			wantNilErr:   true,
+			wantAttempts: 3,
+		},
+		{
+			name:         "too many requests",
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:   "0",
+			want:         []byte("response body"),
+			wantNilErr:   true,
+			wantAttempts: 2,
+		},
+		{
+			name:         "max attempts",
+			statuses:     []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK},
+			maxAttempts:  2,
+			want:         nil,
+			wantNilErr:   false,
+			wantAttempts: 2,
+		},
+		{
+			name:         "not found",
+			statuses:     []int{http.StatusNotFound, http.StatusOK},
+			want:         nil,
+			wantNilErr:   false,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				status := tt.statuses[min(n, len(tt.statuses))-1]
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					fmt.Fprint(w, "response body")
+				}
+			}))
+			defer ts.Close()
+
+			opts := Options{MaxAttempts: tt.maxAttempts, Backoff: time.Millisecond}
+			got, err := GetWithOptions(context.Background(), ts.URL, opts)
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: want nil: %v, got: %v", tt.wantNilErr, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("content mismatch (-want +got):\n%v", diff)
+			}
+			if n := int(attempts.Load()); n != tt.wantAttempts {
+				t.Errorf("unexpected number of attempts: want: %v, got: %v", tt.wantAttempts, n)
+			}
+		})
+	}
+}
+
+func TestGetWithOptions_deadline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	opts := Options{MaxAttempts: 100, Backoff: time.Hour}
+	if _, err := GetWithOptions(ctx, ts.URL, opts); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error: want: %v, got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{
+			name:  "seconds",
+			value: "5",
+			want:  5 * time.Second,
+		},
+		{
+			name:  "capped",
+			value: "3600",
+			want:  maxBackoff,
+		},
+		{
+			name:  "date in the past",
+			value: "Wed, 21 Oct 2015 07:28:00 GMT",
+			want:  0,
+		},
+		{
+			name:  "invalid",
+			value: "invalid",
+			want:  0,
+		},
+		{
+			name:  "empty",
+			value: "",
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value); got != tt.want {
+				t.Errorf("unexpected duration: want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestGet_URL(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -0,0 +1,65 @@
+// Copyright 2023 Adevinta
+
+// Package workdir manages the directory where Lava stores transient
+// and cached data.
+package workdir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Subdirectories of the Lava home directory.
+const (
+	// Catalogs contains cached checktype catalogs.
+	Catalogs = "catalogs"
+
+	// Git contains the Git repositories served by Lava.
+	Git = "git"
+)
+
+// Home returns the Lava home directory. It is taken from the
+// LAVA_HOME environment variable. If LAVA_HOME is not set, the
+// directory "lava" under the user cache directory is used. For
+// instance, "$XDG_CACHE_HOME/lava" on Linux.
+func Home() (string, error) {
+	if home := os.Getenv("LAVA_HOME"); home != "" {
+		return home, nil
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("get user cache dir: %w", err)
+	}
+	return filepath.Join(cacheDir, "lava"), nil
+}
+
+// Dir returns the path of the named subdirectory of the Lava home
+// directory. The directory is created if it does not exist.
+func Dir(name string) (string, error) {
+	home, err := Home()
+	if err != nil {
+		return "", fmt.Errorf("get Lava home: %w", err)
+	}
+
+	dir := filepath.Join(home, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("make dir: %w", err)
+	}
+	return dir, nil
+}
+
+// MkdirTemp creates a new temporary directory in the named
+// subdirectory of the Lava home directory and returns its path. The
+// pattern is interpreted like in [os.MkdirTemp]. Temporary
+// directories are not shared, so concurrent runs do not interfere
+// with each other. It is the caller's responsibility to remove the
+// directory when no longer needed.
+func MkdirTemp(name, pattern string) (string, error) {
+	dir, err := Dir(name)
+	if err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(dir, pattern)
+}
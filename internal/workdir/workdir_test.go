@@ -0,0 +1,65 @@
+// Copyright 2023 Adevinta
+
+package workdir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHome(t *testing.T) {
+	t.Run("LAVA_HOME", func(t *testing.T) {
+		t.Setenv("LAVA_HOME", "/lava/home")
+
+		got, err := Home()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := "/lava/home"; got != want {
+			t.Errorf("unexpected home: got: %v, want: %v", got, want)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Setenv("LAVA_HOME", "")
+		t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+		t.Setenv("HOME", "/home/user")
+
+		got, err := Home()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if filepath.Base(got) != "lava" {
+			t.Errorf("unexpected home: %v", got)
+		}
+	})
+}
+
+func TestMkdirTemp(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("LAVA_HOME", home)
+
+	dir1, err := MkdirTemp(Git, "*.git")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dir2, err := MkdirTemp(Git, "*.git")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if dir1 == dir2 {
+		t.Errorf("temporary directories are not unique: %v", dir1)
+	}
+
+	for _, dir := range []string{dir1, dir2} {
+		if !strings.HasPrefix(dir, filepath.Join(home, Git)+string(filepath.Separator)) {
+			t.Errorf("unexpected parent directory: %v", dir)
+		}
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			t.Errorf("directory was not created: %v", dir)
+		}
+	}
+}